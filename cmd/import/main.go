@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/pedramktb/go-base-lib/pkg/env"
+	"go.uber.org/fx"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+	userDI "github.com/pedramktb/schwarzit-probearbeit/internal/user/fx"
+	userImport "github.com/pedramktb/schwarzit-probearbeit/internal/user/import"
+	"github.com/pedramktb/schwarzit-probearbeit/pkg/postgres"
+	"github.com/pedramktb/schwarzit-probearbeit/pkg/redis"
+)
+
+// Imports users in bulk from a CSV or NDJSON file (or stdin with -) and prints the report as JSON,
+// exits with 1 if the import or any row failed
+func main() {
+	format := flag.String("format", "", "format of the input (csv or ndjson), defaults to the file extension")
+	dryRun := flag.Bool("dry-run", false, "only validate the rows and report what would happen")
+	upsert := flag.Bool("upsert", false, "save rows with the email of an existing user as a new version of that user")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <file|->\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	path := flag.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	if !types.ImportFormat(*format).IsValid() {
+		fmt.Fprintf(os.Stderr, "unknown format %q, use -format csv or -format ndjson\n", *format)
+		os.Exit(2)
+	}
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var report types.ImportReport
+	app := fx.New(
+		fx.NopLogger,
+		postgres.FXPostgresModule,
+		redis.FXRedisModule,
+		userDI.FXUserModule,
+		fx.Invoke(func(importer *userImport.Importer) (err error) {
+			// The command has direct access to the database and imports as an admin
+			report, err = importer.Import(context.Background(), usecase.Actor{IsAdmin: true}, input, types.ImportFormat(*format), types.ImportOptions{
+				DryRun: *dryRun,
+				Upsert: *upsert,
+			})
+			return err
+		}),
+	)
+	if err := app.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(dtos.FromImportReport(&report)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
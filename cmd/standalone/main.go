@@ -3,9 +3,12 @@ package main
 import (
 	"go.uber.org/fx"
 
+	addressDI "github.com/pedramktb/schwarzit-probearbeit/internal/address/fx"
 	authDI "github.com/pedramktb/schwarzit-probearbeit/internal/auth/fx"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/event"
 	ginDI "github.com/pedramktb/schwarzit-probearbeit/internal/gin/fx"
 	userDI "github.com/pedramktb/schwarzit-probearbeit/internal/user/fx"
+	webhookDI "github.com/pedramktb/schwarzit-probearbeit/internal/webhook/fx"
 	"github.com/pedramktb/schwarzit-probearbeit/pkg/postgres"
 	"github.com/pedramktb/schwarzit-probearbeit/pkg/redis"
 )
@@ -18,7 +21,10 @@ func init() {
 		redis.FXRedisModule,
 		authDI.FXAuthModule,
 		userDI.FXUserModule,
+		addressDI.FXAddressModule,
+		webhookDI.FXWebhookModule,
 		ginDI.FXGinRoutersModule,
+		event.FXEventModule,
 	)
 }
 
@@ -22,7 +22,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Query users",
+                "description": "Query users, the pages can be navigated by offset or by the returned cursors",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,6 +34,18 @@ const docTemplate = `{
                 ],
                 "summary": "Query users",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "example": "Berlin",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from next_cursor or prev_cursor of a previous response, replaces the offset.\nIt is only valid with the same filters and sort as the query it was returned from.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "format": "email",
@@ -41,6 +53,27 @@ const docTemplate = `{
                         "name": "email",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "example": "versions,addresses",
+                        "description": "Expand embeds the comma separated related data (versions, addresses), requires admin access.\nSessions can't be expanded and fail with invalid_expand, the tokens are stateless and there are no sessions.",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "id,first_name,last_name",
+                        "description": "Fields limits the attributes of the users to the comma separated fields\n(id, version_id, first_name, last_name, email, phone, created_at, updated_at)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "last_name:prefix:Mü|first_name:prefix:Mü,created_at:gte:2024-01-01",
+                        "description": "Filter expression ` + "`" + `field:operator:value` + "`" + `, conditions are combined with ` + "`" + `,` + "`" + ` (AND) and ` + "`" + `|` + "`" + ` (OR, binding stronger).\nFields: id, version_id, first_name, last_name, email, phone, is_admin, created_at, updated_at,\nstreet, street_number, zip_code, city (address fields match any address of the user).\nOperators: eq, ne, gt, gte, lt, lte, in (values separated by ` + "`" + `;` + "`" + `) and the case-insensitive prefix, suffix, contains.\n` + "`" + `\\` + "`" + ` escapes ` + "`" + `,` + "`" + `, ` + "`" + `|` + "`" + `, ` + "`" + `;` + "`" + ` and ` + "`" + `\\` + "`" + ` in values.",
+                        "name": "filter",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "example": "John",
@@ -61,12 +94,15 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
+                        "maximum": 100,
+                        "minimum": 0,
                         "type": "integer",
                         "example": 10,
                         "name": "limit",
                         "in": "query"
                     },
                     {
+                        "minimum": 0,
                         "type": "integer",
                         "example": 0,
                         "name": "offset",
@@ -79,24 +115,79 @@ const docTemplate = `{
                         "name": "phone",
                         "in": "query"
                     },
+                    {
+                        "maxLength": 255,
+                        "type": "string",
+                        "example": "jon doe",
+                        "description": "Search for partial or misspelled names, emails and phone numbers, the results are sorted by relevance by default",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "last_name,-created_at",
+                        "description": "Sort by comma separated fields, a ` + "`" + `-` + "`" + ` prefix sorts descending, ties are broken by id.\nFields: id, first_name, last_name, email, created_at, updated_at and relevance (only with q).",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": false,
+                        "description": "Total requests the number of all matching users in the response and the X-Total-Count header",
+                        "name": "total",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "format": "uuid",
                         "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad",
                         "name": "version_id",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12345",
+                        "name": "zip_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached page, the response is 304 if the page is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/User"
-                                }
+                            "$ref": "#/definitions/UserPage"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Revalidation is required before using a stored page"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak entity tag of the page"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of all matching users, if requested with total"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Revalidation is required before using a stored page"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak entity tag of the page"
                             }
                         }
                     },
@@ -153,6 +244,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/SaveUser"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, retries with the same key replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -160,6 +257,16 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user"
+                            },
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "true if the response is the replay of an earlier request"
+                            }
                         }
                     },
                     "400": {
@@ -186,6 +293,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict Error, the email is taken or the idempotency key was used",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -195,26 +314,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/users/me": {
-            "get": {
+        "/api/v1/users/batch": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get me as a user",
+                "description": "Apply up to 100 create, patch and delete operations at once and get a result per operation in the same order.\nAtomic batches are applied in one transaction: if an operation fails, nothing is applied and the response has the status of the failed operation,\nthe other operations have the status 424. Otherwise each operation is applied on its own and the response is 200 even if some operations failed.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "user"
                 ],
-                "summary": "Get me (user)",
+                "summary": "Mutate many users",
+                "parameters": [
+                    {
+                        "description": "Operations",
+                        "name": "operations",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/BatchMutateUsers"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, retries with the same key replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/User"
+                            "$ref": "#/definitions/BatchMutateUsersResponse"
                         }
                     },
                     "400": {
@@ -236,7 +375,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Not Found Error",
+                        "description": "Not Found Error of an atomic batch",
+                        "schema": {
+                            "$ref": "#/definitions/BatchMutateUsersResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict Error of the idempotency key",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -248,14 +393,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/users/batch-get": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update me as a user",
+                "description": "Get up to 100 users by id at once, duplicated ids are returned once.\nThe found users are returned in the requested order, the ids of users that don't exist are listed as missing",
                 "consumes": [
                     "application/json"
                 ],
@@ -265,15 +412,15 @@ const docTemplate = `{
                 "tags": [
                     "user"
                 ],
-                "summary": "Update me (user)",
+                "summary": "Get many users",
                 "parameters": [
                     {
-                        "description": "User",
-                        "name": "user",
+                        "description": "User IDs",
+                        "name": "ids",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/SaveUser"
+                            "$ref": "#/definitions/BatchGetUsers"
                         }
                     }
                 ],
@@ -281,7 +428,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/User"
+                            "$ref": "#/definitions/BatchGetUsersResponse"
                         }
                     },
                     "400": {
@@ -296,18 +443,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden Error",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found Error",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -315,24 +450,47 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/users/changes": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Delete me as a user",
+                "description": "Get the creates, updates (new versions) and deletes of users in the order they were made, starting after the since token.\nContinue with the next_since token of the response to get the following changes, a token never expires.\nChanges are only listed once they are a few seconds old, so that changes committed later with an earlier timestamp are not skipped.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "user"
                 ],
-                "summary": "Delete me (user)",
+                "summary": "Get the changes of users",
+                "parameters": [
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 100,
+                        "description": "Limit is the maximum number of changes, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "eyJzIjoiY2hhbmdlZF9hdCxjaGFuZ2VfaWQiLCJ2IjpbXX0.c2lnbmF0dXJl",
+                        "description": "Since is the next_since token of a previous response, the feed starts at the beginning without it",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserChanges"
+                        }
                     },
                     "400": {
                         "description": "Bad Request Error",
@@ -352,12 +510,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found Error",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -365,40 +517,55 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/api/v1/users/changes/stream": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Patch me as a user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stream the changes of users as Server-Sent Events, the data of each event is a UserChange and its id the token to continue after it.\nAll changes after the since token (or the Last-Event-ID header on reconnects) are sent first, then changes are pushed as they are committed.\nChanges are delivered at least once, a change made while catching up might be sent twice.\nThe stream ends if the server can't keep up, clients should reconnect with the id of the last event.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
                     "user"
                 ],
-                "summary": "Patch me (user)",
+                "summary": "Stream the changes of users",
                 "parameters": [
                     {
-                        "description": "User",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/PatchUser"
-                        }
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 100,
+                        "description": "Limit is the maximum number of changes, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "eyJzIjoiY2hhbmdlZF9hdCxjaGFuZ2VfaWQiLCJ2IjpbXX0.c2lnbmF0dXJl",
+                        "description": "Since is the next_since token of a previous response, the feed starts at the beginning without it",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token to continue after, overrides since",
+                        "name": "Last-Event-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/UserChange"
+                            }
                         }
                     },
                     "400": {
@@ -419,12 +586,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found Error",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -434,31 +595,127 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/users/{id}": {
+        "/api/v1/users/export": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get a user by id",
+                "description": "Stream all users matching the filters (as in the query endpoint) as CSV, NDJSON or Parquet, one row per user without the password.\nWith history every version of the matching users is exported, ordered by user and version.\nErrors after the first rows were sent can only be signaled by aborting the response.",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
                 ],
                 "tags": [
                     "user"
                 ],
-                "summary": "Get a user",
+                "summary": "Export users",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "example": "Berlin",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "email",
+                        "example": "abc@xyz.com",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "last_name:prefix:Mü|first_name:prefix:Mü,created_at:gte:2024-01-01",
+                        "description": "Filter expression ` + "`" + `field:operator:value` + "`" + `, conditions are combined with ` + "`" + `,` + "`" + ` (AND) and ` + "`" + `|` + "`" + ` (OR, binding stronger).\nFields: id, version_id, first_name, last_name, email, phone, is_admin, created_at, updated_at,\nstreet, street_number, zip_code, city (address fields match any address of the user).\nOperators: eq, ne, gt, gte, lt, lte, in (values separated by ` + "`" + `;` + "`" + `) and the case-insensitive prefix, suffix, contains.\n` + "`" + `\\` + "`" + ` escapes ` + "`" + `,` + "`" + `, ` + "`" + `|` + "`" + `, ` + "`" + `;` + "`" + ` and ` + "`" + `\\` + "`" + ` in values.",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "John",
+                        "name": "first_name",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "parquet"
+                        ],
+                        "type": "string",
+                        "example": "csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": false,
+                        "description": "History exports all versions of the matching users instead of only their latest version",
+                        "name": "history",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad",
                         "name": "id",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Doe",
+                        "name": "last_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "phone",
+                        "example": "+49123456789",
+                        "name": "phone",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 255,
+                        "type": "string",
+                        "example": "jon doe",
+                        "description": "Search for partial or misspelled names, emails and phone numbers, the results are sorted by relevance by default",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "last_name,-created_at",
+                        "description": "Sort by comma separated fields, a ` + "`" + `-` + "`" + ` prefix sorts descending, ties are broken by id.\nFields: id, first_name, last_name, email, created_at, updated_at and relevance (only with q).",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad",
+                        "name": "version_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12345",
+                        "name": "zip_code",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/UserExportRow"
+                            }
+                        }
+                    },
                     "400": {
                         "description": "Bad Request Error",
                         "schema": {
@@ -477,12 +734,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found Error",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -490,16 +741,19 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "security": [
+            }
+        },
+        "/api/v1/users/import": {
+            "post": {
+                "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update a user by id",
+                "description": "Create users in bulk from CSV (with a header of the columns first_name, last_name, email, phone, password and the optional is_admin)\nor NDJSON (one SaveUser per line). Every row is validated like a created user, the valid rows are saved in batches of one transaction each.\nThe report lists the outcome of every row, failing rows don't prevent the import of the others.",
                 "consumes": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "produces": [
                     "application/json"
@@ -507,22 +761,40 @@ const docTemplate = `{
                 "tags": [
                     "user"
                 ],
-                "summary": "Update a user",
+                "summary": "Import users",
                 "parameters": [
                     {
+                        "type": "boolean",
+                        "example": false,
+                        "description": "DryRun only validates the rows and reports what would happen, nothing is saved",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
                         "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "example": "csv",
+                        "description": "Format of the request body, defaults to the format of the Content-Type (text/csv or application/x-ndjson)",
+                        "name": "format",
+                        "in": "query"
                     },
                     {
-                        "description": "User",
-                        "name": "user",
+                        "type": "boolean",
+                        "example": false,
+                        "description": "Upsert saves rows with the email of an existing user as a new version of that user, otherwise they fail.\nThe admin flag of the existing user is kept unless the row sets is_admin.",
+                        "name": "upsert",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Users",
+                        "name": "users",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/SaveUser"
+                            "type": "string"
                         }
                     }
                 ],
@@ -530,7 +802,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/User"
+                            "$ref": "#/definitions/ImportReport"
                         }
                     },
                     "400": {
@@ -551,12 +823,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found Error",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -564,33 +830,74 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/users/me": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Delete a user by id",
+                "description": "Get me as a user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "user"
                 ],
-                "summary": "Delete a user",
+                "summary": "Get me (user)",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "ETag of a cached user, the response is 304 if it is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time of a cached user, ignored with If-None-Match",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Private, revalidation is required before using a stored user"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Creation time of the version"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Private, revalidation is required before using a stored user"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Creation time of the version"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request Error",
@@ -624,13 +931,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Patch a user by id",
+                "description": "Update me as a user",
                 "consumes": [
                     "application/json"
                 ],
@@ -640,23 +947,22 @@ const docTemplate = `{
                 "tags": [
                     "user"
                 ],
-                "summary": "Patch a user",
+                "summary": "Update me (user)",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "description": "User",
                         "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/PatchUser"
+                            "$ref": "#/definitions/SaveUser"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version the change is based on, the change fails if it is not the latest one",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -664,6 +970,12 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user"
+                            }
                         }
                     },
                     "400": {
@@ -690,6 +1002,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict Error, the email is taken",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -697,50 +1027,59 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/auth/login": {
-            "post": {
-                "description": "user login",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete me as a user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "user"
                 ],
-                "summary": "Login",
+                "summary": "Delete me (user)",
                 "parameters": [
                     {
-                        "description": "Login Request",
-                        "name": "login",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/LoginRequest"
-                        }
+                        "type": "string",
+                        "description": "ETag of the version the change is based on, the change fails if it is not the latest one",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/AuthResponse"
-                        }
+                        "description": "OK"
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Bad Request Error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed Error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -752,42 +1091,94 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/auth/refresh": {
-            "post": {
-                "description": "refresh token",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Patch me as a user\nThe body is a partial user (null fields are ignored), a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) by the Content-Type.\nJSON Patches are applied to the writable fields first_name, last_name, email, phone and is_admin, a password can be added.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "user"
                 ],
-                "summary": "Refresh",
+                "summary": "Patch me (user)",
                 "parameters": [
+                    {
+                        "description": "User, or a JSON Patch as array of operations ({op, path, from, value}) for application/json-patch+json",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PatchUser"
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "Refresh Token",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "description": "ETag of the version the change is based on, the change fails if it is not the latest one",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/AuthResponse"
+                            "$ref": "#/definitions/User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Bad Request Error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict Error, the email is taken or a test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -801,36 +1192,88 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "user registration",
+        "/api/v1/users/me/addresses": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Query my addresses as a user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "address"
                 ],
-                "summary": "Register",
+                "summary": "Query my addresses (user)",
                 "parameters": [
                     {
-                        "description": "Register User",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/RegisterUser"
-                        }
+                        "type": "boolean",
+                        "example": true,
+                        "name": "is_default",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "billing",
+                            "shipping",
+                            "home"
+                        ],
+                        "type": "string",
+                        "example": "home",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 0,
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/User"
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/UserAddress"
+                                }
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -842,55 +1285,3165 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create an address of me as a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Create my address (user)",
+                "parameters": [
+                    {
+                        "description": "Address",
+                        "name": "address",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SaveUserAddress"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/addresses/{address_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get an address of me as a user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Get my address (user)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address ID",
+                        "name": "address_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update an address of me as a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Update my address (user)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address ID",
+                        "name": "address_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Address",
+                        "name": "address",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SaveUserAddress"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete an address of me as a user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Delete my address (user)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address ID",
+                        "name": "address_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Patch an address of me as a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Patch my address (user)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address ID",
+                        "name": "address_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Address",
+                        "name": "address",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PatchUserAddress"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Export all data held about me as a user (subject access request): the profile, the version history, the addresses and the audit events.\nThere are no sessions (tokens are stateless), API keys or consents to export.\nLarge exports are generated asynchronously, in which case an export job is returned which can be downloaded once ready",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Export me (user)",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "zip"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserExport"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests Error of asynchronous exports",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/exports/{export_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the status of an asynchronously generated export of me as a user or download it once ready",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get my export (user)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export ID",
+                        "name": "export_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserExport"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get a user by id, optionally limited to some fields or with related data embedded",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "versions,addresses",
+                        "description": "Expand embeds the comma separated related data (versions, addresses), requires admin access.\nSessions can't be expanded and fail with invalid_expand, the tokens are stateless and there are no sessions.",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "id,first_name,last_name",
+                        "description": "Fields limits the attributes of the users to the comma separated fields\n(id, version_id, first_name, last_name, email, phone, created_at, updated_at)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached user, the response is 304 if it is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time of a cached user, ignored with If-None-Match",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Revalidation is required before using a stored user"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user, weak if limited to some fields or with related data embedded"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Creation time of the version, only without fields and expand"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Revalidation is required before using a stored user"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user, weak if limited to some fields or with related data embedded"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Creation time of the version, only without fields and expand"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update a user by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SaveUser"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version the change is based on, the change fails if it is not the latest one",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict Error, the email is taken",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete a user by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version the change is based on, the change fails if it is not the latest one",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Patch a user by id\nThe body is a partial user (null fields are ignored), a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) by the Content-Type.\nJSON Patches are applied to the writable fields first_name, last_name, email, phone and is_admin, a password can be added.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Patch a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User, or a JSON Patch as array of operations ({op, path, from, value}) for application/json-patch+json",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PatchUser"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version the change is based on, the change fails if it is not the latest one",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict Error, the email is taken or a test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/addresses": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Query the addresses of a user by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Query addresses of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "name": "is_default",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "billing",
+                            "shipping",
+                            "home"
+                        ],
+                        "type": "string",
+                        "example": "home",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 0,
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/UserAddress"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create an address of a user by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Create an address of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Address",
+                        "name": "address",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SaveUserAddress"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/addresses/{address_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get an address of a user by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Get an address of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address ID",
+                        "name": "address_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update an address of a user by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Update an address of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address ID",
+                        "name": "address_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Address",
+                        "name": "address",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SaveUserAddress"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete an address of a user by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Delete an address of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address ID",
+                        "name": "address_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Patch an address of a user by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "address"
+                ],
+                "summary": "Patch an address of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address ID",
+                        "name": "address_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Address",
+                        "name": "address",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PatchUserAddress"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Export all data held about a user by id (subject access request): the profile, the version history, the addresses and the audit events.\nThere are no sessions (tokens are stateless), API keys or consents to export.\nLarge exports are generated asynchronously, in which case an export job is returned which can be downloaded once ready",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Export a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "zip"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserExport"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests Error of asynchronous exports",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/exports/{export_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get the status of an asynchronously generated export of a user by id or download it once ready",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get a user export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export ID",
+                        "name": "export_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserExport"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Query the webhooks, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Query webhooks (admin)",
+                "parameters": [
+                    {
+                        "maximum": 100,
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 0,
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/Webhook"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Subscribe an endpoint to kinds of user events. Each event is posted as JSON (UserEvent) with the headers\nX-Webhook-Id (the event id), X-Webhook-Event (the kind), X-Webhook-Timestamp (unix seconds) and\nX-Webhook-Signature (` + "`" + `sha256=` + "`" + ` and the hex HMAC-SHA256 of ` + "`" + `\u003ctimestamp\u003e.\u003cbody\u003e` + "`" + ` with the secret).\nFailed deliveries are retried with exponential backoff until they are dead.\nThe secret is generated if it is missing, it is only returned in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Create a webhook (admin)",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SaveWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/CreatedWebhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get a webhook by id, without its secret",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Get a webhook (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update the url and events of a webhook by id, and its secret if given.\nPending deliveries are sent to the new url, with the new secret.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Update a webhook (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SaveWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Error, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete a webhook by id with its deliveries and their logs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Delete a webhook (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Query the deliveries of a webhook by id, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Query the deliveries of a webhook (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "example": 0,
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "example": "dead",
+                        "name": "state",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/WebhookDelivery"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries/{delivery_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get a delivery of a webhook by id, with the log of its attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Get a delivery of a webhook (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries/{delivery_id}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Send a delivery of a webhook by id again, e.g. a dead one once the endpoint is fixed.\nThe delivery is pending and due right away, its attempts are reset while its log is kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Redeliver a delivery of a webhook (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "user login",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Login",
+                "parameters": [
+                    {
+                        "description": "Login Request",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "refresh token",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Refresh Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "user registration",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register",
+                "parameters": [
+                    {
+                        "description": "Register User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/RegisterUser"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, retries with the same key replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        },
+                        "headers": {
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "true if the response is the replay of an earlier request"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict, the email is taken or the idempotency key was used",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable, the data violates a constraint",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
         "AuthResponse": {
             "description": "auth response",
             "type": "object",
             "properties": {
-                "access_token": {
-                    "type": "string"
+                "access_token": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "BatchGetUsers": {
+            "description": "BatchGetUsers DTO model for fetching many users at once",
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string",
+                        "format": "uuid"
+                    },
+                    "example": [
+                        "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                    ]
+                }
+            }
+        },
+        "BatchGetUsersResponse": {
+            "description": "BatchGetUsersResponse DTO model for the found users in the requested order and the ids of the missing ones",
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/User"
+                    }
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "format": "uuid"
+                    }
+                }
+            }
+        },
+        "BatchMutateUsers": {
+            "description": "BatchMutateUsers DTO model for applying many mutations at once",
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "atomic": {
+                    "description": "Atomic applies all operations in one transaction, otherwise each operation is applied on its own",
+                    "type": "boolean",
+                    "example": true
+                },
+                "operations": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/UserOperation"
+                    }
+                }
+            }
+        },
+        "BatchMutateUsersResponse": {
+            "description": "BatchMutateUsersResponse DTO model for the outcome of a batch by operation",
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UserOperationResult"
+                    }
+                }
+            }
+        },
+        "CreatedWebhook": {
+            "description": "CreatedWebhook DTO model for the response of webhook creations",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "user.created",
+                            "user.updated",
+                            "user.deleted",
+                            "user.password_changed",
+                            "user.login_succeeded",
+                            "user.login_failed"
+                        ]
+                    },
+                    "example": [
+                        "user.created",
+                        "user.deleted"
+                    ]
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "secret": {
+                    "description": "Secret signs the deliveries, it can't be read again",
+                    "type": "string",
+                    "example": "3f7a2c9e8b1d4f6a0c5e7b9d2f4a6c8e"
+                },
+                "url": {
+                    "type": "string",
+                    "format": "uri",
+                    "example": "https://example.com/hooks/users"
+                }
+            }
+        },
+        "ErrorResponse": {
+            "description": "ErrorResponse DTO model of the problem details (RFC 9457) of errors, served as application/problem+json",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is the stable machine-readable code of the error",
+                    "type": "string",
+                    "example": "email_taken"
+                },
+                "detail": {
+                    "description": "Detail is the message of the error for humans, internal errors have none",
+                    "type": "string",
+                    "example": "email is already taken"
+                },
+                "errors": {
+                    "description": "Errors are the fields which failed the validation, with messages in the language of the Accept-Language header",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/users"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "6f1c2b9e-0d5a-4c1e-9a43-2f8e5b7d3c10"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 409
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Conflict"
+                },
+                "type": {
+                    "description": "Type is always about:blank, the code identifies the problem",
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "ExportJob": {
+            "description": "ExportJob DTO model for asynchronously generated exports",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "download_url": {
+                    "type": "string",
+                    "example": "/api/v1/users/me/exports/b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "json",
+                        "zip"
+                    ],
+                    "example": "json"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "ready",
+                        "failed"
+                    ],
+                    "example": "pending"
+                }
+            }
+        },
+        "FieldError": {
+            "description": "FieldError DTO model for a field which failed a validation rule",
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "phone must be an E.164 phone number, e.g. +49123456789"
+                },
+                "pointer": {
+                    "description": "Pointer is the JSON Pointer of the field in the body, or the name of the query parameter",
+                    "type": "string",
+                    "example": "/phone"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "phone"
+                }
+            }
+        },
+        "ImportReport": {
+            "description": "ImportReport DTO model for the outcome of a user import by row",
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportRow"
+                    }
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "ImportRow": {
+            "description": "ImportRow DTO model for the outcome of a single row of a user import",
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "format": "email",
+                    "example": "abc@xyz.com"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "phone is not a valid E.164 phone number"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "row": {
+                    "description": "Row is the line of the row in the input",
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "updated",
+                        "failed"
+                    ],
+                    "example": "created"
+                }
+            }
+        },
+        "LoginRequest": {
+            "description": "login request",
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "format": "email",
+                    "example": "abc@xyz.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password"
+                }
+            }
+        },
+        "PatchUser": {
+            "description": "PatchUser DTO model for user updates (partial)",
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "format": "email",
+                    "example": "abc@xyz.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "is_admin": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password"
+                },
+                "phone": {
+                    "type": "string",
+                    "format": "phone",
+                    "example": "+49123456789"
+                }
+            }
+        },
+        "PatchUserAddress": {
+            "description": "PatchUserAddress DTO model for address updates (partial)",
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1,
+                    "example": "Berlin"
+                },
+                "extra": {
+                    "description": "null removes the extra",
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1,
+                    "example": "Apartment 1"
+                },
+                "is_default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "billing",
+                        "shipping",
+                        "home"
+                    ],
+                    "example": "home"
+                },
+                "street": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1,
+                    "example": "Main Street"
+                },
+                "street_number": {
+                    "type": "string",
+                    "example": "123"
+                },
+                "zip_code": {
+                    "type": "string",
+                    "example": "12345"
+                }
+            }
+        },
+        "RegisterUser": {
+            "description": "RegisterUser DTO model for user registration",
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password",
+                "phone"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "format": "email",
+                    "example": "abc@xyz.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+49123456789"
+                }
+            }
+        },
+        "SaveUser": {
+            "description": "SaveUser DTO model for user creation and updates (overwrites)",
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password",
+                "phone"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "format": "email",
+                    "example": "abc@xyz.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "is_admin": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password"
+                },
+                "phone": {
+                    "type": "string",
+                    "format": "phone",
+                    "example": "+49123456789"
+                }
+            }
+        },
+        "SaveUserAddress": {
+            "description": "SaveUserAddress DTO model for address creation and updates (overwrites)",
+            "type": "object",
+            "required": [
+                "city",
+                "kind",
+                "street",
+                "street_number",
+                "zip_code"
+            ],
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Berlin"
+                },
+                "extra": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1,
+                    "example": "Apartment 1"
+                },
+                "is_default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "billing",
+                        "shipping",
+                        "home"
+                    ],
+                    "example": "home"
+                },
+                "street": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Main Street"
+                },
+                "street_number": {
+                    "type": "string",
+                    "example": "123"
+                },
+                "zip_code": {
+                    "type": "string",
+                    "example": "12345"
+                }
+            }
+        },
+        "SaveWebhook": {
+            "description": "SaveWebhook DTO model for webhook creations and updates (overwrites)",
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "user.created",
+                            "user.updated",
+                            "user.deleted",
+                            "user.password_changed",
+                            "user.login_succeeded",
+                            "user.login_failed"
+                        ]
+                    },
+                    "example": [
+                        "user.created",
+                        "user.deleted"
+                    ]
+                },
+                "secret": {
+                    "description": "Secret signs the deliveries, it is generated on creations and kept on updates if missing",
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 16,
+                    "example": "3f7a2c9e8b1d4f6a0c5e7b9d2f4a6c8e"
+                },
+                "url": {
+                    "type": "string",
+                    "format": "uri",
+                    "maxLength": 1000,
+                    "example": "https://example.com/hooks/users"
+                }
+            }
+        },
+        "User": {
+            "description": "User DTO model for responses",
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "description": "Addresses are only included if expanded",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UserAddress"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "format": "email",
+                    "example": "abc@xyz.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "phone": {
+                    "type": "string",
+                    "format": "phone",
+                    "example": "+49123456789"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "version_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "versions": {
+                    "description": "Versions is the version history, only if expanded",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UserVersion"
+                    }
+                }
+            }
+        },
+        "UserAddress": {
+            "description": "UserAddress DTO model for responses",
+            "type": "object",
+            "required": [
+                "city",
+                "street",
+                "street_number",
+                "zip_code"
+            ],
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Berlin"
                 },
-                "refresh_token": {
-                    "type": "string"
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "extra": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1,
+                    "example": "Apartment 1"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "is_default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "billing",
+                        "shipping",
+                        "home"
+                    ],
+                    "example": "home"
+                },
+                "street": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Main Street"
+                },
+                "street_number": {
+                    "type": "string",
+                    "example": "123"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "version_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "zip_code": {
+                    "type": "string",
+                    "example": "12345"
                 }
             }
         },
-        "ErrorResponse": {
-            "description": "ErrorResponse DTO model",
+        "UserChange": {
+            "description": "UserChange DTO model for a create, update (a new version) or delete of a user",
             "type": "object",
             "properties": {
-                "error": {
+                "change_id": {
+                    "description": "ChangeID identifies the change, it is the version id for creates and updates and the user id for deletes",
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "changed_at": {
                     "type": "string",
-                    "example": "error message"
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "updated",
+                        "deleted"
+                    ],
+                    "example": "updated"
+                },
+                "user": {
+                    "description": "User is the created or updated version, not set for deletes",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/User"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
                 }
             }
         },
-        "LoginRequest": {
-            "description": "login request",
+        "UserChanges": {
+            "description": "UserChanges DTO model for a part of the changes feed of users",
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "description": "HasMore is true if more changes are available right away",
+                    "type": "boolean",
+                    "example": false
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UserChange"
+                    }
+                },
+                "next_since": {
+                    "description": "NextSince continues the feed after the items, it is only null if the feed is empty",
+                    "type": "string",
+                    "example": "eyJzIjoiY2hhbmdlZF9hdCxjaGFuZ2VfaWQiLCJ2IjpbXX0.c2lnbmF0dXJl"
+                }
+            }
+        },
+        "UserEvent": {
+            "description": "UserEvent DTO model for the events delivered to webhooks, which is also the body of the deliveries, and exported with users",
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
                 "email": {
+                    "description": "Email is the email logins were tried with",
                     "type": "string",
                     "format": "email",
                     "example": "abc@xyz.com"
                 },
-                "password": {
+                "id": {
+                    "description": "ID identifies the event, receivers can skip events they have seen already",
                     "type": "string",
-                    "example": "password"
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "user.created",
+                        "user.updated",
+                        "user.deleted",
+                        "user.password_changed",
+                        "user.login_succeeded",
+                        "user.login_failed"
+                    ],
+                    "example": "user.updated"
+                },
+                "occurred_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "previous_version_id": {
+                    "description": "PreviousVersionID is the version of updated users that was the latest one before, the nil uuid otherwise",
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "00000000-0000-0000-0000-000000000000"
+                },
+                "user_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "version_id": {
+                    "description": "VersionID is the created version of created and updated users and of changed passwords, the nil uuid otherwise",
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
                 }
             }
         },
-        "PatchUser": {
-            "description": "PatchUser DTO model for user updates (partial)",
+        "UserExport": {
+            "description": "UserExport DTO model containing all data held about a user. The service has no sessions (tokens are stateless), API keys or consents, so there are none to export.",
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UserAddress"
+                    }
+                },
+                "events": {
+                    "description": "Events are the audit events of the user, e.g. changes and logins, in the order they occurred in",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UserEvent"
+                    }
+                },
+                "generated_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "profile": {
+                    "$ref": "#/definitions/User"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/UserVersion"
+                    }
+                }
+            }
+        },
+        "UserExportRow": {
+            "description": "UserExportRow DTO model for a row of bulk exports of users (one line of NDJSON, CSV with the same columns)",
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
                 "email": {
                     "type": "string",
                     "format": "email",
@@ -900,70 +4453,124 @@ const docTemplate = `{
                     "type": "string",
                     "example": "John"
                 },
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
                 "is_admin": {
                     "type": "boolean",
                     "example": false
                 },
+                "is_latest_version": {
+                    "type": "boolean",
+                    "example": true
+                },
                 "last_name": {
                     "type": "string",
                     "example": "Doe"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "password"
-                },
                 "phone": {
                     "type": "string",
                     "format": "phone",
                     "example": "+49123456789"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "version_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
                 }
             }
         },
-        "RegisterUser": {
-            "description": "RegisterUser DTO model for user registration",
+        "UserOperation": {
+            "description": "UserOperation DTO model for a single mutation of a batch",
             "type": "object",
             "required": [
-                "email",
-                "first_name",
-                "last_name",
-                "password",
-                "phone"
+                "op"
             ],
             "properties": {
-                "email": {
+                "id": {
+                    "description": "ID of the user to patch or delete",
                     "type": "string",
-                    "format": "email",
-                    "example": "abc@xyz.com"
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
                 },
-                "first_name": {
+                "op": {
                     "type": "string",
-                    "example": "John"
+                    "enum": [
+                        "create",
+                        "patch",
+                        "delete"
+                    ],
+                    "example": "patch"
                 },
-                "last_name": {
+                "user": {
+                    "description": "User is a SaveUser for create and a PatchUser for patch operations",
+                    "type": "object"
+                }
+            }
+        },
+        "UserOperationResult": {
+            "description": "UserOperationResult DTO model for the outcome of a single mutation of a batch",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is the machine-readable code of the error, see the error responses",
                     "type": "string",
-                    "example": "Doe"
+                    "example": "not_found"
                 },
-                "password": {
+                "error": {
                     "type": "string",
-                    "example": "password"
+                    "example": "not found"
                 },
-                "phone": {
+                "status": {
+                    "description": "Status is the HTTP status code the operation would have had on its own,\n424 if it was not applied as another operation of an atomic batch failed",
+                    "type": "integer",
+                    "example": 200
+                },
+                "user": {
+                    "$ref": "#/definitions/User"
+                }
+            }
+        },
+        "UserPage": {
+            "description": "UserPage DTO model for pages of users",
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/User"
+                    }
+                },
+                "next_cursor": {
                     "type": "string",
-                    "example": "+49123456789"
+                    "example": "eyJzIjoiaWQiLCJ2IjpbIjEiXX0.c2lnbmF0dXJl"
+                },
+                "prev_cursor": {
+                    "type": "string",
+                    "example": "eyJzIjoiaWQiLCJ2IjpbIjEiXSwiYiI6dHJ1ZX0.c2lnbmF0dXJl"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
                 }
             }
         },
-        "SaveUser": {
-            "description": "SaveUser DTO model for user creation and updates (overwrites)",
+        "UserVersion": {
+            "description": "UserVersion DTO model for a single version in the history of a user",
             "type": "object",
-            "required": [
-                "email",
-                "first_name",
-                "last_name",
-                "password",
-                "phone"
-            ],
             "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
                 "email": {
                     "type": "string",
                     "format": "email",
@@ -981,45 +4588,133 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Doe"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "password"
-                },
                 "phone": {
                     "type": "string",
                     "format": "phone",
                     "example": "+49123456789"
+                },
+                "version_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
                 }
             }
         },
-        "User": {
-            "description": "User DTO model for responses",
+        "Webhook": {
+            "description": "Webhook DTO model for responses, the secret is only returned on creation",
             "type": "object",
             "properties": {
-                "email": {
+                "created_at": {
                     "type": "string",
-                    "format": "email",
-                    "example": "abc@xyz.com"
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
                 },
-                "first_name": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "user.created",
+                            "user.updated",
+                            "user.deleted",
+                            "user.password_changed",
+                            "user.login_succeeded",
+                            "user.login_failed"
+                        ]
+                    },
+                    "example": [
+                        "user.created",
+                        "user.deleted"
+                    ]
+                },
+                "id": {
                     "type": "string",
-                    "example": "John"
+                    "format": "uuid",
+                    "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+                },
+                "url": {
+                    "type": "string",
+                    "format": "uri",
+                    "example": "https://example.com/hooks/users"
+                }
+            }
+        },
+        "WebhookAttempt": {
+            "description": "WebhookAttempt DTO model for the log of deliveries",
+            "type": "object",
+            "properties": {
+                "attempted_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "error": {
+                    "description": "Error describes why the attempt failed, missing if it succeeded",
+                    "type": "string",
+                    "example": "unexpected status 500"
+                },
+                "status_code": {
+                    "description": "StatusCode is the status of the response, missing if there was none",
+                    "type": "integer",
+                    "example": 500
+                }
+            }
+        },
+        "WebhookDelivery": {
+            "description": "WebhookDelivery DTO model for responses",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts counts the failed attempts since the delivery was created or redelivered",
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "event": {
+                    "$ref": "#/definitions/UserEvent"
                 },
                 "id": {
                     "type": "string",
                     "format": "uuid",
                     "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
                 },
-                "last_name": {
+                "log": {
+                    "description": "Log are the attempts of the delivery, oldest first, only included if the delivery is requested by its id",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/WebhookAttempt"
+                    }
+                },
+                "next_attempt_at": {
+                    "description": "NextAttemptAt is only set for pending deliveries",
                     "type": "string",
-                    "example": "Doe"
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:30Z"
                 },
-                "phone": {
+                "state": {
+                    "description": "State is pending until the delivery succeeded, or until it is dead as every attempt failed",
                     "type": "string",
-                    "format": "phone",
-                    "example": "+49123456789"
+                    "enum": [
+                        "pending",
+                        "succeeded",
+                        "dead"
+                    ],
+                    "example": "pending"
                 },
-                "version_id": {
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "webhook_id": {
                     "type": "string",
                     "format": "uuid",
                     "example": "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
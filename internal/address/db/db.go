@@ -0,0 +1,127 @@
+package addressDB
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/transaction"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+type db struct {
+	*gorm.DB
+}
+
+func create(g *gorm.DB) *db {
+	return &db{
+		DB: g,
+	}
+}
+
+// conn returns the transaction of the unit of work of the context, so that the queries join it
+func (d *db) conn(ctx context.Context) *gorm.DB {
+	return transaction.DB(ctx, d.DB)
+}
+
+// lastVersionQuery wraps the latest versions of the addresses in a subquery so that conditions can use the selected names
+func lastVersionQuery(db *gorm.DB) *gorm.DB {
+	return db.Table("(?) AS user_addresses", db.Table("addresses").Joins(
+		"JOIN (?) AS last_version ON addresses.id = last_version.address_id",
+		db.Table("address_versions").Select("DISTINCT ON (address_id) *").Order("address_id, created_at DESC"),
+	).Joins("JOIN users ON users.id = addresses.user_id").Select(
+		"addresses.id as id",
+		"last_version.id as version_id",
+		"addresses.user_id as user_id",
+		"last_version.kind as kind",
+		"last_version.is_default as is_default",
+		"last_version.address as address",
+		"addresses.created_at as created_at",
+		"last_version.created_at as updated_at",
+	).Where("addresses.deleted_at IS NULL AND users.deleted_at IS NULL"))
+}
+
+func (d *db) Get(ctx context.Context, id uuid.UUID) (types.UserAddress, error) {
+	var address types.UserAddress
+	err := lastVersionQuery(d.conn(ctx)).Where("id = ?", id).First(&address).Error
+	return address, types.DBError(err)
+}
+
+func (d *db) Query(ctx context.Context, params types.QueryParams) ([]types.UserAddress, error) {
+	var addresses []types.UserAddress
+	err := types.Query(lastVersionQuery(d.conn(ctx)), params).Order("created_at, id").Find(&addresses).Error
+	return addresses, types.DBError(err)
+}
+
+func (d *db) GetByUsers(ctx context.Context, userIDs []uuid.UUID) ([]types.UserAddress, error) {
+	var addresses []types.UserAddress
+	err := lastVersionQuery(d.conn(ctx)).Where("user_id IN ?", userIDs).Order("user_id, created_at, id").Find(&addresses).Error
+	return addresses, types.DBError(err)
+}
+
+func (d *db) Save(ctx context.Context, address types.UserAddress) (types.UserAddress, error) {
+	base, version := address.ToSave()
+	err := d.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// The owning user is locked to serialize concurrent changes of the default addresses
+		if err := tx.Table("users").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", address.UserID).First(&types.User{}).Error; err != nil {
+			return types.DBError(err)
+		}
+
+		// Create or find the base address
+		if base != nil {
+			if err := tx.Table("addresses").Create(base).Error; err != nil {
+				return types.DBError(err)
+			}
+		} else {
+			var existing types.UserAddress
+			if err := tx.Table("addresses").Where("id = ? AND user_id = ? AND deleted_at IS NULL", address.ID, address.UserID).
+				First(&existing).Error; err != nil {
+				return types.DBError(err)
+			}
+			address.CreatedAt = existing.CreatedAt
+		}
+
+		// Only one address per user and kind can be the default one
+		if address.IsDefault {
+			if err := unsetDefaults(tx, address); err != nil {
+				return err
+			}
+		}
+
+		// Create the new version
+		if err := tx.Table("address_versions").Create(version).Error; err != nil {
+			return types.DBError(err)
+		}
+
+		return nil
+	})
+	return address, err
+}
+
+func unsetDefaults(tx *gorm.DB, address types.UserAddress) error {
+	var defaults []types.UserAddress
+	if err := lastVersionQuery(tx).Where("user_id = ? AND kind = ? AND is_default AND id <> ?", address.UserID, address.Kind, address.ID).
+		Find(&defaults).Error; err != nil {
+		return types.DBError(err)
+	}
+
+	for _, d := range defaults {
+		d.IsDefault = false
+		_, version := d.ToSave()
+		if err := tx.Table("address_versions").Create(version).Error; err != nil {
+			return types.DBError(err)
+		}
+	}
+
+	return nil
+}
+
+func (d *db) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := d.conn(ctx).Table("addresses").Where("id = ? AND deleted_at IS NULL", id).First(&types.UserAddress{}).Error; err != nil {
+		return types.DBError(err)
+	}
+	return types.DBError(d.conn(ctx).Table("addresses").Where("id = ? AND deleted_at IS NULL", id).Delete(nil).Error)
+}
@@ -0,0 +1,286 @@
+package addressDB
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	testData "github.com/pedramktb/schwarzit-probearbeit/internal/test_data"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+var postgresContainer testcontainers.Container
+var ip, port string
+
+func TestMain(m *testing.M) {
+	postgresContainer, ip, port = postgres.Test_Create_Container()
+	defer func(postgresContainer testcontainers.Container, ctx context.Context) {
+		_ = postgresContainer.Terminate(ctx)
+	}(postgresContainer, context.Background())
+
+	defer os.Exit(m.Run())
+}
+
+func Test_Get(t *testing.T) {
+	dbName := "test-address-get"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	// test
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		want    types.UserAddress
+		wantErr bool
+	}{
+		{
+			name:    "Success Case",
+			id:      testData.TestAddress.ID,
+			want:    testData.TestAddress,
+			wantErr: false,
+		},
+		{
+			name:    "Not Found Case",
+			id:      uuid.New(),
+			want:    types.UserAddress{},
+			wantErr: true,
+		},
+	}
+
+	addressDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addressDB.Get(context.Background(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Query(t *testing.T) {
+	dbName := "test-address-query"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	// test
+	tests := []struct {
+		name    string
+		query   types.QueryParams
+		want    []types.UserAddress
+		wantErr bool
+	}{
+		{
+			name:    "Success Case",
+			query:   types.QueryParams{Conditions: &types.UserAddressPatch{UserID: types.ToOptional(testData.TestUser.ID)}},
+			want:    []types.UserAddress{testData.TestAddress},
+			wantErr: false,
+		},
+		{
+			name:    "Not Found Case",
+			query:   types.QueryParams{Conditions: &types.UserAddressPatch{UserID: types.ToOptional(testData.TestAdminUser.ID)}},
+			want:    []types.UserAddress{},
+			wantErr: false,
+		},
+	}
+
+	addressDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addressDB.Query(context.Background(), tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.Query() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			assert.Equal(t, len(tt.want), len(got))
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func Test_GetByUsers(t *testing.T) {
+	dbName := "test-address-get-by-users"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	// test
+	tests := []struct {
+		name    string
+		userIDs []uuid.UUID
+		want    []types.UserAddress
+		wantErr bool
+	}{
+		{
+			name:    "Success Case",
+			userIDs: []uuid.UUID{testData.TestUser.ID, testData.TestAdminUser.ID},
+			want:    []types.UserAddress{testData.TestAddress},
+			wantErr: false,
+		},
+		{
+			name:    "Not Found Case",
+			userIDs: []uuid.UUID{testData.TestAdminUser.ID},
+			want:    []types.UserAddress{},
+			wantErr: false,
+		},
+	}
+
+	addressDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addressDB.GetByUsers(context.Background(), tt.userIDs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.GetByUsers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Save(t *testing.T) {
+	dbName := "test-address-save"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	TestAddress1 := testData.TestAddress
+	TestAddress1.Address.City = "Hamburg"
+
+	TestAddress2 := testData.TestAddress
+	TestAddress2.ID = uuid.Nil
+	TestAddress2.Address.Extra = nil
+
+	TestAddress3 := testData.TestAddress
+	TestAddress3.ID = uuid.Nil
+	TestAddress3.UserID = uuid.New()
+
+	// test
+	tests := []struct {
+		name    string
+		address types.UserAddress
+		want    types.UserAddress
+		wantErr bool
+	}{
+		{
+			name:    "Update Case",
+			address: TestAddress1,
+			want:    TestAddress1,
+			wantErr: false,
+		},
+		{
+			name:    "New Default Case",
+			address: TestAddress2,
+			want:    TestAddress2,
+			wantErr: false,
+		},
+		{
+			name:    "User Not Found Case",
+			address: TestAddress3,
+			wantErr: true,
+		},
+	}
+
+	addressDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved, err := addressDB.Save(context.Background(), tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.Save() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			if tt.want.ID == uuid.Nil {
+				tt.want.ID = saved.ID
+				tt.want.CreatedAt = saved.CreatedAt
+			}
+			tt.want.VersionID = saved.VersionID
+			tt.want.UpdatedAt = saved.UpdatedAt
+			assert.Equal(t, tt.want.ID, saved.ID)
+			got, err := addressDB.Get(context.Background(), saved.ID)
+			if err != nil {
+				t.Errorf("db.Get() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			// Only the last saved default address of a kind stays the default one
+			defaults, err := addressDB.Query(context.Background(), types.QueryParams{Conditions: &types.UserAddressPatch{
+				UserID:    types.ToOptional(tt.address.UserID),
+				Kind:      types.ToOptional(tt.address.Kind),
+				IsDefault: types.ToOptional(true),
+			}})
+			if err != nil {
+				t.Errorf("db.Query() error = %v", err)
+				return
+			}
+			assert.Equal(t, []types.UserAddress{got}, defaults)
+		})
+	}
+}
+
+func Test_Delete(t *testing.T) {
+	dbName := "test-address-delete"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	// test
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		wantErr bool
+	}{
+		{
+			name:    "Success Case",
+			id:      testData.TestAddress.ID,
+			wantErr: false,
+		},
+		{
+			name:    "Not Found Case",
+			id:      uuid.New(),
+			wantErr: true,
+		},
+	}
+
+	addressDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := addressDB.Delete(context.Background(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.Delete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			got, err := addressDB.Get(context.Background(), tt.id)
+			if errors.Is(err, types.ErrNotFound) {
+			} else if err != nil {
+				t.Errorf("db.Get() error = %v", err)
+				return
+			}
+			assert.Equal(t, types.UserAddress{}, got)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package addressDB
+
+import (
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"go.uber.org/fx"
+)
+
+var FXAddressDBProvide = fx.Provide(
+	create,
+	func(d *db) datasource.Getter[types.UserAddress] { return d },
+	func(d *db) datasource.Querier[types.UserAddress] { return d },
+	func(d *db) datasource.ByUsersGetter[types.UserAddress] { return d },
+	func(d *db) datasource.Saver[types.UserAddress] { return d },
+	func(d *db) datasource.Deleter[types.UserAddress] { return d },
+)
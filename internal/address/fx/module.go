@@ -0,0 +1,13 @@
+package addressDI
+
+import (
+	"go.uber.org/fx"
+
+	addressDB "github.com/pedramktb/schwarzit-probearbeit/internal/address/db"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+var FXAddressModule = fx.Module("address",
+	addressDB.FXAddressDBProvide,
+	usecase.FXAddressServiceProvide,
+)
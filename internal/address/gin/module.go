@@ -0,0 +1,33 @@
+package addressGinRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+)
+
+func provideRoutes(e gin.IRouter, r *r, authMiddleware gin.HandlerFunc) {
+	g := e.Group("/api/v1/users")
+	{
+		g.Use(authMiddleware)
+		g.GET("/:id/addresses", r.Query)
+		g.POST("/:id/addresses", r.Create)
+		g.GET("/:id/addresses/:address_id", r.Get)
+		g.PUT("/:id/addresses/:address_id", r.Update)
+		g.PATCH("/:id/addresses/:address_id", r.Patch)
+		g.DELETE("/:id/addresses/:address_id", r.Delete)
+		g.GET("/me/addresses", r.QueryMe)
+		g.POST("/me/addresses", r.CreateMe)
+		g.GET("/me/addresses/:address_id", r.GetMe)
+		g.PUT("/me/addresses/:address_id", r.UpdateMe)
+		g.PATCH("/me/addresses/:address_id", r.PatchMe)
+		g.DELETE("/me/addresses/:address_id", r.DeleteMe)
+	}
+}
+
+var FXAddressGinRouterModule = fx.Options(
+	fx.Provide(create),
+	fx.Invoke(fx.Annotate(
+		provideRoutes,
+		fx.ParamTags("", "", `name:"authMiddleware"`),
+	)),
+)
@@ -0,0 +1,403 @@
+package addressGinRouter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+type r struct {
+	addressService *usecase.AddressService
+}
+
+func create(addressService *usecase.AddressService) *r {
+	return &r{addressService}
+}
+
+// @Summary Query addresses of a user
+// @Description Query the addresses of a user by id
+// @Tags address
+// @Security Bearer
+// @Produce json
+// @Param id path string true "User ID"
+// @Param params query UserAddressQueryParams false "Query Parameters"
+// @Success 200 {array} []UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/{id}/addresses [get]
+func (r *r) Query(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	r.query(c, func(ctx context.Context, actor usecase.Actor, params types.QueryParams) ([]types.UserAddress, error) {
+		return r.addressService.Query(ctx, actor, id, params)
+	})
+}
+
+// @Summary Create an address of a user
+// @Description Create an address of a user by id
+// @Tags address
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address body SaveUserAddress true "Address"
+// @Success 200 {object} UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/{id}/addresses [post]
+func (r *r) Create(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	r.create(c, func(ctx context.Context, actor usecase.Actor, address types.UserAddress) (types.UserAddress, error) {
+		return r.addressService.Create(ctx, actor, id, address)
+	})
+}
+
+// @Summary Get an address of a user
+// @Description Get an address of a user by id
+// @Tags address
+// @Security Bearer
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address_id path string true "Address ID"
+// @Success 200 {object} UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/{id}/addresses/{address_id} [get]
+func (r *r) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	r.get(c, func(ctx context.Context, actor usecase.Actor, addressID uuid.UUID) (types.UserAddress, error) {
+		return r.addressService.Get(ctx, actor, id, addressID)
+	})
+}
+
+// @Summary Update an address of a user
+// @Description Update an address of a user by id
+// @Tags address
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address_id path string true "Address ID"
+// @Param address body SaveUserAddress true "Address"
+// @Success 200 {object} UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/{id}/addresses/{address_id} [put]
+func (r *r) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	r.update(c, func(ctx context.Context, actor usecase.Actor, addressID uuid.UUID, address types.UserAddress) (types.UserAddress, error) {
+		return r.addressService.Update(ctx, actor, id, addressID, address)
+	})
+}
+
+// @Summary Patch an address of a user
+// @Description Patch an address of a user by id
+// @Tags address
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address_id path string true "Address ID"
+// @Param address body PatchUserAddress true "Address"
+// @Success 200 {object} UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/{id}/addresses/{address_id} [patch]
+func (r *r) Patch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	r.patch(c, func(ctx context.Context, actor usecase.Actor, addressID uuid.UUID, patch types.UserAddressPatch) (types.UserAddress, error) {
+		return r.addressService.Patch(ctx, actor, id, addressID, patch)
+	})
+}
+
+// @Summary Delete an address of a user
+// @Description Delete an address of a user by id
+// @Tags address
+// @Security Bearer
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address_id path string true "Address ID"
+// @Success 200
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/{id}/addresses/{address_id} [delete]
+func (r *r) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	r.delete(c, func(ctx context.Context, actor usecase.Actor, addressID uuid.UUID) error {
+		return r.addressService.Delete(ctx, actor, id, addressID)
+	})
+}
+
+// @Summary Query my addresses (user)
+// @Description Query my addresses as a user
+// @Tags address
+// @Security Bearer
+// @Produce json
+// @Param params query UserAddressQueryParams false "Query Parameters"
+// @Success 200 {array} []UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/me/addresses [get]
+func (r *r) QueryMe(c *gin.Context) {
+	r.query(c, r.addressService.QueryMe)
+}
+
+// @Summary Create my address (user)
+// @Description Create an address of me as a user
+// @Tags address
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param address body SaveUserAddress true "Address"
+// @Success 200 {object} UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/me/addresses [post]
+func (r *r) CreateMe(c *gin.Context) {
+	r.create(c, r.addressService.CreateMe)
+}
+
+// @Summary Get my address (user)
+// @Description Get an address of me as a user
+// @Tags address
+// @Security Bearer
+// @Produce json
+// @Param address_id path string true "Address ID"
+// @Success 200 {object} UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/me/addresses/{address_id} [get]
+func (r *r) GetMe(c *gin.Context) {
+	r.get(c, r.addressService.GetMe)
+}
+
+// @Summary Update my address (user)
+// @Description Update an address of me as a user
+// @Tags address
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param address_id path string true "Address ID"
+// @Param address body SaveUserAddress true "Address"
+// @Success 200 {object} UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/me/addresses/{address_id} [put]
+func (r *r) UpdateMe(c *gin.Context) {
+	r.update(c, r.addressService.UpdateMe)
+}
+
+// @Summary Patch my address (user)
+// @Description Patch an address of me as a user
+// @Tags address
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param address_id path string true "Address ID"
+// @Param address body PatchUserAddress true "Address"
+// @Success 200 {object} UserAddress
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/me/addresses/{address_id} [patch]
+func (r *r) PatchMe(c *gin.Context) {
+	r.patch(c, r.addressService.PatchMe)
+}
+
+// @Summary Delete my address (user)
+// @Description Delete an address of me as a user
+// @Tags address
+// @Security Bearer
+// @Produce json
+// @Param address_id path string true "Address ID"
+// @Success 200
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/me/addresses/{address_id} [delete]
+func (r *r) DeleteMe(c *gin.Context) {
+	r.delete(c, r.addressService.DeleteMe)
+}
+
+func (r *r) query(c *gin.Context, query func(ctx context.Context, actor usecase.Actor, params types.QueryParams) ([]types.UserAddress, error)) {
+	paramsDTO := dtos.UserAddressQueryParams{}
+	if err := c.ShouldBindQuery(&paramsDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	if addresses, err := query(c.Request.Context(), ginRouter.GetActor(c), paramsDTO.ToQueryParams()); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		addressDTOs := make([]dtos.UserAddress, len(addresses))
+		for i, address := range addresses {
+			addressDTOs[i] = dtos.FromUserAddress(&address)
+		}
+		c.JSON(http.StatusOK, addressDTOs)
+	}
+}
+
+func (r *r) create(c *gin.Context, create func(ctx context.Context, actor usecase.Actor, address types.UserAddress) (types.UserAddress, error)) {
+	addressDTO := dtos.SaveUserAddress{}
+	if err := c.ShouldBindJSON(&addressDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	address, err := create(c.Request.Context(), ginRouter.GetActor(c), addressDTO.ToUserAddress(uuid.Nil, uuid.Nil))
+	respondAddress(c, address, err)
+}
+
+func (r *r) get(c *gin.Context, get func(ctx context.Context, actor usecase.Actor, id uuid.UUID) (types.UserAddress, error)) {
+	id, err := parseAddressID(c)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	address, err := get(c.Request.Context(), ginRouter.GetActor(c), id)
+	respondAddress(c, address, err)
+}
+
+func (r *r) update(c *gin.Context, update func(ctx context.Context, actor usecase.Actor, id uuid.UUID, address types.UserAddress) (types.UserAddress, error)) {
+	id, err := parseAddressID(c)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	addressDTO := dtos.SaveUserAddress{}
+	if err := c.ShouldBindJSON(&addressDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	address, err := update(c.Request.Context(), ginRouter.GetActor(c), id, addressDTO.ToUserAddress(id, uuid.Nil))
+	respondAddress(c, address, err)
+}
+
+func (r *r) patch(c *gin.Context, patch func(ctx context.Context, actor usecase.Actor, id uuid.UUID, patch types.UserAddressPatch) (types.UserAddress, error)) {
+	id, err := parseAddressID(c)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	addressDTO := dtos.PatchUserAddress{}
+	if err := c.ShouldBindJSON(&addressDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	address, err := patch(c.Request.Context(), ginRouter.GetActor(c), id, addressDTO.ToUserAddressPatch())
+	respondAddress(c, address, err)
+}
+
+func (r *r) delete(c *gin.Context, delete func(ctx context.Context, actor usecase.Actor, id uuid.UUID) error) {
+	id, err := parseAddressID(c)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	if err := delete(c.Request.Context(), ginRouter.GetActor(c), id); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.Status(http.StatusOK)
+	}
+}
+
+// parseAddressID parses the address id of the path
+func parseAddressID(c *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("address_id"))
+	if err != nil {
+		return uuid.Nil, errors.CombineErrors(types.ErrInvalidID, err)
+	}
+	return id, nil
+}
+
+// respondAddress responds with the address or the error
+func respondAddress(c *gin.Context, address types.UserAddress, err error) {
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.JSON(http.StatusOK, dtos.FromUserAddress(&address))
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"go.uber.org/fx"
 )
 
-func provideRoutes(e gin.IRouter, r *r) {
+func provideRoutes(e gin.IRouter, r *r, idempotencyMiddleware gin.HandlerFunc) {
 	g := e.Group("/auth")
 	{
-		g.POST("/register", r.Register)
+		g.POST("/register", idempotencyMiddleware, r.Register)
 		g.POST("/login", r.Login)
 		g.GET("/refresh", r.Refresh)
 	}
@@ -16,11 +16,14 @@ func provideRoutes(e gin.IRouter, r *r) {
 
 var FXAuthGinRouterModule = fx.Options(
 	fx.Provide(
-		fx.Annotate(create, fx.ParamTags(`name:"cachedUserByEmailGetter"`, `name:"cachedUserSaver"`, "")),
+		create,
 		fx.Annotate(
 			func(r *r) gin.HandlerFunc { return r.AuthMiddleware },
 			fx.ResultTags(`name:"authMiddleware"`),
 		),
 	),
-	fx.Invoke(provideRoutes),
+	fx.Invoke(fx.Annotate(
+		provideRoutes,
+		fx.ParamTags("", "", `name:"idempotencyMiddleware"`),
+	)),
 )
@@ -9,28 +9,24 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	authJWT "github.com/pedramktb/schwarzit-probearbeit/internal/auth/jwt"
-	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
 	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
 )
 
 type r struct {
-	datasource.UserByEmailGetter
-	userSaver datasource.Saver[types.User]
-	jwt       *authJWT.JWT
+	users *usecase.UserService
+	jwt   *authJWT.JWT
 }
 
 func create(
-	userByEmailGetter datasource.UserByEmailGetter,
-	userSaver datasource.Saver[types.User],
+	users *usecase.UserService,
 	jwt *authJWT.JWT,
 ) *r {
 	return &r{
-		userByEmailGetter,
-		userSaver,
+		users,
 		jwt,
 	}
 }
@@ -40,18 +36,22 @@ func create(
 // @Tags auth
 // @Produce json
 // @Param user body RegisterUser true "Register User"
+// @Param Idempotency-Key header string false "Unique key of the request, retries with the same key replay the first response"
 // @Success 200 {object} User
+// @Header 200 {string} Idempotent-Replayed "true if the response is the replay of an earlier request"
 // @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 409 {object} ErrorResponse "Conflict, the email is taken or the idempotency key was used"
+// @Failure 422 {object} ErrorResponse "Unprocessable, the data violates a constraint"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /auth/register [post]
 func (r *r) Register(c *gin.Context) {
 	var registerDTO dtos.RegisterUser
 	if err := c.ShouldBindBodyWithJSON(&registerDTO); err != nil {
-		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
 		return
 	}
 
-	user, err := r.userSaver.Save(c.Request.Context(), registerDTO.ToUser())
+	user, err := r.users.Register(c.Request.Context(), registerDTO.ToUserInput())
 	if err != nil {
 		ginRouter.ErrorResponse(c, err)
 		return
@@ -74,21 +74,16 @@ func (r *r) Register(c *gin.Context) {
 func (r *r) Login(c *gin.Context) {
 	var loginRequest dtos.LoginRequest
 	if err := c.ShouldBindBodyWithJSON(&loginRequest); err != nil {
-		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
 		return
 	}
 
-	user, err := r.UserByEmailGetter.GetByEmail(c.Request.Context(), loginRequest.Email)
+	user, err := r.users.Login(c.Request.Context(), loginRequest.Email, loginRequest.Password)
 	if err != nil {
 		ginRouter.ErrorResponse(c, err)
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(loginRequest.Password)); err != nil {
-		ginRouter.ErrorResponse(c, errors.Join(types.ErrUnauthorized, err))
-		return
-	}
-
 	claims := jwt.MapClaims{
 		"sub":   user.ID.String(),
 		"admin": user.IsAdmin,
@@ -12,14 +12,33 @@ type Getter[T any] interface {
 	Get(ctx context.Context, id uuid.UUID) (T, error)
 }
 
+// ManyGetter returns the entities with the given ids, missing ones are left out
+type ManyGetter[T any] interface {
+	GetMany(ctx context.Context, ids []uuid.UUID) ([]T, error)
+}
+
 type Querier[T any] interface {
 	Query(ctx context.Context, params types.QueryParams) ([]T, error)
 }
 
+type PageQuerier[T any] interface {
+	QueryPage(ctx context.Context, params types.QueryParams) (types.Page[T], error)
+}
+
+// Streamer passes the entities matching the query one by one to fn, without holding all of them in memory
+type Streamer[T any] interface {
+	Stream(ctx context.Context, params types.QueryParams, fn func(T) error) error
+}
+
 type Saver[T any] interface {
 	Save(ctx context.Context, t T) (T, error)
 }
 
+// BatchSaver saves all or none of the given entities
+type BatchSaver[T any] interface {
+	SaveBatch(ctx context.Context, ts []T) ([]T, error)
+}
+
 type Deleter[T any] interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -31,3 +50,28 @@ type VersionGetter[T any] interface {
 type VersionsGetter[T any] interface {
 	GetVersions(ctx context.Context, versionID []uuid.UUID) ([]T, error)
 }
+
+type HistoryGetter[T any] interface {
+	GetHistory(ctx context.Context, id uuid.UUID) ([]T, error)
+	GetHistories(ctx context.Context, ids []uuid.UUID) ([]T, error)
+	CountHistory(ctx context.Context, id uuid.UUID) (int64, error)
+}
+
+// HistoryStreamer passes all versions of the entities matching the query one by one to fn
+type HistoryStreamer[T any] interface {
+	StreamHistory(ctx context.Context, params types.QueryParams, fn func(T) error) error
+}
+
+type ByUsersGetter[T any] interface {
+	GetByUsers(ctx context.Context, userIDs []uuid.UUID) ([]T, error)
+}
+
+// VersionedDeleter deletes an entity only if the given version is still its latest one
+type VersionedDeleter[T any] interface {
+	DeleteVersion(ctx context.Context, id, versionID uuid.UUID) error
+}
+
+// UnitOfWork runs fn atomically, the datasources called with the context passed to fn join its transaction
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
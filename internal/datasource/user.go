@@ -2,6 +2,9 @@ package datasource
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
 )
@@ -9,3 +12,41 @@ import (
 type UserByEmailGetter interface {
 	GetByEmail(ctx context.Context, email string) (types.User, error)
 }
+
+type UsersByEmailsGetter interface {
+	GetByEmails(ctx context.Context, emails []string) ([]types.User, error)
+}
+
+// UserBatchMutator applies the operations either atomically, where the first failing operation rolls back all others
+// and its error is returned, or each on its own. There is a result for every operation in both cases.
+type UserBatchMutator interface {
+	MutateBatch(ctx context.Context, ops []types.UserOperation, atomic bool) ([]types.UserOperationResult, error)
+}
+
+// UserChangesGetter returns at most limit changes of users made before until, in the order of
+// types.UserChangeSort and after the cursor if given
+type UserChangesGetter interface {
+	GetChanges(ctx context.Context, since *types.Cursor, until time.Time, limit int) ([]types.UserChange, error)
+}
+
+// UserChangesListener pushes the changes of users as they are committed until the context is done.
+// The channel is closed when listening fails or the listener falls behind, changes in between have to be fetched again.
+type UserChangesListener interface {
+	ListenChanges(ctx context.Context) (<-chan types.UserChange, error)
+}
+
+// UserEventSaver writes events to the outbox, with the changes made in the unit of work of the context if any
+type UserEventSaver interface {
+	SaveEvents(ctx context.Context, events []types.UserEvent) error
+}
+
+// UserEventRelayer passes at most limit of the oldest unpublished events of the outbox to publish and marks them as
+// published if publishing succeeds, it returns how many were published. Concurrent relayers get different events.
+type UserEventRelayer interface {
+	RelayEvents(ctx context.Context, limit int, publish func(ctx context.Context, events []types.UserEvent) error) (int, error)
+}
+
+// UserEventGetter returns the events of a user in the order they occurred in, the audit log of the user
+type UserEventGetter interface {
+	GetEvents(ctx context.Context, userID uuid.UUID) ([]types.UserEvent, error)
+}
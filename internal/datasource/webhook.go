@@ -0,0 +1,37 @@
+package datasource
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// WebhookDeliveryEnqueuer creates a pending delivery of every event to each webhook subscribed to its kind,
+// events that were enqueued before are skipped
+type WebhookDeliveryEnqueuer interface {
+	EnqueueDeliveries(ctx context.Context, events []types.UserEvent) error
+}
+
+// WebhookDeliveryClaimer returns at most limit of the due pending deliveries and postpones their next attempt by
+// the lease, so that concurrent claimers get other deliveries and the claimed ones are retried if they are not saved
+type WebhookDeliveryClaimer interface {
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]types.WebhookDelivery, error)
+}
+
+// WebhookAttemptSaver logs the attempt of the delivery and saves its state, attempts and next attempt
+type WebhookAttemptSaver interface {
+	SaveAttempt(ctx context.Context, delivery types.WebhookDelivery, attempt types.WebhookAttempt) error
+}
+
+// WebhookDeliveryGetter returns a delivery of a webhook with its log
+type WebhookDeliveryGetter interface {
+	GetDelivery(ctx context.Context, webhookID, id uuid.UUID) (types.WebhookDelivery, error)
+}
+
+// WebhookRedeliverer makes a delivery of a webhook pending again and due right away, with its attempts reset
+type WebhookRedeliverer interface {
+	Redeliver(ctx context.Context, webhookID, id uuid.UUID) (types.WebhookDelivery, error)
+}
@@ -1,14 +1,19 @@
 package dtos
 
-import "github.com/pedramktb/schwarzit-probearbeit/internal/types"
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
 
 // @Description Address DTO model for retrievals, creations and updates
 type Address struct {
-	Street       string  `json:"street" binding:"required" validate:"required" example:"Main Street"`
-	StreetNumber string  `json:"street_number" binding:"required" validate:"required" example:"123"`
-	Extra        *string `json:"extra" binding:"omitnil" validate:"optional" example:"Apartment 1"`
-	ZipCode      string  `json:"zip_code" binding:"required" validate:"required" example:"12345"`
-	City         string  `json:"city" binding:"required" validate:"required" example:"Berlin"`
+	Street       string  `json:"street" binding:"required,max=255" validate:"required" example:"Main Street"`
+	StreetNumber string  `json:"street_number" binding:"required,street_number" validate:"required" example:"123"`
+	Extra        *string `json:"extra" binding:"omitnil,min=1,max=255" validate:"optional" example:"Apartment 1"`
+	ZipCode      string  `json:"zip_code" binding:"required,zip_code" validate:"required" example:"12345"`
+	City         string  `json:"city" binding:"required,max=255" validate:"required" example:"Berlin"`
 } // @name Address
 
 func FromAddress(c *types.Address) Address {
@@ -30,3 +35,104 @@ func (c *Address) ToAddress() types.Address {
 		City:         c.City,
 	}
 }
+
+// @Description UserAddress DTO model for responses
+// @Tags address
+type UserAddress struct {
+	ID        uuid.UUID `json:"id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	VersionID uuid.UUID `json:"version_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	Kind      string    `json:"kind" enums:"billing,shipping,home" example:"home"`
+	IsDefault bool      `json:"is_default" example:"true"`
+	Address
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+} // @name UserAddress
+
+// @Description SaveUserAddress DTO model for address creation and updates (overwrites)
+// @Tags address
+type SaveUserAddress struct {
+	Kind      string `json:"kind" binding:"required,oneof=billing shipping home" validate:"required" enums:"billing,shipping,home" example:"home"`
+	IsDefault bool   `json:"is_default" example:"true"`
+	Address
+} // @name SaveUserAddress
+
+// @Description PatchUserAddress DTO model for address updates (partial)
+// @Tags address
+type PatchUserAddress struct {
+	Kind         *string                 `json:"kind" binding:"omitempty,oneof=billing shipping home" enums:"billing,shipping,home" example:"home"`
+	IsDefault    *bool                   `json:"is_default" example:"true"`
+	Street       *string                 `json:"street" binding:"omitnil,min=1,max=255" example:"Main Street"`
+	StreetNumber *string                 `json:"street_number" binding:"omitnil,street_number" example:"123"`
+	Extra        types.Optional[*string] `json:"extra" binding:"omitnil,min=1,max=255" swaggertype:"string" example:"Apartment 1"` // null removes the extra
+	ZipCode      *string                 `json:"zip_code" binding:"omitnil,zip_code" example:"12345"`
+	City         *string                 `json:"city" binding:"omitnil,min=1,max=255" example:"Berlin"`
+} // @name PatchUserAddress
+
+// @Description UserAddressQueryParams DTO model for address query parameters
+// @Tags address
+type UserAddressQueryParams struct {
+	Pagination
+	Kind      *string `json:"kind" form:"kind" binding:"omitempty,oneof=billing shipping home" enums:"billing,shipping,home" example:"home"`
+	IsDefault *bool   `json:"is_default" form:"is_default" example:"true"`
+} // @name UserAddressQueryParams
+
+func FromUserAddress(a *types.UserAddress) UserAddress {
+	return UserAddress{
+		ID:        a.ID,
+		VersionID: a.VersionID,
+		Kind:      string(a.Kind),
+		IsDefault: a.IsDefault,
+		Address:   FromAddress(&a.Address),
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
+
+func (a *SaveUserAddress) ToUserAddress(id, userID uuid.UUID) types.UserAddress {
+	return types.UserAddress{
+		ID:        id,
+		UserID:    userID,
+		Kind:      types.AddressKind(a.Kind),
+		IsDefault: a.IsDefault,
+		Address:   a.Address.ToAddress(),
+	}
+}
+
+func (a *PatchUserAddress) ToUserAddressPatch() types.UserAddressPatch {
+	p := types.UserAddressPatch{}
+	if a.Kind != nil {
+		p.Kind = types.Optional[types.AddressKind]{HasValue: true, Value: types.AddressKind(*a.Kind)}
+	}
+	if a.IsDefault != nil {
+		p.IsDefault = types.Optional[bool]{HasValue: true, Value: *a.IsDefault}
+	}
+	if a.Street != nil {
+		p.Address.Street = types.Optional[string]{HasValue: true, Value: *a.Street}
+	}
+	if a.StreetNumber != nil {
+		p.Address.StreetNumber = types.Optional[string]{HasValue: true, Value: *a.StreetNumber}
+	}
+	p.Address.Extra = a.Extra
+	if a.ZipCode != nil {
+		p.Address.ZipCode = types.Optional[string]{HasValue: true, Value: *a.ZipCode}
+	}
+	if a.City != nil {
+		p.Address.City = types.Optional[string]{HasValue: true, Value: *a.City}
+	}
+	return p
+}
+
+// ToQueryParams converts the params to query params, which are limited to a user by the address service
+func (a *UserAddressQueryParams) ToQueryParams() types.QueryParams {
+	p := types.UserAddressPatch{}
+	if a.Kind != nil {
+		p.Kind = types.Optional[types.AddressKind]{HasValue: true, Value: types.AddressKind(*a.Kind)}
+	}
+	if a.IsDefault != nil {
+		p.IsDefault = types.Optional[bool]{HasValue: true, Value: *a.IsDefault}
+	}
+	return types.QueryParams{
+		Pagination: a.Pagination.ToPagination(),
+		Conditions: &p,
+	}
+}
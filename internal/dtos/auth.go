@@ -3,7 +3,7 @@ package dtos
 // @Description login request
 // @Tags auth
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email" format:"email" validate:"required" example:"abc@xyz.com"`
+	Email    string `json:"email" binding:"required,email_address" format:"email" validate:"required" example:"abc@xyz.com"`
 	Password string `json:"password" binding:"required" validate:"required" example:"password"`
 } // @name LoginRequest
 
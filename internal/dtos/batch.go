@@ -0,0 +1,93 @@
+package dtos
+
+import (
+	"encoding/json"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// @Description BatchGetUsers DTO model for fetching many users at once
+// @Tags user
+type BatchGetUsers struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1,max=100" swaggertype:"array,string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+} // @name BatchGetUsers
+
+// @Description BatchGetUsersResponse DTO model for the found users in the requested order and the ids of the missing ones
+// @Tags user
+type BatchGetUsersResponse struct {
+	Items   []User      `json:"items"`
+	Missing []uuid.UUID `json:"missing" swaggertype:"array,string" format:"uuid"`
+} // @name BatchGetUsersResponse
+
+// @Description UserOperation DTO model for a single mutation of a batch
+// @Tags user
+type UserOperation struct {
+	Op string `json:"op" binding:"required,oneof=create patch delete" enums:"create,patch,delete" example:"patch"`
+	// ID of the user to patch or delete
+	ID *uuid.UUID `json:"id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	// User is a SaveUser for create and a PatchUser for patch operations
+	User json.RawMessage `json:"user" swaggertype:"object"`
+} // @name UserOperation
+
+// @Description BatchMutateUsers DTO model for applying many mutations at once
+// @Tags user
+type BatchMutateUsers struct {
+	// Atomic applies all operations in one transaction, otherwise each operation is applied on its own
+	Atomic     bool            `json:"atomic" example:"true"`
+	Operations []UserOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+} // @name BatchMutateUsers
+
+// @Description UserOperationResult DTO model for the outcome of a single mutation of a batch
+// @Tags user
+type UserOperationResult struct {
+	// Status is the HTTP status code the operation would have had on its own,
+	// 424 if it was not applied as another operation of an atomic batch failed
+	Status int   `json:"status" example:"200"`
+	User   *User `json:"user,omitempty"`
+	// Code is the machine-readable code of the error, see the error responses
+	Code  string `json:"code,omitempty" example:"not_found"`
+	Error string `json:"error,omitempty" example:"not found"`
+} // @name UserOperationResult
+
+// @Description BatchMutateUsersResponse DTO model for the outcome of a batch by operation
+// @Tags user
+type BatchMutateUsersResponse struct {
+	Results []UserOperationResult `json:"results"`
+} // @name BatchMutateUsersResponse
+
+func (o *UserOperation) ToUserOperation() (types.UserOperation, error) {
+	op := types.UserOperation{Kind: types.UserOperationKind(o.Op)}
+	if op.Kind != types.UserOperationCreate {
+		if o.ID == nil {
+			return types.UserOperation{}, errors.CombineErrors(types.ErrInvalidOperation, errors.New("id is required"))
+		}
+		op.ID = *o.ID
+	}
+
+	switch op.Kind {
+	case types.UserOperationCreate:
+		userDTO := SaveUser{}
+		if err := decodeOperationUser(o.User, &userDTO); err != nil {
+			return types.UserOperation{}, err
+		}
+		op.Input = userDTO.ToUserInput()
+	case types.UserOperationPatch:
+		userDTO := PatchUser{}
+		if err := decodeOperationUser(o.User, &userDTO); err != nil {
+			return types.UserOperation{}, err
+		}
+		op.Patch = userDTO.ToUserPatch()
+	}
+	return op, nil
+}
+
+// decodeOperationUser decodes the user of an operation like the request binding does, the usecases validate it
+func decodeOperationUser(data json.RawMessage, obj any) error {
+	if len(data) == 0 {
+		return errors.CombineErrors(types.ErrInvalidOperation, errors.New("user is required"))
+	}
+	return BindingError(json.Unmarshal(data, obj))
+}
@@ -0,0 +1,54 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// @Description UserChangesParams DTO model for reading the changes feed of users
+// @Tags user
+type UserChangesParams struct {
+	// Since is the next_since token of a previous response, the feed starts at the beginning without it
+	Since string `json:"since" form:"since" example:"eyJzIjoiY2hhbmdlZF9hdCxjaGFuZ2VfaWQiLCJ2IjpbXX0.c2lnbmF0dXJl"`
+	// Limit is the maximum number of changes, 100 by default
+	Limit int `json:"limit" form:"limit" binding:"omitempty,min=1,max=1000" minimum:"1" maximum:"1000" example:"100"`
+} // @name UserChangesParams
+
+// @Description UserChange DTO model for a create, update (a new version) or delete of a user
+// @Tags user
+type UserChange struct {
+	Kind string `json:"kind" enums:"created,updated,deleted" example:"updated"`
+	// ChangeID identifies the change, it is the version id for creates and updates and the user id for deletes
+	ChangeID  uuid.UUID `json:"change_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	ChangedAt time.Time `json:"changed_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	UserID    uuid.UUID `json:"user_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	// User is the created or updated version, not set for deletes
+	User *User `json:"user,omitempty"`
+} // @name UserChange
+
+// @Description UserChanges DTO model for a part of the changes feed of users
+// @Tags user
+type UserChanges struct {
+	Items []UserChange `json:"items"`
+	// NextSince continues the feed after the items, it is only null if the feed is empty
+	NextSince *string `json:"next_since" example:"eyJzIjoiY2hhbmdlZF9hdCxjaGFuZ2VfaWQiLCJ2IjpbXX0.c2lnbmF0dXJl"`
+	// HasMore is true if more changes are available right away
+	HasMore bool `json:"has_more" example:"false"`
+} // @name UserChanges
+
+func FromUserChange(c *types.UserChange) UserChange {
+	change := UserChange{
+		Kind:      string(c.Kind),
+		ChangeID:  c.ID,
+		ChangedAt: c.ChangedAt,
+		UserID:    c.User.ID,
+	}
+	if c.Kind != types.UserChangeDeleted {
+		user := FromUser(&c.User)
+		change.User = &user
+	}
+	return change
+}
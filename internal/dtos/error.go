@@ -1,6 +1,17 @@
 package dtos
 
-// @Description ErrorResponse DTO model
+// @Description ErrorResponse DTO model of the problem details (RFC 9457) of errors, served as application/problem+json
 type ErrorResponse struct {
-	Error string `json:"error" example:"error message"`
+	// Type is always about:blank, the code identifies the problem
+	Type   string `json:"type" example:"about:blank"`
+	Title  string `json:"title" example:"Conflict"`
+	Status int    `json:"status" example:"409"`
+	// Detail is the message of the error for humans, internal errors have none
+	Detail   string `json:"detail,omitempty" example:"email is already taken"`
+	Instance string `json:"instance" example:"/api/v1/users"`
+	// Code is the stable machine-readable code of the error
+	Code      string `json:"code" example:"email_taken"`
+	RequestID string `json:"request_id" example:"6f1c2b9e-0d5a-4c1e-9a43-2f8e5b7d3c10"`
+	// Errors are the fields which failed the validation, with messages in the language of the Accept-Language header
+	Errors []FieldError `json:"errors,omitempty"`
 } // @Name ErrorResponse
@@ -0,0 +1,151 @@
+package dtos
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// @Description UserVersion DTO model for a single version in the history of a user
+// @Tags user
+type UserVersion struct {
+	VersionID uuid.UUID `json:"version_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	FirstName string    `json:"first_name" example:"John"`
+	LastName  string    `json:"last_name" example:"Doe"`
+	Email     string    `json:"email" format:"email" example:"abc@xyz.com"`
+	Phone     string    `json:"phone" format:"phone" example:"+49123456789"`
+	IsAdmin   bool      `json:"is_admin" example:"false"`
+} // @name UserVersion
+
+// @Description UserExport DTO model containing all data held about a user. The service has no sessions (tokens are stateless),
+// @Description API keys or consents, so there are none to export.
+// @Tags user
+type UserExport struct {
+	GeneratedAt time.Time     `json:"generated_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	Profile     User          `json:"profile"`
+	Versions    []UserVersion `json:"versions"`
+	Addresses   []UserAddress `json:"addresses"`
+	// Events are the audit events of the user, e.g. changes and logins, in the order they occurred in
+	Events []UserEvent `json:"events"`
+} // @name UserExport
+
+// @Description ExportJob DTO model for asynchronously generated exports
+// @Tags user
+type ExportJob struct {
+	ID          uuid.UUID `json:"id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	Format      string    `json:"format" enums:"json,zip" example:"json"`
+	Status      string    `json:"status" enums:"pending,ready,failed" example:"pending"`
+	CreatedAt   time.Time `json:"created_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	DownloadURL string    `json:"download_url" example:"/api/v1/users/me/exports/b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+} // @name ExportJob
+
+// @Description UserBulkExportParams DTO model for bulk exports of users
+// @Tags user
+type UserBulkExportParams struct {
+	UserSearchParams
+	Format string `json:"format" form:"format" binding:"omitempty,oneof=csv ndjson parquet" enums:"csv,ndjson,parquet" example:"csv"`
+	// History exports all versions of the matching users instead of only their latest version
+	History bool `json:"history" form:"history" example:"false"`
+} // @name UserBulkExportParams
+
+// @Description UserExportRow DTO model for a row of bulk exports of users (one line of NDJSON, CSV with the same columns)
+// @Tags user
+type UserExportRow struct {
+	ID              uuid.UUID `json:"id" parquet:"id,uuid" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	VersionID       uuid.UUID `json:"version_id" parquet:"version_id,uuid" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	IsLatestVersion bool      `json:"is_latest_version" parquet:"is_latest_version" example:"true"`
+	FirstName       string    `json:"first_name" parquet:"first_name" example:"John"`
+	LastName        string    `json:"last_name" parquet:"last_name" example:"Doe"`
+	Email           string    `json:"email" parquet:"email" format:"email" example:"abc@xyz.com"`
+	Phone           string    `json:"phone" parquet:"phone" format:"phone" example:"+49123456789"`
+	IsAdmin         bool      `json:"is_admin" parquet:"is_admin" example:"false"`
+	CreatedAt       time.Time `json:"created_at" parquet:"created_at,timestamp(microsecond)" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	UpdatedAt       time.Time `json:"updated_at" parquet:"updated_at,timestamp(microsecond)" format:"date-time" example:"2024-01-01T12:00:00Z"`
+} // @name UserExportRow
+
+// UserExportColumns are the CSV columns of UserExportRow
+var UserExportColumns = []string{"id", "version_id", "is_latest_version", "first_name", "last_name", "email", "phone", "is_admin", "created_at", "updated_at"}
+
+func (r *UserExportRow) CSV() []string {
+	return []string{
+		r.ID.String(),
+		r.VersionID.String(),
+		strconv.FormatBool(r.IsLatestVersion),
+		r.FirstName,
+		r.LastName,
+		r.Email,
+		r.Phone,
+		strconv.FormatBool(r.IsAdmin),
+		r.CreatedAt.Format(time.RFC3339Nano),
+		r.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func (p *UserBulkExportParams) ToBulkExportFormat() types.BulkExportFormat {
+	if p.Format == "" {
+		return types.BulkExportFormatCSV
+	}
+	return types.BulkExportFormat(p.Format)
+}
+
+func FromUserExportRow(u *types.User) UserExportRow {
+	return UserExportRow{
+		ID:              u.ID,
+		VersionID:       u.VersionID,
+		IsLatestVersion: u.IsLatestVersion,
+		FirstName:       u.FirstName,
+		LastName:        u.LastName,
+		Email:           u.Email,
+		Phone:           u.Phone,
+		IsAdmin:         u.IsAdmin,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+	}
+}
+
+func FromUserVersion(u *types.User) UserVersion {
+	return UserVersion{
+		VersionID: u.VersionID,
+		CreatedAt: u.UpdatedAt,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		IsAdmin:   u.IsAdmin,
+	}
+}
+
+func FromUserExport(e *types.UserExport) UserExport {
+	versions := make([]UserVersion, len(e.Versions))
+	for i, version := range e.Versions {
+		versions[i] = FromUserVersion(&version)
+	}
+	addresses := make([]UserAddress, len(e.Addresses))
+	for i, address := range e.Addresses {
+		addresses[i] = FromUserAddress(&address)
+	}
+	events := make([]UserEvent, len(e.Events))
+	for i, event := range e.Events {
+		events[i] = FromUserEvent(&event)
+	}
+	return UserExport{
+		GeneratedAt: e.GeneratedAt,
+		Profile:     FromUser(&e.User),
+		Versions:    versions,
+		Addresses:   addresses,
+		Events:      events,
+	}
+}
+
+func FromExportJob(j *types.ExportJob, downloadURL string) ExportJob {
+	return ExportJob{
+		ID:          j.ID,
+		Format:      string(j.Format),
+		Status:      string(j.Status),
+		CreatedAt:   j.CreatedAt,
+		DownloadURL: downloadURL,
+	}
+}
@@ -0,0 +1,89 @@
+package dtos
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// @Description ImportParams DTO model for the options of user imports
+// @Tags user
+type ImportParams struct {
+	// Format of the request body, defaults to the format of the Content-Type (text/csv or application/x-ndjson)
+	Format string `json:"format" form:"format" binding:"omitempty,oneof=csv ndjson" enums:"csv,ndjson" example:"csv"`
+	// DryRun only validates the rows and reports what would happen, nothing is saved
+	DryRun bool `json:"dry_run" form:"dry_run" example:"false"`
+	// Upsert saves rows with the email of an existing user as a new version of that user, otherwise they fail.
+	// The admin flag of the existing user is kept unless the row sets is_admin.
+	Upsert bool `json:"upsert" form:"upsert" example:"false"`
+} // @name ImportParams
+
+// @Description ImportRow DTO model for the outcome of a single row of a user import
+// @Tags user
+type ImportRow struct {
+	// Row is the line of the row in the input
+	Row    int        `json:"row" example:"2"`
+	Email  string     `json:"email,omitempty" format:"email" example:"abc@xyz.com"`
+	Status string     `json:"status" enums:"created,updated,failed" example:"created"`
+	ID     *uuid.UUID `json:"id,omitempty" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	Error  string     `json:"error,omitempty" example:"phone is not a valid E.164 phone number"`
+} // @name ImportRow
+
+// @Description ImportReport DTO model for the outcome of a user import by row
+// @Tags user
+type ImportReport struct {
+	DryRun  bool        `json:"dry_run" example:"false"`
+	Created int         `json:"created" example:"1"`
+	Updated int         `json:"updated" example:"0"`
+	Failed  int         `json:"failed" example:"0"`
+	Rows    []ImportRow `json:"rows"`
+} // @name ImportReport
+
+// Validate checks the user with the rules of types.UserInput, which the usecases check as well,
+// the error has the English messages of the failed fields
+func (u *SaveUser) Validate() error {
+	err := u.ToUserInput().Validate()
+	var validationErr *types.ValidationError
+	if errors.As(err, &validationErr) {
+		fieldErrors := FromFieldErrors(validationErr.Fields, language.English)
+		messages := make([]string, len(fieldErrors))
+		for i, fieldError := range fieldErrors {
+			messages[i] = fieldError.Message
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+	return err
+}
+
+func (p *ImportParams) ToImportOptions() types.ImportOptions {
+	return types.ImportOptions{
+		DryRun: p.DryRun,
+		Upsert: p.Upsert,
+	}
+}
+
+func FromImportReport(r *types.ImportReport) ImportReport {
+	rows := make([]ImportRow, len(r.Rows))
+	for i, row := range r.Rows {
+		rows[i] = ImportRow{
+			Row:    row.Row,
+			Email:  row.Email,
+			Status: string(row.Status),
+			Error:  row.Error,
+		}
+		if row.ID != uuid.Nil {
+			rows[i].ID = types.Pointer(row.ID)
+		}
+	}
+	return ImportReport{
+		DryRun:  r.DryRun,
+		Created: r.Created,
+		Updated: r.Updated,
+		Failed:  r.Failed,
+		Rows:    rows,
+	}
+}
@@ -0,0 +1,117 @@
+package dtos
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// languages are the languages of the messages of field errors, the first one is the default
+var languages = []language.Tag{language.English, language.German}
+
+var languageMatcher = language.NewMatcher(languages)
+
+// messages are the messages of the validation rules by language, the field is the first and the parameter of the rule
+// the second argument. Rules with lengths or sizes have a message by the kind of the field, unknown rules the default.
+var messages = map[language.Tag]map[string]string{
+	language.English: {
+		"validation_failed": "The request has invalid fields",
+		"default":           "%[1]s is invalid",
+		"required":          "%[1]s is required",
+		"type":              "%[1]s must be of type %[2]s",
+		"oneof":             "%[1]s must be one of %[2]s",
+		"min.string":        "%[1]s must have at least %[2]s characters",
+		"min.array":         "%[1]s must have at least %[2]s items",
+		"min.number":        "%[1]s must be at least %[2]s",
+		"max.string":        "%[1]s must have at most %[2]s characters",
+		"max.array":         "%[1]s must have at most %[2]s items",
+		"max.number":        "%[1]s must be at most %[2]s",
+		"max_bytes":         "%[1]s must have at most %[2]s bytes",
+		"email_address":     "%[1]s must be a valid email address",
+		"phone":             "%[1]s must be an E.164 phone number, e.g. +49123456789",
+		"zip_code":          "%[1]s must be a 5 digit zip code",
+		"street_number":     "%[1]s must be a street number, e.g. 123, 123a or 123-125",
+		"http_url":          "%[1]s must be an http or https URL",
+		"unique":            "%[1]s must not contain duplicates",
+	},
+	language.German: {
+		"validation_failed": "Die Anfrage hat ungültige Felder",
+		"default":           "%[1]s ist ungültig",
+		"required":          "%[1]s ist erforderlich",
+		"type":              "%[1]s muss vom Typ %[2]s sein",
+		"oneof":             "%[1]s muss einer der Werte %[2]s sein",
+		"min.string":        "%[1]s muss mindestens %[2]s Zeichen haben",
+		"min.array":         "%[1]s muss mindestens %[2]s Einträge haben",
+		"min.number":        "%[1]s muss mindestens %[2]s sein",
+		"max.string":        "%[1]s darf höchstens %[2]s Zeichen haben",
+		"max.array":         "%[1]s darf höchstens %[2]s Einträge haben",
+		"max.number":        "%[1]s darf höchstens %[2]s sein",
+		"max_bytes":         "%[1]s darf höchstens %[2]s Bytes haben",
+		"email_address":     "%[1]s muss eine gültige E-Mail-Adresse sein",
+		"phone":             "%[1]s muss eine E.164-Telefonnummer sein, z. B. +49123456789",
+		"zip_code":          "%[1]s muss eine 5-stellige Postleitzahl sein",
+		"street_number":     "%[1]s muss eine Hausnummer sein, z. B. 123, 123a oder 123-125",
+		"http_url":          "%[1]s muss eine http- oder https-URL sein",
+		"unique":            "%[1]s darf keine Duplikate enthalten",
+	},
+}
+
+// MatchLanguage returns the supported language preferred by an Accept-Language header, English by default
+func MatchLanguage(acceptLanguage string) language.Tag {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := languageMatcher.Match(tags...)
+	return languages[index]
+}
+
+// ValidationMessage returns the message of failed validations in the language
+func ValidationMessage(lang language.Tag) string {
+	return messages[lang]["validation_failed"]
+}
+
+// @Description FieldError DTO model for a field which failed a validation rule
+type FieldError struct {
+	// Pointer is the JSON Pointer of the field in the body, or the name of the query parameter
+	Pointer string `json:"pointer" example:"/phone"`
+	Rule    string `json:"rule" example:"phone"`
+	Message string `json:"message" example:"phone must be an E.164 phone number, e.g. +49123456789"`
+} // @name FieldError
+
+func FromFieldErrors(fields []types.FieldError, lang language.Tag) []FieldError {
+	fieldErrors := make([]FieldError, len(fields))
+	for i, field := range fields {
+		fieldErrors[i] = FieldError{
+			Pointer: field.Pointer,
+			Rule:    field.Rule,
+			Message: fieldMessage(field, lang),
+		}
+	}
+	return fieldErrors
+}
+
+func fieldMessage(field types.FieldError, lang language.Tag) string {
+	key := field.Rule
+	if key == "min" || key == "max" {
+		switch field.Kind {
+		case reflect.String:
+			key += ".string"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			key += ".array"
+		default:
+			key += ".number"
+		}
+	}
+	message, ok := messages[lang][key]
+	if !ok {
+		message = messages[lang]["default"]
+	}
+
+	name := field.Pointer[strings.LastIndex(field.Pointer, "/")+1:]
+	if name == "" {
+		name = "value"
+	}
+	return fmt.Sprintf(message, name, field.Param)
+}
@@ -5,8 +5,8 @@ import "github.com/pedramktb/schwarzit-probearbeit/internal/types"
 // @Description pagination model for queries
 // @Tags pagination
 type Pagination struct {
-	Limit  int `json:"limit" example:"10"`
-	Offset int `json:"offset" example:"0"`
+	Limit  int `json:"limit" form:"limit" binding:"omitempty,min=0,max=100" minimum:"0" maximum:"100" example:"10"`
+	Offset int `json:"offset" form:"offset" binding:"omitempty,min=0" minimum:"0" example:"0"`
 } // @name Pagination
 
 func (p *Pagination) ToPagination() types.Pagination {
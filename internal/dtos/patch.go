@@ -0,0 +1,140 @@
+package dtos
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// @Description JSONPatchOperation DTO model for the operations of JSON Patches (RFC 6902)
+// @Tags user
+type JSONPatchOperation struct {
+	Op   string `json:"op" binding:"required,oneof=add remove replace move copy test" enums:"add,remove,replace,move,copy,test" example:"replace"`
+	Path string `json:"path" example:"/last_name"`
+	// From is the path of the value to move or copy
+	From string `json:"from,omitempty" example:"/first_name"`
+	// Value to add, replace or test, required for these operations
+	Value types.Optional[any] `json:"value" swaggertype:"object" example:"Doe"`
+} // @name JSONPatchOperation
+
+// @Description MergePatchUser DTO model for user updates as JSON Merge Patch (RFC 7396), null removes a field, which no field of users allows
+// @Tags user
+type MergePatchUser struct {
+	FirstName types.Optional[*string] `json:"first_name" swaggertype:"string" example:"John"`
+	LastName  types.Optional[*string] `json:"last_name" swaggertype:"string" example:"Doe"`
+	Email     types.Optional[*string] `json:"email" swaggertype:"string" format:"email" example:"abc@xyz.com"`
+	Phone     types.Optional[*string] `json:"phone" swaggertype:"string" format:"phone" example:"+49123456789"`
+	IsAdmin   types.Optional[*bool]   `json:"is_admin" swaggertype:"boolean" example:"false"`
+	Password  types.Optional[*string] `json:"password" swaggertype:"string" example:"password"`
+} // @name MergePatchUser
+
+func ToJSONPatchOperations(operations []JSONPatchOperation) []types.JSONPatchOperation {
+	patch := make([]types.JSONPatchOperation, len(operations))
+	for i, operation := range operations {
+		patch[i] = types.JSONPatchOperation{
+			Op:    types.JSONPatchOp(operation.Op),
+			Path:  operation.Path,
+			From:  operation.From,
+			Value: operation.Value,
+		}
+	}
+	return patch
+}
+
+// notNull returns the value of a member of a merge patch, null would remove a field users can't be without
+func notNull[T any](field string, member types.Optional[*T], fields *[]types.FieldError) types.Optional[T] {
+	if !member.HasValue {
+		return types.Optional[T]{}
+	}
+	if member.Value == nil {
+		*fields = append(*fields, types.FieldError{Pointer: "/" + field, Rule: "required"})
+		return types.Optional[T]{}
+	}
+	return types.ToOptional(*member.Value)
+}
+
+// ToUserPatch returns the patch of a merge patch, the usecases validate the values of its members
+func (u *MergePatchUser) ToUserPatch() (types.UserPatch, error) {
+	var fields []types.FieldError
+	userPatch := types.UserPatch{
+		FirstName: notNull("first_name", u.FirstName, &fields),
+		LastName:  notNull("last_name", u.LastName, &fields),
+		Email:     notNull("email", u.Email, &fields),
+		Phone:     notNull("phone", u.Phone, &fields),
+		IsAdmin:   notNull("is_admin", u.IsAdmin, &fields),
+		Password:  notNull("password", u.Password, &fields),
+	}
+	if len(fields) > 0 {
+		return userPatch, &types.ValidationError{Fields: fields}
+	}
+	return userPatch, nil
+}
+
+// ToUserDocument returns the writable fields of a user as the document JSON Patches are applied to,
+// the password is write-only and can only be added
+func ToUserDocument(u *types.User) map[string]any {
+	return map[string]any{
+		"first_name": u.FirstName,
+		"last_name":  u.LastName,
+		"email":      u.Email,
+		"phone":      u.Phone,
+		"is_admin":   u.IsAdmin,
+	}
+}
+
+// UserPatchFromDocument returns the changes of a patched user document to the user
+func UserPatchFromDocument(u *types.User, document any) (types.UserPatch, error) {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return types.UserPatch{}, errors.Join(types.ErrInvalidPatch, err)
+	}
+	var patchDTO MergePatchUser
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patchDTO); err != nil {
+		return types.UserPatch{}, errors.Join(types.ErrInvalidPatch, err)
+	}
+
+	// The patched document is the whole user, a missing field was removed
+	var fields []types.FieldError
+	for _, member := range []struct {
+		field   string
+		present bool
+	}{
+		{"first_name", patchDTO.FirstName.HasValue},
+		{"last_name", patchDTO.LastName.HasValue},
+		{"email", patchDTO.Email.HasValue},
+		{"phone", patchDTO.Phone.HasValue},
+		{"is_admin", patchDTO.IsAdmin.HasValue},
+	} {
+		if !member.present {
+			fields = append(fields, types.FieldError{Pointer: "/" + member.field, Rule: "required"})
+		}
+	}
+	if len(fields) > 0 {
+		return types.UserPatch{}, &types.ValidationError{Fields: fields}
+	}
+	userPatch, err := patchDTO.ToUserPatch()
+	if err != nil {
+		return userPatch, err
+	}
+	if userPatch.FirstName.Value == u.FirstName {
+		userPatch.FirstName = types.Optional[string]{}
+	}
+	if userPatch.LastName.Value == u.LastName {
+		userPatch.LastName = types.Optional[string]{}
+	}
+	if userPatch.Email.Value == u.Email {
+		userPatch.Email = types.Optional[string]{}
+	}
+	if userPatch.Phone.Value == u.Phone {
+		userPatch.Phone = types.Optional[string]{}
+	}
+	if userPatch.IsAdmin.Value == u.IsAdmin {
+		userPatch.IsAdmin = types.Optional[bool]{}
+	}
+	return userPatch, nil
+}
@@ -0,0 +1,31 @@
+package dtos
+
+import (
+	"encoding/json"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// SparseUserPage is a UserPage with the users limited to the requested fields
+type SparseUserPage struct {
+	UserPage
+	Items []map[string]json.RawMessage `json:"items"`
+}
+
+// Sparse returns the JSON object of the DTO limited to the given fields
+func Sparse(v any, fields types.Fields) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	for key := range object {
+		if !fields.Contains(key) {
+			delete(object, key)
+		}
+	}
+	return object, nil
+}
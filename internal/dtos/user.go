@@ -1,9 +1,13 @@
 package dtos
 
 import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // @Description User DTO model for responses
@@ -15,35 +19,86 @@ type User struct {
 	LastName  string    `json:"last_name" example:"Doe"`
 	Email     string    `json:"email" format:"email" example:"abc@xyz.com"`
 	Phone     string    `json:"phone" format:"phone" example:"+49123456789"`
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	// Versions is the version history, only if expanded
+	Versions *[]UserVersion `json:"versions,omitempty"`
+	// Addresses are only included if expanded
+	Addresses *[]UserAddress `json:"addresses,omitempty"`
 } // @name User
 
+// @Description UserViewParams DTO model for the fields and related data of user responses
+// @Tags user
+type UserViewParams struct {
+	// Fields limits the attributes of the users to the comma separated fields
+	// (id, version_id, first_name, last_name, email, phone, created_at, updated_at)
+	Fields string `json:"fields" form:"fields" example:"id,first_name,last_name"`
+	// Expand embeds the comma separated related data (versions, addresses), requires admin access.
+	// Sessions can't be expanded and fail with invalid_expand, the tokens are stateless and there are no sessions.
+	Expand string `json:"expand" form:"expand" example:"versions,addresses"`
+} // @name UserViewParams
+
+// @Description UserPage DTO model for pages of users
+// @Tags user
+type UserPage struct {
+	Items      []User  `json:"items"`
+	NextCursor *string `json:"next_cursor" example:"eyJzIjoiaWQiLCJ2IjpbIjEiXX0.c2lnbmF0dXJl"`
+	PrevCursor *string `json:"prev_cursor" example:"eyJzIjoiaWQiLCJ2IjpbIjEiXSwiYiI6dHJ1ZX0.c2lnbmF0dXJl"`
+	Total      *int64  `json:"total,omitempty" example:"42"`
+} // @name UserPage
+
 // @Description QueryUser DTO model for user queries
 // @Tags user
 type QueryUser struct {
-	ID        *uuid.UUID `json:"id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
-	VersionID *uuid.UUID `json:"version_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
-	FirstName *string    `json:"first_name" example:"John"`
-	LastName  *string    `json:"last_name" example:"Doe"`
-	Email     *string    `json:"email" binding:"omitempty,email" format:"email" example:"abc@xyz.com"`
-	Phone     *string    `json:"phone" format:"phone" example:"+49123456789"`
+	ID        *uuid.UUID `json:"id" form:"id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	VersionID *uuid.UUID `json:"version_id" form:"version_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	FirstName *string    `json:"first_name" form:"first_name" example:"John"`
+	LastName  *string    `json:"last_name" form:"last_name" example:"Doe"`
+	Email     *string    `json:"email" form:"email" binding:"omitempty,email_address" format:"email" example:"abc@xyz.com"`
+	Phone     *string    `json:"phone" form:"phone" format:"phone" example:"+49123456789"`
+	City      *string    `json:"city" form:"city" example:"Berlin"`
+	ZipCode   *string    `json:"zip_code" form:"zip_code" example:"12345"`
 } // @name QueryUser
 
+// @Description UserSearchParams DTO model for the filters, search and sort of user queries
+// @Tags user
+type UserSearchParams struct {
+	QueryUser
+	// Filter expression `field:operator:value`, conditions are combined with `,` (AND) and `|` (OR, binding stronger).
+	// Fields: id, version_id, first_name, last_name, email, phone, is_admin, created_at, updated_at,
+	// street, street_number, zip_code, city (address fields match any address of the user).
+	// Operators: eq, ne, gt, gte, lt, lte, in (values separated by `;`) and the case-insensitive prefix, suffix, contains.
+	// `\` escapes `,`, `|`, `;` and `\` in values.
+	Filter string `json:"filter" form:"filter" example:"last_name:prefix:Mü|first_name:prefix:Mü,created_at:gte:2024-01-01"`
+	// Search for partial or misspelled names, emails and phone numbers, the results are sorted by relevance by default
+	Q string `json:"q" form:"q" binding:"omitempty,max=255" example:"jon doe"`
+	// Sort by comma separated fields, a `-` prefix sorts descending, ties are broken by id.
+	// Fields: id, first_name, last_name, email, created_at, updated_at and relevance (only with q).
+	Sort string `json:"sort" form:"sort" example:"last_name,-created_at"`
+} // @name UserSearchParams
+
 // @Description UserQueryParams DTO model for user query parameters
 // @Tags user
 type UserQueryParams struct {
 	Pagination
-	QueryUser
+	UserSearchParams
+	UserViewParams
+	// Cursor from next_cursor or prev_cursor of a previous response, replaces the offset.
+	// It is only valid with the same filters and sort as the query it was returned from.
+	Cursor string `json:"cursor" form:"cursor"`
+	// Total requests the number of all matching users in the response and the X-Total-Count header
+	Total bool `json:"total" form:"total" example:"false"`
 } // @name UserQueryParams
 
 // @Description SaveUser DTO model for user creation and updates (overwrites)
 // @Tags user
 type SaveUser struct {
-	FirstName string `json:"first_name" binding:"required" validate:"required" example:"John"`
-	LastName  string `json:"last_name" binding:"required" validate:"required" example:"Doe"`
-	Email     string `json:"email" binding:"required,email" validate:"required" format:"email" example:"abc@xyz.com"`
-	Phone     string `json:"phone" binding:"required" validate:"required" format:"phone" example:"+49123456789"`
+	FirstName string `json:"first_name" validate:"required" example:"John"`
+	LastName  string `json:"last_name" validate:"required" example:"Doe"`
+	Email     string `json:"email" validate:"required" format:"email" example:"abc@xyz.com"`
+	Phone     string `json:"phone" validate:"required" format:"phone" example:"+49123456789"`
 	IsAdmin   bool   `json:"is_admin" example:"false"`
-	Password  string `json:"password" binding:"required" validate:"required" example:"password"`
+	Password  string `json:"password" validate:"required" example:"password"`
 } // @name SaveUser
 
 // @Description PatchUser DTO model for user updates (partial)
@@ -51,7 +106,7 @@ type SaveUser struct {
 type PatchUser struct {
 	FirstName *string `json:"first_name" example:"John"`
 	LastName  *string `json:"last_name" example:"Doe"`
-	Email     *string `json:"email" binding:"omitempty,email" format:"email" example:"abc@xyz.com"`
+	Email     *string `json:"email" format:"email" example:"abc@xyz.com"`
 	Phone     *string `json:"phone" format:"phone" example:"+49123456789"`
 	IsAdmin   *bool   `json:"is_admin" example:"false"`
 	Password  *string `json:"password" example:"password"`
@@ -60,11 +115,11 @@ type PatchUser struct {
 // @Description RegisterUser DTO model for user registration
 // @Tags user
 type RegisterUser struct {
-	FirstName string `json:"first_name" binding:"required" validate:"required" example:"John"`
-	LastName  string `json:"last_name" binding:"required" validate:"required" example:"Doe"`
-	Email     string `json:"email" binding:"required,email" validate:"required" format:"email" example:"abc@xyz.com"`
-	Phone     string `json:"phone" binding:"required" validate:"required" example:"+49123456789"`
-	Password  string `json:"password" binding:"required" validate:"required" example:"password"`
+	FirstName string `json:"first_name" validate:"required" example:"John"`
+	LastName  string `json:"last_name" validate:"required" example:"Doe"`
+	Email     string `json:"email" validate:"required" format:"email" example:"abc@xyz.com"`
+	Phone     string `json:"phone" validate:"required" example:"+49123456789"`
+	Password  string `json:"password" validate:"required" example:"password"`
 } // @name RegisterUser
 
 func FromUser(u *types.User) User {
@@ -75,7 +130,54 @@ func FromUser(u *types.User) User {
 		LastName:  u.LastName,
 		Email:     u.Email,
 		Phone:     u.Phone,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+// FromUserPage converts a page of users with its encoded cursors
+func FromUserPage(p *types.Page[types.User], next, prev *string) UserPage {
+	items := make([]User, len(p.Items))
+	for i, user := range p.Items {
+		items[i] = FromUser(&user)
+	}
+	return UserPage{
+		Items:      items,
+		NextCursor: next,
+		PrevCursor: prev,
+		Total:      p.Total,
+	}
+}
+
+func (v *UserViewParams) ToFields() (types.Fields, error) {
+	fields, err := types.ParseFields(v.Fields, types.UserFields)
+	if err != nil {
+		return nil, errors.Join(types.ErrInvalidFields, err)
+	}
+	return fields, nil
+}
+
+func (v *UserViewParams) ToExpand() (types.Fields, error) {
+	if slices.Contains(strings.Split(v.Expand, ","), "sessions") {
+		return nil, errors.Join(types.ErrInvalidExpand, errors.New("sessions can't be expanded, tokens are stateless and there are no sessions"))
+	}
+	expand, err := types.ParseFields(v.Expand, types.UserExpansions)
+	if err != nil {
+		return nil, errors.Join(types.ErrInvalidExpand, err)
 	}
+	return expand, nil
+}
+
+// ToFilter returns the exact-equality conditions on the addresses of the user
+func (u *QueryUser) ToFilter() types.Filter {
+	conditions := map[string]any{}
+	if u.City != nil {
+		conditions["city"] = *u.City
+	}
+	if u.ZipCode != nil {
+		conditions["zip_code"] = *u.ZipCode
+	}
+	return types.EqualFilter(conditions)
 }
 
 func (u *QueryUser) ToUserPatch() types.UserPatch {
@@ -101,33 +203,49 @@ func (u *QueryUser) ToUserPatch() types.UserPatch {
 	return p
 }
 
-func (u *UserQueryParams) ToQueryParams() types.QueryParams {
+func (u *UserSearchParams) ToQueryParams() (types.QueryParams, error) {
+	filter, err := types.ParseFilter(u.Filter, types.UserFilterFields)
+	if err != nil {
+		return types.QueryParams{}, err
+	}
+	sort, err := types.ParseSort(u.Sort, types.UserSortFields)
+	if err != nil {
+		return types.QueryParams{}, err
+	}
+	if u.Q == "" && slices.ContainsFunc(sort, func(k types.SortKey) bool { return k.Field == "relevance" }) {
+		return types.QueryParams{}, errors.Join(types.ErrInvalidSort, errors.New("relevance requires a search"))
+	} else if u.Q != "" && sort == nil {
+		sort = types.Sort{{Field: "relevance", Desc: true}}
+	}
 	return types.QueryParams{
-		Pagination: u.Pagination.ToPagination(),
 		Conditions: types.Pointer(u.QueryUser.ToUserPatch()),
-	}
+		Filter:     filter.And(u.QueryUser.ToFilter()),
+		Search:     u.Q,
+		Sort:       sort,
+	}, nil
 }
 
-func (u *SaveUser) ToCreateUser() types.User {
-	return types.User{
-		FirstName:    u.FirstName,
-		LastName:     u.LastName,
-		Email:        u.Email,
-		Phone:        u.Phone,
-		IsAdmin:      u.IsAdmin,
-		PasswordHash: HashPassword(u.Password),
+func (u *UserQueryParams) ToQueryParams() (types.QueryParams, error) {
+	params, err := u.UserSearchParams.ToQueryParams()
+	if err != nil {
+		return params, err
 	}
+	if params.Fields, err = u.UserViewParams.ToFields(); err != nil {
+		return types.QueryParams{}, err
+	}
+	params.Pagination = u.Pagination.ToPagination()
+	params.Total = u.Total
+	return params, nil
 }
 
-func (u *SaveUser) ToUpdateUser(id uuid.UUID) types.User {
-	return types.User{
-		ID:           id,
-		FirstName:    u.FirstName,
-		LastName:     u.LastName,
-		Email:        u.Email,
-		Phone:        u.Phone,
-		IsAdmin:      u.IsAdmin,
-		PasswordHash: HashPassword(u.Password),
+func (u *SaveUser) ToUserInput() types.UserInput {
+	return types.UserInput{
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		IsAdmin:   u.IsAdmin,
+		Password:  u.Password,
 	}
 }
 
@@ -149,22 +267,17 @@ func (u *PatchUser) ToUserPatch() types.UserPatch {
 		userPatch.IsAdmin = types.Optional[bool]{HasValue: true, Value: *u.IsAdmin}
 	}
 	if u.Password != nil {
-		userPatch.PasswordHash = types.Optional[string]{HasValue: true, Value: HashPassword(*u.Password)}
+		userPatch.Password = types.Optional[string]{HasValue: true, Value: *u.Password}
 	}
 	return userPatch
 }
 
-func (u *RegisterUser) ToUser() types.User {
-	return types.User{
-		FirstName:    u.FirstName,
-		LastName:     u.LastName,
-		Email:        u.Email,
-		Phone:        u.Phone,
-		PasswordHash: HashPassword(u.Password),
+func (u *RegisterUser) ToUserInput() types.UserInput {
+	return types.UserInput{
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		Password:  u.Password,
 	}
 }
-
-func HashPassword(password string) string {
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hash)
-}
@@ -0,0 +1,53 @@
+package dtos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+func Test_ToExpand(t *testing.T) {
+	tests := []struct {
+		name    string
+		expand  string
+		want    types.Fields
+		wantErr string
+	}{
+		{
+			name:   "Empty Case",
+			expand: "",
+		},
+		{
+			name:   "Duplicates Case",
+			expand: "addresses,versions,addresses",
+			want:   types.Fields{"addresses", "versions"},
+		},
+		{
+			name:    "Sessions Case",
+			expand:  "versions,sessions",
+			wantErr: "invalid expand\nsessions can't be expanded, tokens are stateless and there are no sessions",
+		},
+		{
+			name:    "Unknown Case",
+			expand:  "friends",
+			wantErr: "invalid expand\nunknown field \"friends\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := UserViewParams{Expand: tt.expand}
+			got, err := params.ToExpand()
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, types.ErrInvalidExpand)
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
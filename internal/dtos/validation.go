@@ -0,0 +1,50 @@
+package dtos
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// validate is the validator of the binding, with the rules of types.RegisterValidations
+var validate *validator.Validate
+
+// init is used instead of Dependency Injection, as the binding rules are needed wherever DTOs are validated
+func init() {
+	var ok bool
+	if validate, ok = binding.Validator.Engine().(*validator.Validate); !ok {
+		panic("unexpected validator of gin")
+	}
+	types.RegisterValidations(validate)
+}
+
+// ValidateElements validates the elements of a slice like the binding does, but keeps the indexes of the failed elements
+func ValidateElements(slice any) error {
+	return validate.Var(slice, "dive")
+}
+
+// BindingError converts the errors of binding requests to errors of the fields which failed the validation,
+// other errors are bad requests
+func BindingError(err error) error {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &validationErrs):
+		return types.NewValidationError(validationErrs)
+	case errors.As(err, &typeErr):
+		return &types.ValidationError{Fields: []types.FieldError{{
+			Pointer: "/" + strings.ReplaceAll(types.EscapePointerToken(typeErr.Field), ".", "/"),
+			Rule:    "type",
+			Param:   typeErr.Type.Kind().String(),
+			Kind:    typeErr.Type.Kind(),
+		}}}
+	}
+	return errors.Join(types.ErrBadRequest, err)
+}
@@ -0,0 +1,128 @@
+package dtos
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+func Test_BindingError(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		obj        any
+		wantFields []FieldError
+	}{
+		{
+			name: "Valid Case",
+			body: `{"first_name":"John","last_name":"Doe","email":"john@xyz.com","phone":"+49123456789","password":"password"}`,
+			obj:  &SaveUser{},
+		},
+		{
+			name: "Embedded Address Case",
+			body: `{"kind":"home","street":"Main Street","street_number":"12-a","zip_code":"1234","city":"Berlin"}`,
+			obj:  &SaveUserAddress{},
+			wantFields: []FieldError{
+				{Pointer: "/street_number", Rule: "street_number", Message: "street_number must be a street number, e.g. 123, 123a or 123-125"},
+				{Pointer: "/zip_code", Rule: "zip_code", Message: "zip_code must be a 5 digit zip code"},
+			},
+		},
+		{
+			name: "Nested Case",
+			body: `{"operations":[{"op":"create"},{"op":"upsert"}]}`,
+			obj:  &BatchMutateUsers{},
+			wantFields: []FieldError{
+				{Pointer: "/operations/1/op", Rule: "oneof", Message: "op must be one of create patch delete"},
+			},
+		},
+		{
+			name: "Type Case",
+			body: `{"first_name":1}`,
+			obj:  &PatchUser{},
+			wantFields: []FieldError{
+				{Pointer: "/first_name", Rule: "type", Message: "first_name must be of type string"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := binding.JSON.BindBody([]byte(tt.body), tt.obj)
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *types.ValidationError
+			if !errors.As(BindingError(err), &validationErr) {
+				t.Fatalf("BindingError() = %v, want a validation error", BindingError(err))
+			}
+			assert.Equal(t, tt.wantFields, FromFieldErrors(validationErr.Fields, language.English))
+		})
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	user := SaveUser{FirstName: strings.Repeat("a", 256), LastName: "Doe", Email: "john@localhost", Phone: "0049123"}
+
+	// The messages of the rules of the usecases, as reported by the import
+	assert.EqualError(t, user.Validate(), "first_name must have at most 255 characters; email must be a valid email address; "+
+		"phone must be an E.164 phone number, e.g. +49123456789; password is required")
+}
+
+func Test_ValidateElements(t *testing.T) {
+	var operations []JSONPatchOperation
+	if err := json.Unmarshal([]byte(`[{"op":"test","path":"/email"},{"op":"append","path":"/email"}]`), &operations); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	var validationErr *types.ValidationError
+	if !errors.As(BindingError(ValidateElements(operations)), &validationErr) {
+		t.Fatal("ValidateElements() want a validation error")
+	}
+	assert.Equal(t, []types.FieldError{{Pointer: "/1/op", Rule: "oneof", Param: "add remove replace move copy test", Kind: validationErr.Fields[0].Kind}}, validationErr.Fields)
+}
+
+func Test_MatchLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           language.Tag
+	}{
+		{
+			name:           "No Header Case",
+			acceptLanguage: "",
+			want:           language.English,
+		},
+		{
+			name:           "Regional Case",
+			acceptLanguage: "de-AT,de;q=0.9,en;q=0.8",
+			want:           language.German,
+		},
+		{
+			name:           "Quality Case",
+			acceptLanguage: "fr;q=1.0,en;q=0.5,de;q=0.7",
+			want:           language.German,
+		},
+		{
+			name:           "Unsupported Case",
+			acceptLanguage: "fr",
+			want:           language.English,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchLanguage(tt.acceptLanguage))
+		})
+	}
+
+	field := types.FieldError{Pointer: "/phone", Rule: "phone"}
+	assert.Equal(t, "phone muss eine E.164-Telefonnummer sein, z. B. +49123456789", fieldMessage(field, language.German))
+}
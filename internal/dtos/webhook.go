@@ -0,0 +1,183 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// @Description Webhook DTO model for responses, the secret is only returned on creation
+// @Tags webhook
+type Webhook struct {
+	ID        uuid.UUID `json:"id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	URL       string    `json:"url" format:"uri" example:"https://example.com/hooks/users"`
+	Events    []string  `json:"events" enums:"user.created,user.updated,user.deleted,user.password_changed,user.login_succeeded,user.login_failed" example:"user.created,user.deleted"`
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+} // @name Webhook
+
+// @Description CreatedWebhook DTO model for the response of webhook creations
+// @Tags webhook
+type CreatedWebhook struct {
+	Webhook
+	// Secret signs the deliveries, it can't be read again
+	Secret string `json:"secret" example:"3f7a2c9e8b1d4f6a0c5e7b9d2f4a6c8e"`
+} // @name CreatedWebhook
+
+// @Description SaveWebhook DTO model for webhook creations and updates (overwrites)
+// @Tags webhook
+type SaveWebhook struct {
+	URL    string   `json:"url" binding:"required,http_url,max=1000" validate:"required" format:"uri" example:"https://example.com/hooks/users"`
+	Events []string `json:"events" binding:"required,min=1,unique,dive,oneof=user.created user.updated user.deleted user.password_changed user.login_succeeded user.login_failed" validate:"required" enums:"user.created,user.updated,user.deleted,user.password_changed,user.login_succeeded,user.login_failed" example:"user.created,user.deleted"`
+	// Secret signs the deliveries, it is generated on creations and kept on updates if missing
+	Secret *string `json:"secret" binding:"omitnil,min=16,max=255" example:"3f7a2c9e8b1d4f6a0c5e7b9d2f4a6c8e"`
+} // @name SaveWebhook
+
+// @Description UserEvent DTO model for the events delivered to webhooks, which is also the body of the deliveries, and exported with users
+// @Tags webhook
+type UserEvent struct {
+	// ID identifies the event, receivers can skip events they have seen already
+	ID     uuid.UUID `json:"id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	Kind   string    `json:"kind" enums:"user.created,user.updated,user.deleted,user.password_changed,user.login_succeeded,user.login_failed" example:"user.updated"`
+	UserID uuid.UUID `json:"user_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	// VersionID is the created version of created and updated users and of changed passwords, the nil uuid otherwise
+	VersionID uuid.UUID `json:"version_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	// PreviousVersionID is the version of updated users that was the latest one before, the nil uuid otherwise
+	PreviousVersionID uuid.UUID `json:"previous_version_id" swaggertype:"string" format:"uuid" example:"00000000-0000-0000-0000-000000000000"`
+	// Email is the email logins were tried with
+	Email      string    `json:"email,omitempty" format:"email" example:"abc@xyz.com"`
+	OccurredAt time.Time `json:"occurred_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+} // @name UserEvent
+
+// @Description WebhookDelivery DTO model for responses
+// @Tags webhook
+type WebhookDelivery struct {
+	ID        uuid.UUID `json:"id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	WebhookID uuid.UUID `json:"webhook_id" swaggertype:"string" format:"uuid" example:"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`
+	Event     UserEvent `json:"event"`
+	// State is pending until the delivery succeeded, or until it is dead as every attempt failed
+	State string `json:"state" enums:"pending,succeeded,dead" example:"pending"`
+	// Attempts counts the failed attempts since the delivery was created or redelivered
+	Attempts int `json:"attempts" example:"1"`
+	// NextAttemptAt is only set for pending deliveries
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" format:"date-time" example:"2024-01-01T12:00:30Z"`
+	CreatedAt     time.Time  `json:"created_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	UpdatedAt     time.Time  `json:"updated_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	// Log are the attempts of the delivery, oldest first, only included if the delivery is requested by its id
+	Log *[]WebhookAttempt `json:"log,omitempty"`
+} // @name WebhookDelivery
+
+// @Description WebhookAttempt DTO model for the log of deliveries
+// @Tags webhook
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at" format:"date-time" example:"2024-01-01T12:00:00Z"`
+	// StatusCode is the status of the response, missing if there was none
+	StatusCode *int `json:"status_code,omitempty" example:"500"`
+	// Error describes why the attempt failed, missing if it succeeded
+	Error      *string `json:"error,omitempty" example:"unexpected status 500"`
+	DurationMS int64   `json:"duration_ms" example:"42"`
+} // @name WebhookAttempt
+
+// @Description WebhookDeliveryQueryParams DTO model for delivery query parameters
+// @Tags webhook
+type WebhookDeliveryQueryParams struct {
+	Pagination
+	State *string `json:"state" form:"state" binding:"omitempty,oneof=pending succeeded dead" enums:"pending,succeeded,dead" example:"dead"`
+} // @name WebhookDeliveryQueryParams
+
+func FromWebhook(w *types.Webhook) Webhook {
+	events := make([]string, len(w.Events))
+	for i, kind := range w.Events {
+		events[i] = string(kind)
+	}
+	return Webhook{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    events,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func FromCreatedWebhook(w *types.Webhook) CreatedWebhook {
+	return CreatedWebhook{
+		Webhook: FromWebhook(w),
+		Secret:  w.Secret,
+	}
+}
+
+// ToWebhook returns the webhook to save, with an empty secret if none is given
+func (w *SaveWebhook) ToWebhook(id uuid.UUID) types.Webhook {
+	events := make(types.Array[types.UserEventKind], len(w.Events))
+	for i, kind := range w.Events {
+		events[i] = types.UserEventKind(kind)
+	}
+	webhook := types.Webhook{
+		ID:     id,
+		URL:    w.URL,
+		Events: events,
+	}
+	if w.Secret != nil {
+		webhook.Secret = *w.Secret
+	}
+	return webhook
+}
+
+func FromUserEvent(e *types.UserEvent) UserEvent {
+	return UserEvent{
+		ID:                e.ID,
+		Kind:              string(e.Kind),
+		UserID:            e.UserID,
+		VersionID:         e.VersionID,
+		PreviousVersionID: e.PreviousVersionID,
+		Email:             e.Email,
+		OccurredAt:        e.OccurredAt,
+	}
+}
+
+func FromWebhookDelivery(d *types.WebhookDelivery) WebhookDelivery {
+	delivery := WebhookDelivery{
+		ID:        d.ID,
+		WebhookID: d.WebhookID,
+		Event:     FromUserEvent(&d.Event),
+		State:     string(d.State),
+		Attempts:  d.Attempts,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+	if d.State == types.WebhookDeliveryPending {
+		delivery.NextAttemptAt = &d.NextAttemptAt
+	}
+	if d.Log != nil {
+		log := make([]WebhookAttempt, len(d.Log))
+		for i, attempt := range d.Log {
+			log[i] = FromWebhookAttempt(&attempt)
+		}
+		delivery.Log = &log
+	}
+	return delivery
+}
+
+func FromWebhookAttempt(a *types.WebhookAttempt) WebhookAttempt {
+	attempt := WebhookAttempt{
+		AttemptedAt: a.AttemptedAt,
+		DurationMS:  a.Duration.Milliseconds(),
+	}
+	if a.StatusCode != 0 {
+		attempt.StatusCode = &a.StatusCode
+	}
+	if !a.Succeeded() {
+		attempt.Error = &a.Error
+	}
+	return attempt
+}
+
+func (d *WebhookDeliveryQueryParams) ToQueryParams(webhookID uuid.UUID) types.QueryParams {
+	c := types.WebhookDeliveryConditions{WebhookID: types.ToOptional(webhookID)}
+	if d.State != nil {
+		c.State = types.ToOptional(types.WebhookDeliveryState(*d.State))
+	}
+	return types.QueryParams{
+		Pagination: d.Pagination.ToPagination(),
+		Conditions: &c,
+	}
+}
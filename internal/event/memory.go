@@ -0,0 +1,42 @@
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// Bus is an in-memory broker for tests and local runs, which passes the published events to its subscribers
+type Bus struct {
+	mu          sync.Mutex
+	subscribers []chan types.UserEvent
+}
+
+func createBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe returns the events published from now on, publishing waits for the subscriber once the buffer is full
+func (b *Bus) Subscribe(buffer int) <-chan types.UserEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subscriber := make(chan types.UserEvent, buffer)
+	b.subscribers = append(b.subscribers, subscriber)
+	return subscriber
+}
+
+func (b *Bus) Publish(ctx context.Context, events []types.UserEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subscriber := range b.subscribers {
+		for _, event := range events {
+			select {
+			case subscriber <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package event
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/go-redis/redis/v8"
+	"github.com/pedramktb/go-base-lib/pkg/env"
+	"go.uber.org/fx"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/worker"
+)
+
+// providePublisher returns the broker chosen by EVENT_BROKER, redis (the default) or memory
+func providePublisher(r *redis.Client, bus *Bus) (Publisher, error) {
+	switch broker := env.GetWithFallback("EVENT_BROKER", "redis"); broker {
+	case "redis":
+		return createRedisStream(r), nil
+	case "memory":
+		return bus, nil
+	default:
+		return nil, errors.Newf("unknown event broker %q", broker)
+	}
+}
+
+// run relays the events in the background while the application is running
+func run(lc fx.Lifecycle, relay *Relay) {
+	worker.Run(lc, relay.Run)
+}
+
+// PublisherGroup is the fx group of the publishers the relay publishes to, the broker and e.g. webhooks
+const PublisherGroup = `group:"eventPublishers"`
+
+var FXEventModule = fx.Module("event",
+	fx.Provide(
+		createBus,
+		fx.Annotate(providePublisher, fx.ResultTags(PublisherGroup)),
+		fx.Annotate(createRelay, fx.ParamTags("", PublisherGroup)),
+	),
+	fx.Invoke(run),
+)
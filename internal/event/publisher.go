@@ -0,0 +1,15 @@
+// Package event relays the domain events of the outbox to a broker, which other systems consume them from, and to
+// the other publishers of PublisherGroup
+package event
+
+import (
+	"context"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// Publisher delivers events to a broker. If it fails, the events are published again, including the ones that
+// were delivered before the failure.
+type Publisher interface {
+	Publish(ctx context.Context, events []types.UserEvent) error
+}
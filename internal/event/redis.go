@@ -0,0 +1,47 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+const (
+	// streamKey is the Redis stream the events are appended to, consumers read it with XREAD or consumer groups
+	streamKey = "user:events"
+	// streamMaxLen approximately bounds the stream, consumers falling further behind miss events
+	streamMaxLen = 100_000
+)
+
+// redisStream publishes the events to a Redis stream, with the id and kind of the event next to the event as JSON
+type redisStream struct {
+	*redis.Client
+}
+
+func createRedisStream(r *redis.Client) *redisStream {
+	return &redisStream{
+		Client: r,
+	}
+}
+
+func (r *redisStream) Publish(ctx context.Context, events []types.UserEvent) error {
+	_, err := r.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: streamKey,
+				MaxLen: streamMaxLen,
+				Approx: true,
+				Values: map[string]any{"id": event.ID.String(), "kind": string(event.Kind), "event": data},
+			})
+		}
+		return nil
+	})
+	return err
+}
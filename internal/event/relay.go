@@ -0,0 +1,50 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/worker"
+)
+
+const (
+	// relayBatchSize is the amount of events published at once
+	relayBatchSize = 100
+	// relayInterval is how often the outbox is polled once it is empty, and failures are retried
+	relayInterval = time.Second
+)
+
+// Relay publishes the events of the outbox to every publisher, at least once and mostly in the order they occurred in.
+// Several relays can run at once, e.g. one per instance, but then events of a user might be published out of order.
+type Relay struct {
+	relayer    datasource.UserEventRelayer
+	publishers []Publisher
+	interval   time.Duration
+}
+
+func createRelay(relayer datasource.UserEventRelayer, publishers []Publisher) *Relay {
+	return &Relay{
+		relayer:    relayer,
+		publishers: publishers,
+		interval:   relayInterval,
+	}
+}
+
+// publish publishes the events to the publishers in order, if one fails the events are relayed again to all of them
+func (r *Relay) publish(ctx context.Context, events []types.UserEvent) error {
+	for _, publisher := range r.publishers {
+		if err := publisher.Publish(ctx, events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run relays the events until the context is done, full batches are followed by the next one right away
+func (r *Relay) Run(ctx context.Context) {
+	worker.Poll(ctx, r.interval, relayBatchSize, "failed to relay events", func(ctx context.Context) (int, error) {
+		return r.relayer.RelayEvents(ctx, relayBatchSize, r.publish)
+	})
+}
@@ -0,0 +1,108 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// fakeOutbox is an in-memory outbox, which removes the events only if publishing them succeeds
+type fakeOutbox struct {
+	mu     sync.Mutex
+	events []types.UserEvent
+}
+
+func (o *fakeOutbox) RelayEvents(ctx context.Context, limit int, publish func(ctx context.Context, events []types.UserEvent) error) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	events := o.events[:min(limit, len(o.events))]
+	if len(events) == 0 {
+		return 0, nil
+	}
+	if err := publish(ctx, events); err != nil {
+		return 0, err
+	}
+	o.events = o.events[len(events):]
+	return len(events), nil
+}
+
+// flakyPublisher fails the given number of times before it publishes to the bus
+type flakyPublisher struct {
+	*Bus
+	failures int
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, events []types.UserEvent) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	return p.Bus.Publish(ctx, events)
+}
+
+func Test_Relay(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   int
+		failures int
+	}{
+		{
+			name:   "Single Batch Case",
+			events: 10,
+		},
+		{
+			name:   "Many Batches Case",
+			events: 2*relayBatchSize + 50,
+		},
+		{
+			name:     "Retry Case",
+			events:   10,
+			failures: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &fakeOutbox{}
+			for range tt.events {
+				outbox.events = append(outbox.events, types.NewUserDeleted(uuid.New()))
+			}
+			want := outbox.events
+
+			bus := createBus()
+			received := bus.Subscribe(tt.events)
+			relay := createRelay(outbox, []Publisher{&flakyPublisher{Bus: bus, failures: tt.failures}})
+			relay.interval = 10 * time.Millisecond
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				relay.Run(ctx)
+			}()
+
+			got := make([]types.UserEvent, 0, tt.events)
+			timeout := time.After(5 * time.Second)
+			for len(got) < tt.events {
+				select {
+				case event := <-received:
+					got = append(got, event)
+				case <-timeout:
+					t.Fatalf("received %d of %d events", len(got), tt.events)
+				}
+			}
+			cancel()
+			<-done
+
+			assert.Equal(t, want, got)
+			assert.Empty(t, outbox.events)
+		})
+	}
+}
@@ -3,6 +3,9 @@ package ginRouter
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
 )
 
 func GetID(c *gin.Context, key string) uuid.UUID {
@@ -14,3 +17,11 @@ func GetID(c *gin.Context, key string) uuid.UUID {
 		return id
 	}
 }
+
+// GetActor returns the authenticated user of the request as set by the auth middleware
+func GetActor(c *gin.Context) usecase.Actor {
+	return usecase.Actor{
+		ID:      GetID(c, string(logging.CtxUserID)),
+		IsAdmin: c.GetBool(string(logging.CtxUserIsAdmin)),
+	}
+}
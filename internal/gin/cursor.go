@@ -0,0 +1,89 @@
+package ginRouter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"maps"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// Cursors signs the cursors handed out to clients, so that they are opaque and can't be forged.
+// A cursor is bound to the filters of the query it was created for.
+type Cursors struct {
+	secret []byte
+}
+
+func createCursors(secret string) *Cursors {
+	return &Cursors{
+		secret: []byte(secret),
+	}
+}
+
+// pageParams are the query parameters a cursor is not bound to
+var pageParams = []string{"cursor", "since", "limit", "offset", "total", "sort", "fields", "expand"}
+
+// scope returns the canonical form of the query parameters of the request a cursor is bound to
+func scope(query url.Values) string {
+	query = maps.Clone(query)
+	for _, param := range pageParams {
+		query.Del(param)
+	}
+	return query.Encode()
+}
+
+func (c *Cursors) sign(scope string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(scope))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Encode returns the signed cursor for the query of the request
+func (c *Cursors) Encode(query url.Values, cursor *types.Cursor) (*string, error) {
+	if cursor == nil {
+		return nil, nil
+	}
+	payload, err := types.MarshalCursor(*cursor)
+	if err != nil {
+		return nil, errors.Join(types.ErrInternal, err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(scope(query), payload))
+	return &encoded, nil
+}
+
+// Decode verifies and decodes the cursor given in the query of the request, if any
+func (c *Cursors) Decode(query url.Values, fields types.SortFields) (*types.Cursor, error) {
+	return c.DecodeString(query.Get("cursor"), query, fields)
+}
+
+// DecodeString verifies and decodes a cursor given elsewhere than in the cursor parameter for the query of the request
+func (c *Cursors) DecodeString(encoded string, query url.Values, fields types.SortFields) (*types.Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	encodedPayload, encodedSignature, _ := strings.Cut(encoded, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, errors.Join(types.ErrInvalidCursor, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, errors.Join(types.ErrInvalidCursor, err)
+	}
+	if !hmac.Equal(signature, c.sign(scope(query), payload)) {
+		return nil, errors.Join(types.ErrInvalidCursor, errors.New("signature mismatch, the filters of the query might have changed"))
+	}
+	cursor, err := types.UnmarshalCursor(payload, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
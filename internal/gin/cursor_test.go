@@ -0,0 +1,98 @@
+package ginRouter
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+func Test_Cursors(t *testing.T) {
+	cursors := createCursors("secret")
+	fields := types.SortFields{"id": types.FilterUUID, "last_name": types.FilterString}
+	cursor := &types.Cursor{
+		Sort:   types.Sort{{Field: "last_name", Desc: true}, {Field: "id"}},
+		Values: []any{"Doe", uuid.MustParse("b05a5d28-1a51-46a8-b35c-6e160a05a0ad")},
+	}
+
+	query := url.Values{"filter": {"last_name:prefix:D"}, "limit": {"5"}}
+	encoded, err := cursors.Encode(query, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    *types.Cursor
+		wantErr bool
+	}{
+		{
+			name:  "No Cursor Case",
+			query: url.Values{},
+			want:  nil,
+		},
+		{
+			name:  "Success Case",
+			query: url.Values{"filter": {"last_name:prefix:D"}, "limit": {"10"}, "cursor": {*encoded}},
+			want:  cursor,
+		},
+		{
+			name:    "Changed Filter Case",
+			query:   url.Values{"filter": {"last_name:prefix:E"}, "cursor": {*encoded}},
+			wantErr: true,
+		},
+		{
+			name:    "Other Secret Case",
+			query:   url.Values{"filter": {"last_name:prefix:D"}, "cursor": {mustEncode(t, createCursors("other"), query, cursor)}},
+			wantErr: true,
+		},
+		{
+			name:    "Malformed Case",
+			query:   url.Values{"cursor": {"not a cursor"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cursors.Decode(tt.query, fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Cursors.Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				assert.ErrorIs(t, err, types.ErrInvalidCursor)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func mustEncode(t *testing.T, cursors *Cursors, query url.Values, cursor *types.Cursor) string {
+	encoded, err := cursors.Encode(query, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *encoded
+}
+
+func Test_CursorsDecodeString(t *testing.T) {
+	cursors := createCursors("secret")
+	cursor := types.UserChange{
+		ID:        uuid.MustParse("b05a5d28-1a51-46a8-b35c-6e160a05a0ad"),
+		ChangedAt: time.Date(2024, 1, 1, 12, 0, 0, 123456000, time.UTC),
+	}.Cursor()
+
+	// The token is not bound to the since parameter it is given in
+	token := mustEncode(t, cursors, url.Values{}, cursor)
+	got, err := cursors.DecodeString(token, url.Values{"since": {token}}, types.UserChangeSortFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, cursor, got)
+}
@@ -13,25 +13,70 @@ import (
 	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
 )
 
+// ErrorStatus returns the HTTP status code of the error class
+func ErrorStatus(err error) int {
+	switch types.ErrorClass(err) {
+	case types.ErrNotFound:
+		return http.StatusNotFound
+	case types.ErrBadRequest:
+		return http.StatusBadRequest
+	case types.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case types.ErrForbidden:
+		return http.StatusForbidden
+	case types.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case types.ErrConflict:
+		return http.StatusConflict
+	case types.ErrUnprocessable:
+		return http.StatusUnprocessableEntity
+	case types.ErrRateLimited:
+		return http.StatusTooManyRequests
+	case types.ErrBatchAborted:
+		return http.StatusFailedDependency
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Problem returns the problem details of the error, which only contain the public message of the error
+// and the localized messages of the fields which failed the validation
+func Problem(c *gin.Context, err error) dtos.ErrorResponse {
+	status := ErrorStatus(err)
+	problem := dtos.ErrorResponse{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  c.Request.URL.Path,
+		Code:      types.ErrorCode(err),
+		RequestID: GetRequestID(c),
+	}
+	if status < http.StatusInternalServerError {
+		problem.Detail = types.PublicMessage(err)
+	}
+
+	var validationErr *types.ValidationError
+	if errors.As(err, &validationErr) {
+		lang := dtos.MatchLanguage(c.GetHeader("Accept-Language"))
+		problem.Detail = dtos.ValidationMessage(lang)
+		problem.Errors = dtos.FromFieldErrors(validationErr.Fields, lang)
+		c.Header("Content-Language", lang.String())
+		c.Header("Vary", "Accept-Language")
+	}
+	return problem
+}
+
+// ErrorResponse logs the error and responds with its problem details as application/problem+json
 func ErrorResponse(c *gin.Context, err error) {
+	status := ErrorStatus(err)
 	switch {
-	case errors.IsAny(err, types.ErrNotFound):
-		logging.FromContext(c.Request.Context()).Debug("Not found", zap.Error(err))
-		c.JSON(http.StatusNotFound, dtos.ErrorResponse{Error: err.Error()})
-	case errors.IsAny(err, types.ErrBadRequest):
-		logging.FromContext(c.Request.Context()).Debug("Bad request", zap.Error(err))
-		c.JSON(http.StatusBadRequest, dtos.ErrorResponse{Error: err.Error()})
-	case errors.IsAny(err, types.ErrUnauthorized):
-		logging.FromContext(c.Request.Context()).Debug("Unauthorized", zap.Error(err))
-		c.JSON(http.StatusUnauthorized, dtos.ErrorResponse{Error: err.Error()})
-	case errors.IsAny(err, types.ErrForbidden):
-		logging.FromContext(c.Request.Context()).Debug("Forbidden", zap.Error(err))
-		c.JSON(http.StatusForbidden, dtos.ErrorResponse{Error: err.Error()})
+	case status != http.StatusInternalServerError:
+		logging.FromContext(c.Request.Context()).Debug(http.StatusText(status), zap.Error(err))
 	case errors.IsAny(err, types.ErrInternal):
 		logging.FromContext(c.Request.Context()).Warn("Internal error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dtos.ErrorResponse{Error: err.Error()})
 	default:
 		logging.FromContext(c.Request.Context()).Error("Unknown error", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dtos.ErrorResponse{Error: err.Error()})
 	}
+	c.Header("Content-Type", "application/problem+json")
+	c.JSON(status, Problem(c, err))
 }
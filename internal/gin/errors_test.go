@@ -0,0 +1,126 @@
+package ginRouter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+func Test_ErrorResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		requestID     string
+		wantStatus    int
+		wantCode      string
+		wantDetail    string
+		wantRequestID bool
+	}{
+		{
+			name:       "Conflict Case",
+			err:        types.ErrEmailTaken,
+			requestID:  "request-1",
+			wantStatus: http.StatusConflict,
+			wantCode:   "email_taken",
+			wantDetail: "email is already taken",
+		},
+		{
+			name:       "Rate Limited Case",
+			err:        types.ErrRateLimited,
+			requestID:  "request-2",
+			wantStatus: http.StatusTooManyRequests,
+			wantCode:   "rate_limited",
+			wantDetail: "too many requests",
+		},
+		{
+			name:          "Internal Case",
+			err:           errors.Join(types.ErrInternal, errors.New(`pq: relation "users" does not exist`)),
+			requestID:     "request id with spaces",
+			wantStatus:    http.StatusInternalServerError,
+			wantCode:      "internal",
+			wantDetail:    "",
+			wantRequestID: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(RequestID)
+			r.GET("/users", func(c *gin.Context) { ErrorResponse(c, tt.err) })
+
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set(RequestIDHeader, tt.requestID)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+			var problem dtos.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			assert.Equal(t, "about:blank", problem.Type)
+			assert.Equal(t, http.StatusText(tt.wantStatus), problem.Title)
+			assert.Equal(t, tt.wantStatus, problem.Status)
+			assert.Equal(t, "/users", problem.Instance)
+			assert.Equal(t, tt.wantCode, problem.Code)
+			assert.Equal(t, tt.wantDetail, problem.Detail)
+			assert.Equal(t, w.Header().Get(RequestIDHeader), problem.RequestID)
+			if tt.wantRequestID {
+				// invalid ids of clients are replaced
+				assert.NotEqual(t, tt.requestID, problem.RequestID)
+				assert.NotEmpty(t, problem.RequestID)
+			} else {
+				assert.Equal(t, tt.requestID, problem.RequestID)
+			}
+		})
+	}
+}
+
+func Test_ValidationErrorResponse(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID)
+	r.POST("/users", func(c *gin.Context) {
+		userDTO := dtos.SaveUser{}
+		if err := c.ShouldBindJSON(&userDTO); err != nil {
+			ErrorResponse(c, dtos.BindingError(err))
+			return
+		}
+		// The rules of users are checked by the usecases
+		ErrorResponse(c, userDTO.ToUserInput().Validate())
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(
+		`{"first_name":"John","last_name":"Doe","email":"john@xyz.com","phone":"123","password":"password"}`,
+	))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "de", w.Header().Get("Content-Language"))
+
+	var problem dtos.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	assert.Equal(t, "validation_failed", problem.Code)
+	assert.Equal(t, "Die Anfrage hat ungültige Felder", problem.Detail)
+	assert.Equal(t, []dtos.FieldError{{
+		Pointer: "/phone",
+		Rule:    "phone",
+		Message: "phone muss eine E.164-Telefonnummer sein, z. B. +49123456789",
+	}}, problem.Errors)
+}
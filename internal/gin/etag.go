@@ -0,0 +1,124 @@
+package ginRouter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+// Cache-Control values of cacheable responses, responses are never used without revalidation as they require
+// authentication, which the API checks when answering the revalidation
+const (
+	// CacheControlShared lets shared caches (e.g. a gateway) store responses to authenticated requests
+	CacheControlShared = "no-cache, must-revalidate"
+	// CacheControlPrivate keeps responses specific to the authenticated user out of shared caches
+	CacheControlPrivate = "private, no-cache"
+)
+
+// ETag returns the strong entity tag of a version, versions are immutable so their id identifies the representation
+func ETag(versionID uuid.UUID) string {
+	return `"` + versionID.String() + `"`
+}
+
+// WeakETag returns a weak entity tag of a response body, it is weak as the encoding of equal content might change
+func WeakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// entityTags splits the list of entity tags of a conditional request header
+func entityTags(header string) []string {
+	tags := strings.Split(header, ",")
+	for i := range tags {
+		tags[i] = strings.TrimSpace(tags[i])
+	}
+	return tags
+}
+
+// VersionCondition returns the condition of the If-Match header on the latest version of a resource,
+// nil without the header
+func VersionCondition(c *gin.Context) usecase.VersionCondition {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return nil
+	}
+	return func(versionID uuid.UUID) bool { return IfMatch(header, ETag(versionID)) }
+}
+
+// IfMatch reports whether the If-Match header matches the current entity tag of the resource (RFC 9110 13.1.1),
+// weak tags never match as the comparison is strong. Requests without the header always match.
+func IfMatch(header, etag string) bool {
+	if header == "" {
+		return true
+	}
+	for _, tag := range entityTags(header) {
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// IfNoneMatch reports whether the If-None-Match header matches the current entity tag of the resource (RFC 9110 13.1.2),
+// the comparison is weak. Requests without the header never match.
+func IfNoneMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, tag := range entityTags(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModified reports whether the client's copy of the resource is still current, by the If-None-Match header
+// or, only without it, by the If-Modified-Since header
+func NotModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		return IfNoneMatch(ifNoneMatch, etag)
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	ifModifiedSince, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	// Last-Modified has a precision of seconds
+	return err == nil && !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// RespondCacheable writes the JSON body with its validators and the Cache-Control header,
+// or 304 Not Modified without a body if the client's copy is still current.
+// The entity tag is a weak one of the body if not given, lastModified is optional.
+func RespondCacheable(c *gin.Context, cacheControl, etag string, lastModified time.Time, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		ErrorResponse(c, errors.Join(types.ErrInternal, err))
+		return
+	}
+	if etag == "" {
+		etag = WeakETag(data)
+	}
+
+	c.Header("Cache-Control", cacheControl)
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if NotModified(c, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,183 @@
+package ginRouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IfMatch(t *testing.T) {
+	etag := ETag(uuid.MustParse("b05a5d28-1a51-46a8-b35c-6e160a05a0ad"))
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{
+			name:   "No Header Case",
+			header: "",
+			want:   true,
+		},
+		{
+			name:   "Match Case",
+			header: `"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`,
+			want:   true,
+		},
+		{
+			name:   "List Case",
+			header: `"6b1cbd3a-2a0b-4fd1-8c1e-0cbb0f2a8d3b", "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`,
+			want:   true,
+		},
+		{
+			name:   "Any Case",
+			header: "*",
+			want:   true,
+		},
+		{
+			name:   "Mismatch Case",
+			header: `"6b1cbd3a-2a0b-4fd1-8c1e-0cbb0f2a8d3b"`,
+			want:   false,
+		},
+		{
+			name:   "Weak Case",
+			header: `W/"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`,
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IfMatch(tt.header, etag))
+		})
+	}
+}
+
+func Test_IfNoneMatch(t *testing.T) {
+	etag := ETag(uuid.MustParse("b05a5d28-1a51-46a8-b35c-6e160a05a0ad"))
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{
+			name:   "No Header Case",
+			header: "",
+			want:   false,
+		},
+		{
+			name:   "Match Case",
+			header: `"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`,
+			want:   true,
+		},
+		{
+			name:   "Any Case",
+			header: "*",
+			want:   true,
+		},
+		{
+			name:   "Mismatch Case",
+			header: `"6b1cbd3a-2a0b-4fd1-8c1e-0cbb0f2a8d3b"`,
+			want:   false,
+		},
+		{
+			name:   "Weak Case",
+			header: `"6b1cbd3a-2a0b-4fd1-8c1e-0cbb0f2a8d3b", W/"b05a5d28-1a51-46a8-b35c-6e160a05a0ad"`,
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IfNoneMatch(tt.header, etag))
+		})
+	}
+}
+
+func Test_RespondCacheable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lastModified := time.Date(2024, 5, 1, 12, 30, 15, 500, time.UTC)
+	body := map[string]string{"name": "test"}
+	weak := WeakETag([]byte(`{"name":"test"}`))
+
+	tests := []struct {
+		name       string
+		etag       string
+		header     http.Header
+		wantStatus int
+		wantETag   string
+	}{
+		{
+			name:       "Unconditional Case",
+			etag:       `"v1"`,
+			header:     http.Header{},
+			wantStatus: http.StatusOK,
+			wantETag:   `"v1"`,
+		},
+		{
+			name:       "If-None-Match Case",
+			etag:       `"v1"`,
+			header:     http.Header{"If-None-Match": {`"v1"`}},
+			wantStatus: http.StatusNotModified,
+			wantETag:   `"v1"`,
+		},
+		{
+			name:       "If-None-Match Precedence Case",
+			etag:       `"v1"`,
+			header:     http.Header{"If-None-Match": {`"v0"`}, "If-Modified-Since": {lastModified.Format(http.TimeFormat)}},
+			wantStatus: http.StatusOK,
+			wantETag:   `"v1"`,
+		},
+		{
+			name:       "If-Modified-Since Case",
+			etag:       `"v1"`,
+			header:     http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}},
+			wantStatus: http.StatusNotModified,
+			wantETag:   `"v1"`,
+		},
+		{
+			name:       "Modified Since Case",
+			etag:       `"v1"`,
+			header:     http.Header{"If-Modified-Since": {lastModified.Add(-time.Second).Format(http.TimeFormat)}},
+			wantStatus: http.StatusOK,
+			wantETag:   `"v1"`,
+		},
+		{
+			name:       "Weak ETag Case",
+			header:     http.Header{"If-None-Match": {weak}},
+			wantStatus: http.StatusNotModified,
+			wantETag:   weak,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.Header = tt.header
+			modified := lastModified
+			if tt.etag == "" {
+				modified = time.Time{}
+			}
+
+			RespondCacheable(c, CacheControlShared, tt.etag, modified, body)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantETag, w.Header().Get("ETag"))
+			assert.Equal(t, CacheControlShared, w.Header().Get("Cache-Control"))
+			if tt.wantStatus == http.StatusNotModified {
+				assert.Empty(t, w.Body.Bytes())
+			} else {
+				assert.JSONEq(t, `{"name":"test"}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -3,13 +3,17 @@ package ginDI
 import (
 	"go.uber.org/fx"
 
+	addressGinRouter "github.com/pedramktb/schwarzit-probearbeit/internal/address/gin"
 	authGinRouter "github.com/pedramktb/schwarzit-probearbeit/internal/auth/gin"
 	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
 	userGinRouter "github.com/pedramktb/schwarzit-probearbeit/internal/user/gin"
+	webhookGinRouter "github.com/pedramktb/schwarzit-probearbeit/internal/webhook/gin"
 )
 
 var FXGinRoutersModule = fx.Module("gin",
 	ginRouter.FXGinRouterModule,
 	authGinRouter.FXAuthGinRouterModule,
 	userGinRouter.FXUserGinRouterModule,
+	addressGinRouter.FXAddressGinRouterModule,
+	webhookGinRouter.FXWebhookGinRouterModule,
 )
@@ -0,0 +1,238 @@
+package ginRouter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+const (
+	// maxIdempotencyKeyLength limits the keys to the length of UUIDs and similar random identifiers with some room
+	maxIdempotencyKeyLength = 255
+	// idempotencyLockTTL releases the keys of requests which never completed, e.g. because the server stopped.
+	// The lock is refreshed while the request is handled, so that it is kept however long the request takes.
+	idempotencyLockTTL      = time.Minute
+	idempotencyStoreTimeout = 5 * time.Second
+)
+
+// replayedHeaders are the response headers stored with the response of a request, other headers are not replayed
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Last-Modified", "X-Total-Count"}
+
+// Idempotency makes retries of non-idempotent requests with the same Idempotency-Key header safe,
+// the response of the first request is stored for the ttl and replayed for the retries
+type Idempotency struct {
+	client  *redis.Client
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+func createIdempotency(r *redis.Client, ttl time.Duration) *Idempotency {
+	return &Idempotency{
+		client:  r,
+		ttl:     ttl,
+		lockTTL: idempotencyLockTTL,
+	}
+}
+
+// idempotencyRecord is a request stored under its key, its status is zero while the request is in flight
+type idempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// recordingWriter keeps a copy of the response body to store it
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// keyFromIdempotencyKey scopes the key to the user and the route, so that keys of different users or endpoints never
+// collide. Keys of unauthenticated requests (e.g. registrations) are scoped to the IP of the client instead.
+func keyFromIdempotencyKey(c *gin.Context, key string) string {
+	scope, client := "anonymous", c.ClientIP()
+	if id, ok := c.Get(string(logging.CtxUserID)); ok {
+		if id, ok := id.(uuid.UUID); ok {
+			scope, client = id.String(), ""
+		}
+	}
+	sum := sha256.Sum256([]byte(c.Request.Method + " " + c.FullPath() + "\n" + client + "\n" + key))
+	return "idempotency:" + scope + ":" + hex.EncodeToString(sum[:])
+}
+
+// fingerprint identifies the request a key was used with
+func fingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Middleware handles requests with an Idempotency-Key header at most once per key and replays the stored response
+// for repeats. Reusing a key for a different request and repeating a request which is still in flight are conflicts.
+// Responses with server errors are not stored, so that the request can be retried with the same key.
+func (i *Idempotency) Middleware(c *gin.Context) {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		c.Next()
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		ErrorResponse(c, types.ErrInvalidIdempotencyKey)
+		c.Abort()
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
+		c.Abort()
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	ctx := c.Request.Context()
+	redisKey := keyFromIdempotencyKey(c, key)
+	record := idempotencyRecord{Fingerprint: fingerprint(c, body)}
+	data, err := json.Marshal(record)
+	if err != nil {
+		ErrorResponse(c, errors.Join(types.ErrInternal, err))
+		c.Abort()
+		return
+	}
+
+	// Locks the key for this request, only one of concurrent requests with the same key succeeds
+	locked, err := i.client.SetNX(ctx, redisKey, data, i.lockTTL).Result()
+	if err != nil {
+		ErrorResponse(c, errors.Join(types.ErrInternal, err))
+		c.Abort()
+		return
+	}
+	if !locked {
+		i.replay(c, redisKey, record.Fingerprint)
+		c.Abort()
+		return
+	}
+
+	writer := &recordingWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	stopRefreshing := i.refreshLock(ctx, redisKey)
+	c.Next()
+	stopRefreshing()
+
+	// The request might be canceled already, the key has to be released or completed anyway
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+	defer cancel()
+
+	if record.Status = writer.Status(); record.Status >= http.StatusInternalServerError {
+		if err := i.client.Del(ctx, redisKey).Err(); err != nil {
+			logging.FromContext(ctx).Error("failed to release idempotency key", zap.Error(err))
+		}
+		return
+	}
+
+	record.Header = make(http.Header)
+	for _, name := range replayedHeaders {
+		if values := writer.Header().Values(name); len(values) > 0 {
+			record.Header[name] = values
+		}
+	}
+	record.Body = writer.body.Bytes()
+	if data, err = json.Marshal(record); err == nil {
+		err = i.client.Set(ctx, redisKey, data, i.ttl).Err()
+	}
+	if err != nil {
+		// The key stays locked until the lock expires, repeats fail with a conflict instead of being handled again
+		logging.FromContext(ctx).Error("failed to store idempotent response", zap.Error(err))
+	}
+}
+
+// refreshLock extends the lock of the key until stop is called, stop returns once the lock isn't refreshed anymore.
+// The lock expires after the lock ttl if the server stops while the request is handled.
+func (i *Idempotency) refreshLock(ctx context.Context, redisKey string) (stop func()) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(i.lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := i.client.Expire(ctx, redisKey, i.lockTTL).Err(); err != nil && ctx.Err() == nil {
+					logging.FromContext(ctx).Error("failed to refresh idempotency key", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// replay responds to a repeated request with the stored response of the first one
+func (i *Idempotency) replay(c *gin.Context, redisKey, fingerprint string) {
+	data, err := i.client.Get(c.Request.Context(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The first request failed and released the key in the meantime
+		c.Header("Retry-After", "1")
+		ErrorResponse(c, types.ErrRequestInProgress)
+		return
+	} else if err != nil {
+		ErrorResponse(c, errors.Join(types.ErrInternal, err))
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		ErrorResponse(c, errors.Join(types.ErrDataCorrupted, err))
+		return
+	}
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		ErrorResponse(c, types.ErrIdempotencyKeyReused)
+	case record.Status == 0:
+		c.Header("Retry-After", "1")
+		ErrorResponse(c, types.ErrRequestInProgress)
+	default:
+		for name, values := range record.Header {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Status(record.Status)
+		if _, err := c.Writer.Write(record.Body); err != nil {
+			logging.FromContext(c.Request.Context()).Debug("failed to replay idempotent response", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package ginRouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+)
+
+func Test_IdempotencyKeyScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	keys := make(map[string]string)
+	handler := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if userID := c.GetHeader("X-User"); userID != "" {
+				c.Set(string(logging.CtxUserID), uuid.MustParse(userID))
+			}
+			keys[name] = keyFromIdempotencyKey(c, c.GetHeader("Idempotency-Key"))
+		}
+	}
+	engine.POST("/users", handler("users"))
+	engine.POST("/register", handler("register"))
+
+	requestFrom := func(name, path, userID, key, remoteAddr string) string {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Idempotency-Key", key)
+		req.Header.Set("X-User", userID)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+		return keys[name]
+	}
+
+	request := func(name, path, userID, key string) string {
+		return requestFrom(name, path, userID, key, "192.0.2.1:1234")
+	}
+
+	user := "b05a5d28-1a51-46a8-b35c-6e160a05a0ad"
+	other := "6b1cbd3a-2a0b-4fd1-8c1e-0cbb0f2a8d3b"
+	key := request("users", "/users", user, "key")
+
+	assert.Equal(t, key, request("users", "/users", user, "key"))
+	assert.NotEqual(t, key, request("users", "/users", user, "other key"))
+	assert.NotEqual(t, key, request("users", "/users", other, "key"))
+	assert.NotEqual(t, key, request("register", "/register", user, "key"))
+	assert.Contains(t, request("register", "/register", "", "key"), "idempotency:anonymous:")
+
+	// keys of unauthenticated requests are scoped to the client, those of users aren't
+	assert.Equal(t, request("register", "/register", "", "key"), requestFrom("register", "/register", "", "key", "192.0.2.1:5678"))
+	assert.NotEqual(t, request("register", "/register", "", "key"), requestFrom("register", "/register", "", "key", "192.0.2.2:1234"))
+	assert.Equal(t, key, requestFrom("users", "/users", user, "key", "192.0.2.2:1234"))
+}
+
+func Test_IdempotencyFingerprint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fingerprintOf := func(target string, body string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, target, nil)
+		return fingerprint(c, []byte(body))
+	}
+
+	want := fingerprintOf("/users", `{"email":"a@example.com"}`)
+	assert.Equal(t, want, fingerprintOf("/users", `{"email":"a@example.com"}`))
+	assert.NotEqual(t, want, fingerprintOf("/users", `{"email":"b@example.com"}`))
+	assert.NotEqual(t, want, fingerprintOf("/users?dry_run=true", `{"email":"a@example.com"}`))
+}
+
+func Test_IdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		key  string
+		// bodies are the bodies of the first and the second request
+		bodies [2]string
+		// status is the status of the handler
+		status int
+		// concurrent sends the second request while the first one is in flight
+		concurrent       bool
+		wantCalls        int32
+		wantSecondStatus int
+		wantReplayed     bool
+	}{
+		{
+			name:             "Replay Case",
+			key:              "key",
+			bodies:           [2]string{`{"email":"a@example.com"}`, `{"email":"a@example.com"}`},
+			status:           http.StatusOK,
+			wantCalls:        1,
+			wantSecondStatus: http.StatusOK,
+			wantReplayed:     true,
+		},
+		{
+			name:             "Replay Client Error Case",
+			key:              "key",
+			bodies:           [2]string{`{"email":"a@example.com"}`, `{"email":"a@example.com"}`},
+			status:           http.StatusUnprocessableEntity,
+			wantCalls:        1,
+			wantSecondStatus: http.StatusUnprocessableEntity,
+			wantReplayed:     true,
+		},
+		{
+			name:             "Reused Key Case",
+			key:              "key",
+			bodies:           [2]string{`{"email":"a@example.com"}`, `{"email":"b@example.com"}`},
+			status:           http.StatusOK,
+			wantCalls:        1,
+			wantSecondStatus: http.StatusConflict,
+		},
+		{
+			name:             "In Flight Case",
+			key:              "key",
+			bodies:           [2]string{`{"email":"a@example.com"}`, `{"email":"a@example.com"}`},
+			status:           http.StatusOK,
+			concurrent:       true,
+			wantCalls:        1,
+			wantSecondStatus: http.StatusConflict,
+		},
+		{
+			name:             "Server Error Case",
+			key:              "key",
+			bodies:           [2]string{`{"email":"a@example.com"}`, `{"email":"a@example.com"}`},
+			status:           http.StatusInternalServerError,
+			wantCalls:        2,
+			wantSecondStatus: http.StatusInternalServerError,
+		},
+		{
+			name:             "No Key Case",
+			bodies:           [2]string{`{"email":"a@example.com"}`, `{"email":"a@example.com"}`},
+			status:           http.StatusOK,
+			wantCalls:        2,
+			wantSecondStatus: http.StatusOK,
+		},
+		{
+			name:             "Invalid Key Case",
+			key:              strings.Repeat("k", maxIdempotencyKeyLength+1),
+			bodies:           [2]string{`{"email":"a@example.com"}`, `{"email":"a@example.com"}`},
+			status:           http.StatusOK,
+			wantCalls:        0,
+			wantSecondStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			defer client.Close()
+			idempotency := createIdempotency(client, time.Hour)
+
+			var calls atomic.Int32
+			started, release := make(chan struct{}), make(chan struct{})
+			engine := gin.New()
+			engine.POST("/users", idempotency.Middleware, func(c *gin.Context) {
+				call := calls.Add(1)
+				if tt.concurrent {
+					close(started)
+					<-release
+				}
+				c.Header("ETag", `"1"`)
+				c.JSON(tt.status, gin.H{"call": call})
+			})
+
+			request := func(body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+				if tt.key != "" {
+					req.Header.Set("Idempotency-Key", tt.key)
+				}
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, req)
+				return w
+			}
+
+			var first *httptest.ResponseRecorder
+			var second *httptest.ResponseRecorder
+			if tt.concurrent {
+				var wg sync.WaitGroup
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					first = request(tt.bodies[0])
+				}()
+				<-started
+				second = request(tt.bodies[1])
+				close(release)
+				wg.Wait()
+				assert.Equal(t, "1", second.Header().Get("Retry-After"))
+			} else {
+				first = request(tt.bodies[0])
+				second = request(tt.bodies[1])
+			}
+
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			assert.Equal(t, tt.wantSecondStatus, second.Code)
+			if tt.wantReplayed {
+				assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+				assert.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+				assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+				assert.Equal(t, first.Body.String(), second.Body.String())
+			} else {
+				assert.Empty(t, second.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
+
+func Test_IdempotencyLockRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	defer client.Close()
+	idempotency := createIdempotency(client, time.Hour)
+	idempotency.lockTTL = 60 * time.Millisecond
+
+	var calls atomic.Int32
+	var repeated *httptest.ResponseRecorder
+	engine := gin.New()
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email":"a@example.com"}`))
+		req.Header.Set("Idempotency-Key", "key")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	engine.POST("/users", idempotency.Middleware, func(c *gin.Context) {
+		if calls.Add(1) > 1 {
+			c.Status(http.StatusCreated)
+			return
+		}
+		// the request takes twice as long as the lock ttl
+		for range 3 {
+			time.Sleep(50 * time.Millisecond)
+			redisServer.FastForward(40 * time.Millisecond)
+		}
+		repeated = request()
+		c.Status(http.StatusCreated)
+	})
+
+	first := request()
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, http.StatusConflict, repeated.Code)
+
+	// the stored response is kept for the ttl rather than the lock ttl
+	redisServer.FastForward(time.Minute)
+	assert.Equal(t, "true", request().Header().Get("Idempotent-Replayed"))
+}
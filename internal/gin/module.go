@@ -1,7 +1,11 @@
 package ginRouter
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/pedramktb/go-base-lib/pkg/env"
 	"go.uber.org/fx"
 )
 
@@ -9,6 +13,14 @@ var FXGinRouterModule = fx.Options(
 	fx.Provide(
 		provideRouter,
 		func(r *gin.Engine) gin.IRouter { return r },
+		func() *Cursors { return createCursors(env.GetOrFail[string]("CURSOR_SECRET")) },
+		fx.Annotate(
+			func(r *redis.Client) gin.HandlerFunc {
+				ttl := time.Duration(env.GetWithFallback("IDEMPOTENCY_TTL", 24*60*60)) * time.Second
+				return createIdempotency(r, ttl).Middleware
+			},
+			fx.ResultTags(`name:"idempotencyMiddleware"`),
+		),
 	),
 	fx.Invoke(run),
 )
@@ -0,0 +1,34 @@
+package ginRouter
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits the request ids of clients (or proxies) to what is safe to log and echo
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID identifies every request by the X-Request-ID header of the client or a new random id,
+// the id is returned in the same header, logged with the request and part of the error responses
+func RequestID(c *gin.Context) {
+	id := c.GetHeader(RequestIDHeader)
+	if !requestIDPattern.MatchString(id) {
+		id = uuid.NewString()
+	}
+	c.Set(string(logging.CtxRequestID), id)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), logging.CtxRequestID, id))
+	c.Header(RequestIDHeader, id)
+	c.Next()
+}
+
+// GetRequestID returns the id of the request
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(string(logging.CtxRequestID))
+}
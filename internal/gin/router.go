@@ -21,6 +21,7 @@ import (
 
 func provideRouter() *gin.Engine {
 	r := gin.Default()
+	r.Use(RequestID)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 	return r
 }
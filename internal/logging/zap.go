@@ -19,11 +19,13 @@ type ContextKey string
 const (
 	CtxUserID      ContextKey = "user.ID"
 	CtxUserIsAdmin ContextKey = "user.IsAdmin"
+	CtxRequestID   ContextKey = "request.ID"
 )
 
 var ctxKeys = []ContextKey{
 	CtxUserID,
 	CtxUserIsAdmin,
+	CtxRequestID,
 }
 
 // init is used instead of Dependency Injection to have logging available at the very beginning of the application
@@ -1,6 +1,8 @@
 package testData
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 
 	"github.com/google/uuid"
@@ -9,7 +11,8 @@ import (
 )
 
 var (
-	TestUserOldVersionID = uuid.New()
+	TestUserOldVersionID        = uuid.New()
+	TestUserOldVersionCreatedAt = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
 )
 
 var TestUser = types.User{
@@ -22,6 +25,8 @@ var TestUser = types.User{
 	Phone:           "+49123456789",
 	IsAdmin:         false,
 	PasswordHash:    "password",
+	CreatedAt:       time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	UpdatedAt:       time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC),
 }
 
 var TestAdminUser = types.User{
@@ -34,19 +39,41 @@ var TestAdminUser = types.User{
 	Phone:           "+49123456789",
 	IsAdmin:         true,
 	PasswordHash:    "password",
+	CreatedAt:       time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	UpdatedAt:       time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+}
+
+var TestAddress = types.UserAddress{
+	ID:        uuid.New(),
+	VersionID: uuid.New(),
+	UserID:    TestUser.ID,
+	Kind:      types.AddressKindHome,
+	IsDefault: true,
+	Address: types.Address{
+		Street:       "Main Street",
+		StreetNumber: "123",
+		Extra:        types.Pointer("Apartment 1"),
+		ZipCode:      "12345",
+		City:         "Berlin",
+	},
+	CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	UpdatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
 }
 
 func MigrateTestData(db *gorm.DB) {
 	migrateUsers(db)
+	migrateAddresses(db)
 }
 
 func migrateUsers(db *gorm.DB) {
 	if err := db.Table("users").Create([]map[string]any{
 		{
-			"id": TestUser.ID,
+			"id":         TestUser.ID,
+			"created_at": TestUser.CreatedAt,
 		},
 		{
-			"id": TestAdminUser.ID,
+			"id":         TestAdminUser.ID,
+			"created_at": TestAdminUser.CreatedAt,
 		},
 	}).Error; err != nil {
 		panic(errors.Wrap(err, "failed to create Test data"))
@@ -55,6 +82,7 @@ func migrateUsers(db *gorm.DB) {
 	if err := db.Table("user_versions").Create([]map[string]any{
 		{
 			"id":            TestUserOldVersionID,
+			"created_at":    TestUserOldVersionCreatedAt,
 			"user_id":       TestUser.ID,
 			"first_name":    "old",
 			"last_name":     "user",
@@ -67,9 +95,10 @@ func migrateUsers(db *gorm.DB) {
 		panic(errors.Wrap(err, "failed to create Test data"))
 	}
 
-	if err := db.Table("user_versions").Create([]map[string]any{
+	currentVersions := []map[string]any{
 		{
 			"id":            TestUser.VersionID,
+			"created_at":    TestUser.UpdatedAt,
 			"user_id":       TestUser.ID,
 			"first_name":    TestUser.FirstName,
 			"last_name":     TestUser.LastName,
@@ -80,6 +109,7 @@ func migrateUsers(db *gorm.DB) {
 		},
 		{
 			"id":            TestAdminUser.VersionID,
+			"created_at":    TestAdminUser.UpdatedAt,
 			"user_id":       TestAdminUser.ID,
 			"first_name":    TestAdminUser.FirstName,
 			"last_name":     TestAdminUser.LastName,
@@ -88,6 +118,35 @@ func migrateUsers(db *gorm.DB) {
 			"is_admin":      TestAdminUser.IsAdmin,
 			"password_hash": TestAdminUser.PasswordHash,
 		},
+	}
+	if err := db.Table("user_versions").Create(&currentVersions).Error; err != nil {
+		panic(errors.Wrap(err, "failed to create Test data"))
+	}
+	if err := db.Table("user_current").Create(&currentVersions).Error; err != nil {
+		panic(errors.Wrap(err, "failed to create Test data"))
+	}
+}
+
+func migrateAddresses(db *gorm.DB) {
+	if err := db.Table("addresses").Create([]map[string]any{
+		{
+			"id":         TestAddress.ID,
+			"created_at": TestAddress.CreatedAt,
+			"user_id":    TestAddress.UserID,
+		},
+	}).Error; err != nil {
+		panic(errors.Wrap(err, "failed to create Test data"))
+	}
+
+	if err := db.Table("address_versions").Create([]map[string]any{
+		{
+			"id":         TestAddress.VersionID,
+			"created_at": TestAddress.UpdatedAt,
+			"address_id": TestAddress.ID,
+			"kind":       string(TestAddress.Kind),
+			"is_default": TestAddress.IsDefault,
+			"address":    TestAddress.Address,
+		},
 	}).Error; err != nil {
 		panic(errors.Wrap(err, "failed to create Test data"))
 	}
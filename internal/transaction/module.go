@@ -0,0 +1,12 @@
+package transaction
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+)
+
+var FXTransactionProvide = fx.Provide(
+	create,
+	func(u *unitOfWork) datasource.UnitOfWork { return u },
+)
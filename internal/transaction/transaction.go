@@ -0,0 +1,99 @@
+// Package transaction implements datasource.UnitOfWork with gorm. The transaction of a unit is carried by the context,
+// datasources get it with DB and join it, and work that may only happen once it is committed is deferred with AfterCommit.
+// The transaction uses a single connection, so the datasources must not be called concurrently within a unit.
+package transaction
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+type ctxKey struct{}
+
+// tx is a unit of work in progress
+type tx struct {
+	db          *gorm.DB
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+func (t *tx) onCommit(fns ...func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.afterCommit = append(t.afterCommit, fns...)
+}
+
+type unitOfWork struct {
+	*gorm.DB
+}
+
+func create(g *gorm.DB) *unitOfWork {
+	return &unitOfWork{
+		DB: g,
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Do(ctx, u.DB, fn)
+}
+
+// Do runs fn in a transaction of db which is rolled back if fn fails or panics. A unit nested in another one is
+// a savepoint of the outer transaction, the functions deferred in it run once the outer transaction is committed.
+func Do(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	parent, _ := ctx.Value(ctxKey{}).(*tx)
+	if parent != nil {
+		db = parent.db
+	}
+
+	t := &tx{}
+	var fnErr error
+	err := db.WithContext(ctx).Transaction(func(g *gorm.DB) error {
+		t.db = g
+		fnErr = fn(context.WithValue(ctx, ctxKey{}, t))
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		// beginning or committing the transaction failed
+		return types.DBError(err)
+	}
+
+	if parent != nil {
+		parent.onCommit(t.afterCommit...)
+		return nil
+	}
+	for _, f := range t.afterCommit {
+		f()
+	}
+	return nil
+}
+
+// DB returns the transaction of the unit of work of the context, or db outside of units
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if t, ok := ctx.Value(ctxKey{}).(*tx); ok {
+		return t.db.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InProgress reports whether the context belongs to a unit of work, whose changes are not visible to others yet
+func InProgress(ctx context.Context) bool {
+	_, ok := ctx.Value(ctxKey{}).(*tx)
+	return ok
+}
+
+// AfterCommit runs fn once the unit of work of the context is committed, not at all if it is rolled back,
+// and right away outside of units
+func AfterCommit(ctx context.Context, fn func()) {
+	if t, ok := ctx.Value(ctxKey{}).(*tx); ok {
+		t.onCommit(fn)
+		return
+	}
+	fn()
+}
@@ -2,63 +2,34 @@ package types
 
 import (
 	"database/sql/driver"
-	"encoding/csv"
-	"fmt"
-	"strings"
+	"regexp"
+	"time"
 
-	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// The patterns of the address domain of the database, see the v1 migration
+var (
+	// StreetNumberPattern matches e.g. 123, 123a, 123-125 and 123a-125
+	StreetNumberPattern = regexp.MustCompile(`^[0-9]+[A-Za-z]?(-[0-9]+[A-Za-z]?)?$`)
+	ZipCodePattern      = regexp.MustCompile(`^\d{5}$`)
 )
 
 type Address struct {
-	Street       string
-	StreetNumber string
-	Extra        *string
-	ZipCode      string
-	City         string
+	Street       string  `composite:"street"`
+	StreetNumber string  `composite:"street_number"`
+	Extra        *string `composite:"extra"`
+	ZipCode      string  `composite:"zip_code"`
+	City         string  `composite:"city"`
 }
 
 func (a *Address) Scan(value any) error {
-	var s string
-	switch v := value.(type) {
-	case nil:
-		return nil
-	case string:
-		s = v
-	case []byte:
-		s = string(v)
-	default:
-		return fmt.Errorf("unsupported type for Address: %T", value)
-	}
-
-	s = strings.TrimSuffix(strings.TrimPrefix(s, "("), ")")
-	reader := csv.NewReader(strings.NewReader(s))
-	reader.TrimLeadingSpace = true
-	fields, err := reader.Read()
-	if err != nil {
-		return errors.Newf("error reading CSV: %v", err)
-	}
-
-	if len(fields) != 5 {
-		return errors.Newf("expected 4 fields, got %d", len(fields))
-	}
-
-	a.Street = fields[0]
-	a.StreetNumber = fields[1]
-	if fields[2] != "" && fields[2] != "NULL" {
-		a.Extra = &fields[2]
-	}
-	a.ZipCode = fields[3]
-	a.City = fields[4]
-
-	return nil
+	return ScanComposite(value, a)
 }
 
 func (a Address) Value() (driver.Value, error) {
-	if a.Extra != nil {
-		return fmt.Sprintf("(%q,%q,%q,%q,%q)", a.Street, a.StreetNumber, *a.Extra, a.ZipCode, a.City), nil
-	} else {
-		return fmt.Sprintf("(%q,%q,NULL,%q,%q)", a.Street, a.StreetNumber, a.ZipCode, a.City), nil
-	}
+	return MarshalComposite(a)
 }
 
 type AddressPatch struct {
@@ -88,3 +59,120 @@ func (c *AddressPatch) ToMap() map[string]any {
 	}
 	return m
 }
+
+type AddressKind string
+
+const (
+	AddressKindBilling  AddressKind = "billing"
+	AddressKindShipping AddressKind = "shipping"
+	AddressKindHome     AddressKind = "home"
+)
+
+func (k AddressKind) IsValid() bool {
+	return k == AddressKindBilling || k == AddressKindShipping || k == AddressKindHome
+}
+
+// UserAddress is a versioned address of a user, only one address per user and kind can be the default one
+type UserAddress struct {
+	ID        uuid.UUID   `gorm:"column:id"`
+	VersionID uuid.UUID   `gorm:"column:version_id"`
+	UserID    uuid.UUID   `gorm:"column:user_id"`
+	Kind      AddressKind `gorm:"column:kind"`
+	IsDefault bool        `gorm:"column:is_default"`
+	Address   Address     `gorm:"column:address"`
+	// CreatedAt is the creation time of the address itself
+	CreatedAt time.Time `gorm:"column:created_at"`
+	// UpdatedAt is the creation time of this version of the address
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+// AfterFind normalizes the timestamps to UTC as the driver returns them in the local timezone
+func (a *UserAddress) AfterFind(_ *gorm.DB) error {
+	a.CreatedAt = a.CreatedAt.UTC()
+	a.UpdatedAt = a.UpdatedAt.UTC()
+	return nil
+}
+
+func (a *UserAddress) ToSave() (base, version map[string]any) {
+	a.VersionID = uuid.New()
+	a.UpdatedAt = now()
+
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+		a.CreatedAt = a.UpdatedAt
+		base = map[string]any{
+			"id":         a.ID,
+			"created_at": a.CreatedAt,
+			"user_id":    a.UserID,
+		}
+	}
+
+	version = map[string]any{
+		"id":         a.VersionID,
+		"created_at": a.UpdatedAt,
+		"address_id": a.ID,
+		"kind":       string(a.Kind),
+		"is_default": a.IsDefault,
+		"address":    a.Address,
+	}
+
+	return base, version
+}
+
+type UserAddressPatch struct {
+	ID        Optional[uuid.UUID]
+	VersionID Optional[uuid.UUID]
+	UserID    Optional[uuid.UUID]
+	Kind      Optional[AddressKind]
+	IsDefault Optional[bool]
+	Address   AddressPatch
+}
+
+// ToMap only maps the top level fields, the address fields are part of a composite column and can't be compared directly
+func (a *UserAddressPatch) ToMap() map[string]any {
+	m := make(map[string]any)
+	if a.ID.HasValue {
+		m["id"] = a.ID.Value
+	}
+	if a.VersionID.HasValue {
+		m["version_id"] = a.VersionID.Value
+	}
+	if a.UserID.HasValue {
+		m["user_id"] = a.UserID.Value
+	}
+	if a.Kind.HasValue {
+		m["kind"] = string(a.Kind.Value)
+	}
+	if a.IsDefault.HasValue {
+		m["is_default"] = a.IsDefault.Value
+	}
+	return m
+}
+
+func (a *UserAddress) ApplyPatch(p UserAddressPatch) {
+	if p.Kind.HasValue {
+		a.Kind = p.Kind.Value
+	}
+	if p.IsDefault.HasValue {
+		a.IsDefault = p.IsDefault.Value
+	}
+	a.Address.ApplyPatch(p.Address)
+}
+
+func (a *Address) ApplyPatch(p AddressPatch) {
+	if p.Street.HasValue {
+		a.Street = p.Street.Value
+	}
+	if p.StreetNumber.HasValue {
+		a.StreetNumber = p.StreetNumber.Value
+	}
+	if p.Extra.HasValue {
+		a.Extra = p.Extra.Value
+	}
+	if p.ZipCode.HasValue {
+		a.ZipCode = p.ZipCode.Value
+	}
+	if p.City.HasValue {
+		a.City = p.City.Value
+	}
+}
@@ -2,7 +2,7 @@ package types
 
 import (
 	"database/sql/driver"
-	"strconv"
+	"reflect"
 	"strings"
 
 	"github.com/cockroachdb/errors"
@@ -11,20 +11,19 @@ import (
 // Array is a custom type that implements the sql.Scanner and driver.Valuer interfaces.
 // This is important for the gorm library to be able to store arrays in the database.
 // Example: gorm fails to store []string but can store Array[string]
-// Note: only primitive types are supported as complex types can have different
-// representations in string or byte slice format. See the time_slots.go as an example.
+// Encoding and decoding follows the text representation of postgres arrays,
+// see https://www.postgresql.org/docs/current/arrays.html#ARRAYS-IO
+// Elements can be of any type supported in text.go, pointer elements map NULL to nil.
+// Multidimensional arrays are represented by nested slices, e.g. Array[Array[int]] or Array[[]int].
 
-type Array[T string |
-	bool |
-	uintptr |
-	int | int64 | int32 | int16 | int8 |
-	uint | uint64 | uint32 | uint16 | uint8 |
-	float64 | float32 |
-	complex128 | complex64] []T
+type Array[T any] []T
 
 func (a *Array[T]) Scan(value any) error {
 	var s string
 	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
 	case []byte:
 		s = string(v)
 	case string:
@@ -33,91 +32,237 @@ func (a *Array[T]) Scan(value any) error {
 		return errors.Newf("unsupported type for Array: %T", value)
 	}
 
-	*a = []T{}
+	element, err := parseArray(s)
+	if err != nil {
+		return err
+	}
+
+	return decodeArray(reflect.ValueOf(a).Elem(), element)
+}
+
+func (a Array[T]) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
 
-	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
-	parts := strings.Split(s, ",")
-	for _, part := range parts {
-		t, err := stringToType[T](part)
+	var b strings.Builder
+	if err := encodeArray(&b, reflect.ValueOf(a)); err != nil {
+		return nil, err
+	}
+	return b.String(), nil
+}
+
+func isArrayType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && !t.Implements(textMarshalerType)
+}
+
+func encodeArray(b *strings.Builder, v reflect.Value) error {
+	b.WriteByte('{')
+	for i := range v.Len() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		element := v.Index(i)
+		if isArrayType(element.Type()) {
+			if err := encodeArray(b, element); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, isNull, err := encodeText(element)
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "array element %d", i)
+		}
+		if isNull {
+			b.WriteString("NULL")
+			continue
 		}
 
-		*a = append(*a, t)
+		writeQuoted(b, s)
 	}
+	b.WriteByte('}')
+	return nil
+}
+
+func decodeArray(v reflect.Value, element arrayElement) error {
+	if !element.isArray {
+		return errors.New("expected array, got scalar element")
+	}
+
+	slice := reflect.MakeSlice(v.Type(), len(element.children), len(element.children))
+	for i, child := range element.children {
+		if isArrayType(slice.Index(i).Type()) {
+			if err := decodeArray(slice.Index(i), child); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if child.isArray {
+			return errors.Newf("array element %d: expected scalar, got array", i)
+		}
+		if err := decodeText(slice.Index(i), child.value); err != nil {
+			return errors.Wrapf(err, "array element %d", i)
+		}
+	}
+	v.Set(slice)
 
 	return nil
 }
 
-func (a Array[T]) Value() (driver.Value, error) {
-	return []T(a), nil
+// arrayElement is either a (sub) array with children or a scalar value, where a nil value represents NULL
+type arrayElement struct {
+	isArray  bool
+	children []arrayElement
+	value    *string
 }
 
-//nolint:gocyclo // this function is long but it's just a switch case
-func stringToType[T string |
-	bool |
-	uintptr |
-	int | int64 | int32 | int16 | int8 |
-	uint | uint64 | uint32 | uint16 | uint8 |
-	float64 | float32 |
-	complex128 | complex64](s string) (T, error) {
-	var t T
-	var val any
-	var err error
-
-	var i64 int64
-	var u64 uint64
-	var f64 float64
-	var c128 complex128
-	switch any(t).(type) {
-	case string:
-		val = s
-	case bool:
-		val, err = strconv.ParseBool(s)
-	case uintptr:
-		u64, err = strconv.ParseUint(s, 10, 0)
-		val = uintptr(u64)
-	case int:
-		i64, err = strconv.ParseInt(s, 10, 0)
-		val = int(i64)
-	case int64:
-		val, err = strconv.ParseInt(s, 10, 64)
-	case int32:
-		i64, err = strconv.ParseInt(s, 10, 32)
-		val = int32(i64)
-	case int16:
-		i64, err = strconv.ParseInt(s, 10, 16)
-		val = int16(i64)
-	case int8:
-		i64, err = strconv.ParseInt(s, 10, 8)
-		val = int8(i64)
-	case uint:
-		u64, err = strconv.ParseUint(s, 10, 0)
-		val = uint(u64)
-	case uint64:
-		val, err = strconv.ParseUint(s, 10, 64)
-	case uint32:
-		u64, err = strconv.ParseUint(s, 10, 32)
-		val = uint32(u64)
-	case uint16:
-		u64, err = strconv.ParseUint(s, 10, 16)
-		val = uint16(u64)
-	case uint8:
-		u64, err = strconv.ParseUint(s, 10, 8)
-		val = uint8(u64)
-	case float64:
-		val, err = strconv.ParseFloat(s, 64)
-	case float32:
-		f64, err = strconv.ParseFloat(s, 32)
-		val = float32(f64)
-	case complex128:
-		val, err = strconv.ParseComplex(s, 128)
-	case complex64:
-		c128, err = strconv.ParseComplex(s, 64)
-		val = complex64(c128)
-	default:
-		return t, errors.Newf("unsupported type: %T", t)
+// parseArray parses an array literal, mirroring array_in of postgres: whitespace around elements is ignored,
+// a backslash escapes the next character, double quotes protect special characters and an unquoted NULL is NULL
+func parseArray(s string) (arrayElement, error) {
+	s = strings.TrimSpace(s)
+
+	// Skip the optional dimension decoration of arrays with lower bounds other than 1, e.g. [0:1]={1,2}
+	if strings.HasPrefix(s, "[") {
+		i := strings.Index(s, "=")
+		if i == -1 {
+			return arrayElement{}, errors.Newf("malformed array literal: %q", s)
+		}
+		s = strings.TrimSpace(s[i+1:])
+	}
+
+	p := arrayParser{s: s}
+	element, err := p.parseArray()
+	if err != nil {
+		return element, err
+	}
+
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return element, errors.Newf("junk after array literal: %q", s)
+	}
+
+	return element, nil
+}
+
+type arrayParser struct {
+	s   string
+	pos int
+}
+
+func (p *arrayParser) skipSpace() {
+	for p.pos < len(p.s) && isArraySpace(p.s[p.pos]) {
+		p.pos++
 	}
-	t = val.(T)
-	return t, err
+}
+
+func isArraySpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\v' || ch == '\f'
+}
+
+func (p *arrayParser) errorf(format string) error {
+	return errors.Newf(format+" at position %d of array literal: %q", p.pos, p.s)
+}
+
+func (p *arrayParser) parseArray() (arrayElement, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+		return arrayElement{}, p.errorf("expected {")
+	}
+	p.pos++
+
+	element := arrayElement{isArray: true}
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return element, nil
+	}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return element, p.errorf("unexpected end")
+		}
+
+		var child arrayElement
+		var err error
+		if p.s[p.pos] == '{' {
+			child, err = p.parseArray()
+		} else {
+			child, err = p.parseScalar()
+		}
+		if err != nil {
+			return element, err
+		}
+		element.children = append(element.children, child)
+
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return element, p.errorf("unexpected end")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return element, nil
+		default:
+			return element, p.errorf("unexpected character")
+		}
+	}
+}
+
+func (p *arrayParser) parseScalar() (arrayElement, error) {
+	var b strings.Builder
+	// escaped tells whether quotes or backslashes were used, which prevents the element from being NULL
+	escaped, inQuote := false, false
+	// significant is the length of the value without trailing unquoted whitespace
+	significant := 0
+
+	for {
+		if p.pos >= len(p.s) {
+			return arrayElement{}, p.errorf("unexpected end")
+		}
+
+		ch := p.s[p.pos]
+		if !inQuote && (ch == ',' || ch == '}') {
+			break
+		}
+
+		switch {
+		case ch == '\\':
+			p.pos++
+			if p.pos >= len(p.s) {
+				return arrayElement{}, p.errorf("unexpected end")
+			}
+			b.WriteByte(p.s[p.pos])
+			escaped = true
+			significant = b.Len()
+		case ch == '"':
+			inQuote = !inQuote
+			escaped = true
+			significant = b.Len()
+		case !inQuote && ch == '{':
+			return arrayElement{}, p.errorf("unexpected character")
+		default:
+			b.WriteByte(ch)
+			if inQuote || !isArraySpace(ch) {
+				significant = b.Len()
+			}
+		}
+		p.pos++
+	}
+
+	value := b.String()[:significant]
+	if !escaped {
+		if value == "" {
+			return arrayElement{}, p.errorf("unexpected empty element")
+		}
+		if strings.EqualFold(value, "NULL") {
+			return arrayElement{}, nil
+		}
+	}
+
+	return arrayElement{value: &value}, nil
 }
@@ -0,0 +1,290 @@
+package types
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ArrayScan(t *testing.T) {
+	id := uuid.MustParse("b05a5d28-1a51-46a8-b35c-6e160a05a0ad")
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 123456000, time.UTC)
+
+	tests := []struct {
+		name    string
+		s       string
+		got     any
+		want    any
+		wantErr bool
+	}{
+		{
+			name: "Empty Case",
+			s:    "{}",
+			got:  &Array[int]{},
+			want: &Array[int]{},
+		},
+		{
+			name: "Numbers Case",
+			s:    "{1, 2 ,3}",
+			got:  &Array[int]{},
+			want: &Array[int]{1, 2, 3},
+		},
+		{
+			name: "Quoted Strings Case",
+			s:    `{"a,b","say \"hi\"","back\\slash"," padded ",plain,""}`,
+			got:  &Array[string]{},
+			want: &Array[string]{"a,b", `say "hi"`, `back\slash`, " padded ", "plain", ""},
+		},
+		{
+			name: "NULL Case",
+			s:    `{NULL,"NULL",null,abc}`,
+			got:  &Array[*string]{},
+			want: &Array[*string]{nil, Pointer("NULL"), nil, Pointer("abc")},
+		},
+		{
+			name: "Multidimensional Case",
+			s:    "{{1,2},{3,4}}",
+			got:  &Array[Array[int]]{},
+			want: &Array[Array[int]]{{1, 2}, {3, 4}},
+		},
+		{
+			name: "Dimension Decoration Case",
+			s:    "[0:1]={t,f}",
+			got:  &Array[bool]{},
+			want: &Array[bool]{true, false},
+		},
+		{
+			name: "UUID Case",
+			s:    "{b05a5d28-1a51-46a8-b35c-6e160a05a0ad,NULL}",
+			got:  &Array[*uuid.UUID]{},
+			want: &Array[*uuid.UUID]{&id, nil},
+		},
+		{
+			name: "Time Case",
+			s:    `{"2024-01-01 12:00:00.123456+00","2024-01-01 13:00:00.123456+01:00"}`,
+			got:  &Array[time.Time]{},
+			want: &Array[time.Time]{ts, ts},
+		},
+		{
+			name:    "NULL In Non-Pointer Case",
+			s:       "{1,NULL}",
+			got:     &Array[int]{},
+			wantErr: true,
+		},
+		{
+			name:    "Empty Element Case",
+			s:       "{1,,2}",
+			got:     &Array[int]{},
+			wantErr: true,
+		},
+		{
+			name:    "Dimension Mismatch Case",
+			s:       "{1,2}",
+			got:     &Array[Array[int]]{},
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated Case",
+			s:       `{"abc}`,
+			got:     &Array[string]{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			switch got := tt.got.(type) {
+			case *Array[int]:
+				err = got.Scan(tt.s)
+			case *Array[string]:
+				err = got.Scan(tt.s)
+			case *Array[*string]:
+				err = got.Scan(tt.s)
+			case *Array[Array[int]]:
+				err = got.Scan(tt.s)
+			case *Array[bool]:
+				err = got.Scan(tt.s)
+			case *Array[*uuid.UUID]:
+				err = got.Scan(tt.s)
+			case *Array[time.Time]:
+				err = got.Scan([]byte(tt.s))
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Array.Scan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			if times, ok := tt.got.(*Array[time.Time]); ok {
+				for i, got := range *times {
+					assert.True(t, got.Equal((*tt.want.(*Array[time.Time]))[i]))
+				}
+				return
+			}
+			assert.Equal(t, tt.want, tt.got)
+		})
+	}
+}
+
+func Test_ArrayValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value driver.Valuer
+		want  any
+	}{
+		{
+			name:  "Nil Case",
+			value: Array[int](nil),
+			want:  nil,
+		},
+		{
+			name:  "Empty Case",
+			value: Array[int]{},
+			want:  "{}",
+		},
+		{
+			name:  "Strings Case",
+			value: Array[*string]{Pointer("a,b"), nil, Pointer(`"\`), Pointer("NULL")},
+			want:  `{"a,b",NULL,"\"\\","NULL"}`,
+		},
+		{
+			name:  "Multidimensional Case",
+			value: Array[[]int]{{1, 2}, {3, 4}},
+			want:  `{{"1","2"},{"3","4"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.value.Value()
+			if err != nil {
+				t.Errorf("Array.Value() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func FuzzArray(f *testing.F) {
+	f.Add("abc", "def", false)
+	f.Add("", "NULL", false)
+	f.Add("", "", true)
+	f.Add(`{"a,b"}`, ` \ `, false)
+	f.Add("\x97", "null", true)
+	f.Fuzz(func(t *testing.T, a, b string, isNull bool) {
+		want := Array[Array[*string]]{{&a, &b}, {&b, &a}}
+		if isNull {
+			want[1][0] = nil
+		}
+
+		value, err := want.Value()
+		if err != nil {
+			t.Fatalf("Array.Value() error = %v", err)
+		}
+
+		var got Array[Array[*string]]
+		if err := got.Scan(value); err != nil {
+			t.Fatalf("Array.Scan(%q) error = %v", value, err)
+		}
+		assert.Equal(t, want, got)
+	})
+}
+
+// FuzzArrayParse makes sure that malformed input is rejected without panicking
+func FuzzArrayParse(f *testing.F) {
+	f.Add("{}")
+	f.Add(`{{"a",NULL},{b,"c\"d"}}`)
+	f.Add("[1:2]={1,2}")
+	f.Add(`{"a`)
+	f.Add(`{\`)
+	f.Fuzz(func(t *testing.T, s string) {
+		var got Array[Array[*string]]
+		_ = got.Scan(s)
+	})
+}
+
+func Test_ArrayPostgres(t *testing.T) {
+	id := uuid.New()
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 123456000, time.UTC)
+
+	tests := []struct {
+		name     string
+		cast     string
+		value    driver.Valuer
+		scan     func(value any) (any, error)
+		want     any
+		wantText string
+	}{
+		{
+			name:  "Text Case",
+			cast:  "text[]",
+			value: Array[*string]{Pointer(`O"Brien, Straße`), nil, Pointer(""), Pointer("NULL"), Pointer(`back\slash {x}`)},
+			scan: func(value any) (any, error) {
+				var a Array[*string]
+				return a, a.Scan(value)
+			},
+			want: Array[*string]{Pointer(`O"Brien, Straße`), nil, Pointer(""), Pointer("NULL"), Pointer(`back\slash {x}`)},
+		},
+		{
+			name:  "UUID Case",
+			cast:  "uuid[]",
+			value: Array[uuid.UUID]{id, uuid.Nil},
+			scan: func(value any) (any, error) {
+				var a Array[uuid.UUID]
+				return a, a.Scan(value)
+			},
+			want: Array[uuid.UUID]{id, uuid.Nil},
+		},
+		{
+			name:  "Time Case",
+			cast:  "timestamptz[]",
+			value: Array[time.Time]{ts},
+			scan: func(value any) (any, error) {
+				var a Array[time.Time]
+				if err := a.Scan(value); err != nil {
+					return nil, err
+				}
+				return Array[time.Time]{a[0].UTC()}, nil
+			},
+			want: Array[time.Time]{ts},
+		},
+		{
+			name:  "Multidimensional Case",
+			cast:  "int[][]",
+			value: Array[Array[*int]]{{Pointer(1), nil}, {Pointer(3), Pointer(4)}},
+			scan: func(value any) (any, error) {
+				var a Array[Array[*int]]
+				return a, a.Scan(value)
+			},
+			want: Array[Array[*int]]{{Pointer(1), nil}, {Pointer(3), Pointer(4)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.value.Value()
+			if err != nil {
+				t.Errorf("Array.Value() error = %v", err)
+				return
+			}
+
+			var returned string
+			if err := testPostgres().Raw("SELECT CAST(? AS "+tt.cast+")::text", value).Row().Scan(&returned); err != nil {
+				t.Errorf("postgres error for %q = %v", value, err)
+				return
+			}
+
+			got, err := tt.scan(returned)
+			if err != nil {
+				t.Errorf("Array.Scan(%q) error = %v", returned, err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
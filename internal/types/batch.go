@@ -0,0 +1,37 @@
+package types
+
+import (
+	"github.com/google/uuid"
+)
+
+// ErrBatchAborted is the result of the operations of an atomic batch which were rolled back or not applied
+// as another operation of the batch failed
+var ErrBatchAborted = NewError(nil, "batch_aborted", "aborted as another operation of the atomic batch failed")
+
+type UserOperationKind string
+
+const (
+	UserOperationCreate UserOperationKind = "create"
+	UserOperationPatch  UserOperationKind = "patch"
+	UserOperationDelete UserOperationKind = "delete"
+)
+
+// UserOperation is a single mutation of a batch
+type UserOperation struct {
+	Kind UserOperationKind
+	// ID is the id of the user to patch or delete
+	ID uuid.UUID
+	// Input is the user to create
+	Input UserInput
+	// User is the user to create with its password hashed, set by the usecases from the input
+	User User
+	// Patch is applied to the latest version of the user
+	Patch UserPatch
+}
+
+// UserOperationResult is the outcome of a single operation of a batch
+type UserOperationResult struct {
+	// User is the created or patched user
+	User User
+	Err  error
+}
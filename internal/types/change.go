@@ -0,0 +1,58 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UserChangeKind string
+
+const (
+	UserChangeCreated UserChangeKind = "created"
+	UserChangeUpdated UserChangeKind = "updated"
+	UserChangeDeleted UserChangeKind = "deleted"
+)
+
+// UserChange is a create, update (a new version) or delete of a user
+type UserChange struct {
+	Kind UserChangeKind `gorm:"column:kind"`
+	// ID identifies the change, it is the version id for creates and updates and the user id for deletes
+	ID        uuid.UUID `gorm:"column:change_id"`
+	ChangedAt time.Time `gorm:"column:changed_at"`
+	// User is the created or updated version of the user, only the id is set for deletes.
+	// The password hash is never set.
+	User User `gorm:"embedded"`
+}
+
+// AfterFind normalizes the timestamps to UTC as the driver returns them in the local timezone
+func (c *UserChange) AfterFind(tx *gorm.DB) error {
+	c.ChangedAt = c.ChangedAt.UTC()
+	return c.User.AfterFind(tx)
+}
+
+// UserChangeSortFields is the order of the changes feed, changes are resumed after a cursor at these fields
+var UserChangeSortFields = SortFields{
+	"changed_at": FilterTime,
+	"change_id":  FilterUUID,
+}
+
+// UserChangeSort orders the changes by the time they were made
+var UserChangeSort = Sort{{Field: "changed_at"}, {Field: "change_id"}}
+
+// SortValue returns the value of a field of UserChangeSortFields for cursors
+func (c UserChange) SortValue(field string) any {
+	switch field {
+	case "changed_at":
+		return c.ChangedAt
+	case "change_id":
+		return c.ID
+	}
+	return nil
+}
+
+// Cursor returns the position right after the change in the changes feed
+func (c UserChange) Cursor() *Cursor {
+	return cursorAt(UserChangeSort, c, false)
+}
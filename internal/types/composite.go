@@ -0,0 +1,177 @@
+package types
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Composite encoding and decoding follows the text representation of postgres composite types (rows),
+// see https://www.postgresql.org/docs/current/rowtypes.html#ROWTYPES-IO-SYNTAX
+// Structs are mapped field by field in declaration order, fields are included by a `composite:"name"` tag.
+// Pointer fields map NULL to nil, non-pointer fields can't hold NULL. An empty string is always quoted to
+// distinguish it from NULL. See text.go for the supported field types.
+
+const compositeTag = "composite"
+
+type compositeField struct {
+	name  string
+	index int
+}
+
+func compositeFields(t reflect.Type) ([]compositeField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, errors.Newf("unsupported type for composite: %s", t)
+	}
+
+	var fields []compositeField
+	for i := range t.NumField() {
+		name, ok := t.Field(i).Tag.Lookup(compositeTag)
+		if !ok || name == "-" {
+			continue
+		}
+		fields = append(fields, compositeField{name: name, index: i})
+	}
+
+	if len(fields) == 0 {
+		return nil, errors.Newf("no composite fields in %s", t)
+	}
+
+	return fields, nil
+}
+
+// MarshalComposite encodes a struct into the text representation of a postgres composite type
+func MarshalComposite(v any) (string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fields, err := compositeFields(rv.Type())
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteByte('(')
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		s, isNull, err := encodeText(rv.Field(field.index))
+		if err != nil {
+			return "", errors.Wrapf(err, "composite field %s", field.name)
+		}
+		if isNull {
+			// NULL is represented by nothing at all
+			continue
+		}
+
+		writeQuoted(&b, s)
+	}
+	b.WriteByte(')')
+
+	return b.String(), nil
+}
+
+// UnmarshalComposite decodes the text representation of a postgres composite type into a struct pointer
+func UnmarshalComposite(s string, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.Newf("composite can only be decoded into a non-nil pointer, got %T", v)
+	}
+	rv = rv.Elem()
+
+	fields, err := compositeFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	values, err := parseComposite(s)
+	if err != nil {
+		return err
+	}
+
+	if len(values) != len(fields) {
+		return errors.Newf("expected %d composite fields, got %d", len(fields), len(values))
+	}
+
+	for i, field := range fields {
+		if err := decodeText(rv.Field(field.index), values[i]); err != nil {
+			return errors.Wrapf(err, "composite field %s", field.name)
+		}
+	}
+
+	return nil
+}
+
+// ScanComposite implements sql.Scanner for composite types, a NULL composite leaves the destination untouched
+func ScanComposite(value, dst any) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return UnmarshalComposite(v, dst)
+	case []byte:
+		return UnmarshalComposite(string(v), dst)
+	default:
+		return errors.Newf("unsupported type for composite: %T", value)
+	}
+}
+
+// parseComposite splits a composite literal into its fields, a nil field represents NULL.
+// The parsing mirrors record_in of postgres: unquoted whitespace is kept, a backslash escapes the next character
+// and a doubled double quote inside quotes is a literal double quote.
+func parseComposite(s string) ([]*string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return nil, errors.Newf("malformed composite literal: %q", s)
+	}
+
+	var values []*string
+	pos := 1
+	for {
+		// An empty field is NULL
+		if s[pos] == ',' || s[pos] == ')' {
+			values = append(values, nil)
+		} else {
+			var b strings.Builder
+			inQuote := false
+			for inQuote || (s[pos] != ',' && s[pos] != ')') {
+				ch := s[pos]
+				switch {
+				case ch == '\\':
+					pos++
+					if pos >= len(s)-1 {
+						return nil, errors.Newf("unexpected end of composite literal: %q", s)
+					}
+					b.WriteByte(s[pos])
+				case ch == '"' && !inQuote:
+					inQuote = true
+				case ch == '"' && s[pos+1] == '"':
+					b.WriteByte('"')
+					pos++
+				case ch == '"':
+					inQuote = false
+				default:
+					b.WriteByte(ch)
+				}
+				pos++
+				if pos >= len(s) {
+					return nil, errors.Newf("unexpected end of composite literal: %q", s)
+				}
+			}
+			value := b.String()
+			values = append(values, &value)
+		}
+
+		if s[pos] == ')' {
+			break
+		}
+		pos++
+	}
+
+	if pos != len(s)-1 {
+		return nil, errors.Newf("junk after composite literal: %q", s)
+	}
+
+	return values, nil
+}
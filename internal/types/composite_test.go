@@ -0,0 +1,273 @@
+package types
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pedramktb/schwarzit-probearbeit/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"gorm.io/gorm"
+)
+
+type testComposite struct {
+	Text     string  `composite:"text_value"`
+	Nullable *string `composite:"nullable_value"`
+	Ignored  string
+	Number   int32 `composite:"int_value"`
+}
+
+var (
+	postgresContainer testcontainers.Container
+	postgresDB        *gorm.DB
+	postgresOnce      sync.Once
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if postgresContainer != nil {
+		_ = postgresContainer.Terminate(context.Background())
+	}
+	os.Exit(code)
+}
+
+// testPostgres lazily starts postgres so that only the tests which need it depend on docker
+func testPostgres() *gorm.DB {
+	postgresOnce.Do(func() {
+		var ip, port string
+		postgresContainer, ip, port = postgres.Test_Create_Container()
+		postgresDB = postgres.Test_Create_DB(ip, port, "test-types")
+		if err := postgresDB.Exec("CREATE TYPE composite_test AS (text_value TEXT, nullable_value TEXT, int_value INTEGER)").Error; err != nil {
+			panic(err)
+		}
+	})
+	return postgresDB
+}
+
+func Test_UnmarshalComposite(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    testComposite
+		wantErr bool
+	}{
+		{
+			name: "Unquoted Case",
+			s:    "(abc,def,12)",
+			want: testComposite{Text: "abc", Nullable: Pointer("def"), Number: 12},
+		},
+		{
+			name: "NULL Case",
+			s:    "(abc,,12)",
+			want: testComposite{Text: "abc", Nullable: nil, Number: 12},
+		},
+		{
+			name: "Empty String Case",
+			s:    `("","",12)`,
+			want: testComposite{Text: "", Nullable: Pointer(""), Number: 12},
+		},
+		{
+			name: "Quoted Case",
+			s:    `("O""Brien Straße","a, b (c)",-1)`,
+			want: testComposite{Text: `O"Brien Straße`, Nullable: Pointer("a, b (c)"), Number: -1},
+		},
+		{
+			name: "Escaped Case",
+			s:    `("back\\slash \"quote\"",\,x,0)`,
+			want: testComposite{Text: `back\slash "quote"`, Nullable: Pointer(",x"), Number: 0},
+		},
+		{
+			name: "Whitespace Case",
+			s:    ` ( a b ," c",1) `,
+			want: testComposite{Text: " a b ", Nullable: Pointer(" c"), Number: 1},
+		},
+		{
+			name:    "NULL In Non-Pointer Case",
+			s:       "(,abc,12)",
+			wantErr: true,
+		},
+		{
+			name:    "Too Few Fields Case",
+			s:       "(abc,def)",
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated Quote Case",
+			s:       `("abc,def,12)`,
+			wantErr: true,
+		},
+		{
+			name:    "Junk Case",
+			s:       "(abc,def,12)x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testComposite
+			err := UnmarshalComposite(tt.s, &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnmarshalComposite() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_MarshalComposite(t *testing.T) {
+	tests := []struct {
+		name string
+		v    testComposite
+		want string
+	}{
+		{
+			name: "Simple Case",
+			v:    testComposite{Text: "abc", Nullable: Pointer("def"), Number: 12},
+			want: `("abc","def","12")`,
+		},
+		{
+			name: "NULL And Empty String Case",
+			v:    testComposite{Text: "", Nullable: nil, Ignored: "ignored", Number: 0},
+			want: `("",,"0")`,
+		},
+		{
+			name: "Escaped Case",
+			v:    testComposite{Text: `O"Brien \ Straße`, Nullable: Pointer("a,b"), Number: -1},
+			want: `("O\"Brien \\ Straße","a,b","-1")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalComposite(tt.v)
+			if err != nil {
+				t.Errorf("MarshalComposite() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Address(t *testing.T) {
+	tests := []struct {
+		name    string
+		address Address
+	}{
+		{
+			name:    "With Extra Case",
+			address: Address{Street: `O"Brien Straße`, StreetNumber: "12a", Extra: Pointer("Hinterhaus, 2. OG"), ZipCode: "12345", City: "Berlin"},
+		},
+		{
+			name:    "Without Extra Case",
+			address: Address{Street: "Main Street", StreetNumber: "123", ZipCode: "12345", City: "Frankfurt (Oder)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.address.Value()
+			if err != nil {
+				t.Errorf("Address.Value() error = %v", err)
+				return
+			}
+			var got Address
+			if err := got.Scan(value); err != nil {
+				t.Errorf("Address.Scan() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.address, got)
+		})
+	}
+}
+
+func addCompositeSeeds(f *testing.F) {
+	f.Add("abc", "def", false, int32(12))
+	f.Add("", "", false, int32(0))
+	f.Add("", "", true, int32(0))
+	f.Add(`O"Brien Straße`, "a, b (c)", false, int32(-1))
+	f.Add(`\"\\""`, `"`, false, int32(1))
+	f.Add(" NULL ", "NULL", false, int32(2))
+	f.Add("tab\tnew\nline", "ünïcödé 🏠", true, int32(3))
+	f.Add("\x97", "0", false, int32(-38))
+}
+
+func FuzzComposite(f *testing.F) {
+	addCompositeSeeds(f)
+	f.Fuzz(func(t *testing.T, text, nullable string, isNull bool, number int32) {
+		want := testComposite{Text: text, Nullable: &nullable, Number: number}
+		if isNull {
+			want.Nullable = nil
+		}
+
+		s, err := MarshalComposite(want)
+		if err != nil {
+			t.Fatalf("MarshalComposite() error = %v", err)
+		}
+
+		var got testComposite
+		if err := UnmarshalComposite(s, &got); err != nil {
+			t.Fatalf("UnmarshalComposite(%q) error = %v", s, err)
+		}
+		assert.Equal(t, want, got)
+	})
+}
+
+// FuzzCompositePostgres checks that postgres reads the encoded fields as they are
+// and that the text representation postgres returns decodes to the same fields
+func FuzzCompositePostgres(f *testing.F) {
+	addCompositeSeeds(f)
+	f.Fuzz(func(t *testing.T, text, nullable string, isNull bool, number int32) {
+		// postgres text can neither hold NUL characters nor invalid UTF-8
+		if strings.ContainsRune(text+nullable, 0) || !utf8.ValidString(text+nullable) {
+			t.Skip()
+		}
+
+		want := testComposite{Text: text, Nullable: &nullable, Number: number}
+		if isNull {
+			want.Nullable = nil
+		}
+
+		s, err := MarshalComposite(want)
+		if err != nil {
+			t.Fatalf("MarshalComposite() error = %v", err)
+		}
+
+		var fromPostgres testComposite
+		var returned string
+		err = testPostgres().Raw(
+			"SELECT (c).text_value, (c).nullable_value, (c).int_value, c::text FROM (SELECT CAST(? AS composite_test) AS c) AS t", s,
+		).Row().Scan(&fromPostgres.Text, &fromPostgres.Nullable, &fromPostgres.Number, &returned)
+		if err != nil {
+			t.Fatalf("postgres error for %q = %v", s, err)
+		}
+		assert.Equal(t, want, fromPostgres)
+
+		var got testComposite
+		if err := UnmarshalComposite(returned, &got); err != nil {
+			t.Fatalf("UnmarshalComposite(%q) error = %v", returned, err)
+		}
+		assert.Equal(t, want, got)
+	})
+}
+
+// FuzzUnmarshalComposite makes sure that malformed input is rejected without panicking
+func FuzzUnmarshalComposite(f *testing.F) {
+	f.Add("(abc,def,12)")
+	f.Add(`("a""b",\,,1)`)
+	f.Add(`("abc`)
+	f.Add(`(\`)
+	f.Add("()")
+	f.Fuzz(func(t *testing.T, s string) {
+		var got testComposite
+		_ = UnmarshalComposite(s, &got)
+	})
+}
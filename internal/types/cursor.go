@@ -0,0 +1,175 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"gorm.io/gorm"
+)
+
+// Cursor is a position in a sorted query result given by the sort key values of a row,
+// the page it points to starts after the row, or ends before it for backward cursors
+type Cursor struct {
+	// Sort includes the tiebreaker, so that the values identify the row
+	Sort     Sort
+	Values   []any
+	Backward bool
+}
+
+// Sortable entities provide the values of their sort fields for cursors
+type Sortable interface {
+	SortValue(field string) any
+}
+
+// Page is a page of a query result with the cursors to its neighbouring pages
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+	Prev  *Cursor
+	// Total is the number of matching rows over all pages, only counted if requested
+	Total *int64
+}
+
+type cursorJSON struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// MarshalCursor encodes the cursor, it is neither encrypted nor signed
+func MarshalCursor(c Cursor) ([]byte, error) {
+	values := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		s, isNull, err := encodeText(reflect.ValueOf(v))
+		if err != nil {
+			return nil, err
+		} else if isNull {
+			return nil, errors.Newf("sort field %q is null", c.Sort[i].Field)
+		}
+		values[i] = s
+	}
+	return json.Marshal(cursorJSON{Sort: c.Sort.String(), Values: values, Backward: c.Backward})
+}
+
+// UnmarshalCursor decodes a cursor, validating the sort and values against the given fields
+func UnmarshalCursor(data []byte, fields SortFields) (Cursor, error) {
+	var c cursorJSON
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, errors.Join(ErrInvalidCursor, err)
+	}
+	sort, err := ParseSort(c.Sort, fields)
+	if err != nil {
+		return Cursor{}, errors.Join(ErrInvalidCursor, err)
+	}
+	if len(sort) != len(c.Values) {
+		return Cursor{}, errors.Join(ErrInvalidCursor, errors.New("number of values does not match the sort"))
+	}
+	values := make([]any, len(c.Values))
+	for i, v := range c.Values {
+		if values[i], err = parseFilterValue(fields[sort[i].Field], v); err != nil {
+			return Cursor{}, errors.Join(ErrInvalidCursor, err)
+		}
+	}
+	return Cursor{Sort: sort, Values: values, Backward: c.Backward}, nil
+}
+
+func cursorAt(sort Sort, item Sortable, backward bool) *Cursor {
+	values := make([]any, len(sort))
+	for i, key := range sort {
+		values[i] = item.SortValue(key.Field)
+	}
+	return &Cursor{Sort: sort, Values: values, Backward: backward}
+}
+
+// Apply adds the keyset condition selecting the rows behind the cursor in the direction of the cursor,
+// i.e. (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... with the comparisons flipped for descending keys
+func (c Cursor) Apply(tx *gorm.DB, columns SortColumns) *gorm.DB {
+	var or []string
+	var vars []any
+	for i, key := range c.Sort {
+		column, ok := columns[key.Field]
+		if !ok {
+			_ = tx.AddError(errors.Join(ErrInternal, errors.Newf("no column for sort field %q", key.Field)))
+			return tx
+		}
+		var and []string
+		for j, previous := range c.Sort[:i] {
+			and = append(and, columns[previous.Field].SQL+" = ?")
+			vars = append(append(vars, columns[previous.Field].Vars...), c.Values[j])
+		}
+		if key.Desc != c.Backward {
+			and = append(and, column.SQL+" < ?")
+		} else {
+			and = append(and, column.SQL+" > ?")
+		}
+		vars = append(append(vars, column.Vars...), c.Values[i])
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return tx.Where("("+strings.Join(or, " OR ")+")", vars...)
+}
+
+// QueryPage queries a page of the sorted and filtered query, starting at the cursor if given, otherwise at the
+// offset. One row more than the limit is fetched to know whether another page follows.
+func QueryPage[T Sortable](db, tx *gorm.DB, params QueryParams, columns SortColumns, tiebreaker string) (Page[T], error) {
+	var page Page[T]
+
+	if params.Total {
+		var total int64
+		if err := db.Table("(?) AS query", tx).Count(&total).Error; err != nil {
+			return page, err
+		}
+		page.Total = &total
+	}
+
+	sort := params.Sort.WithTiebreaker(tiebreaker)
+	backward := false
+	if params.Cursor != nil {
+		if params.Offset != 0 {
+			return page, errors.Join(ErrInvalidCursor, errors.New("cursor and offset can't be combined"))
+		}
+		if params.Sort != nil && !slices.Equal(sort, params.Cursor.Sort) {
+			return page, errors.Join(ErrInvalidCursor, errors.New("sort differs from the sort of the cursor"))
+		}
+		sort, backward = params.Cursor.Sort, params.Cursor.Backward
+		tx = params.Cursor.Apply(tx, columns)
+	}
+	if backward {
+		tx = sort.Reverse().Apply(tx, columns, tiebreaker)
+	} else {
+		tx = sort.Apply(tx, columns, tiebreaker)
+	}
+
+	limit := params.limit()
+	if err := tx.Limit(limit + 1).Offset(params.Offset).Find(&page.Items).Error; err != nil {
+		return page, err
+	}
+	hasMore := len(page.Items) > limit
+	if hasMore {
+		page.Items = page.Items[:limit]
+	}
+	if backward {
+		slices.Reverse(page.Items)
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	first, last := page.Items[0], page.Items[len(page.Items)-1]
+	if backward {
+		page.Next = cursorAt(sort, last, false)
+		if hasMore {
+			page.Prev = cursorAt(sort, first, true)
+		}
+	} else {
+		if hasMore {
+			page.Next = cursorAt(sort, last, false)
+		}
+		if params.Cursor != nil || params.Offset > 0 {
+			page.Prev = cursorAt(sort, first, true)
+		}
+	}
+	return page, nil
+}
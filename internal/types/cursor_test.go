@@ -0,0 +1,105 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Cursor(t *testing.T) {
+	cursor := Cursor{
+		Sort:     Sort{{Field: "last_name"}, {Field: "created_at", Desc: true}, {Field: "id"}},
+		Values:   []any{"O\"Brien, Mü", time.Date(2024, 1, 1, 12, 0, 0, 123456000, time.UTC), uuid.MustParse("b05a5d28-1a51-46a8-b35c-6e160a05a0ad")},
+		Backward: true,
+	}
+
+	data, err := MarshalCursor(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalCursor(data, testSortFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, cursor, got)
+}
+
+func Test_UnmarshalCursor(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "Invalid JSON Case",
+			data: `{"s":`,
+		},
+		{
+			name: "Unknown Field Case",
+			data: `{"s":"password_hash","v":["x"]}`,
+		},
+		{
+			name: "Missing Value Case",
+			data: `{"s":"last_name,id","v":["Doe"]}`,
+		},
+		{
+			name: "Invalid Value Case",
+			data: `{"s":"id","v":["not a uuid"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalCursor([]byte(tt.data), testSortFields)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
+
+func Test_CursorApply(t *testing.T) {
+	db := testDryRunDB(t)
+
+	columns := SortColumns{
+		"id":         {SQL: "users.id"},
+		"last_name":  {SQL: "last_version.last_name"},
+		"created_at": {SQL: "users.created_at"},
+	}
+	sort := Sort{{Field: "last_name"}, {Field: "created_at", Desc: true}, {Field: "id"}}
+	values := []any{"Doe", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), uuid.Nil}
+
+	tests := []struct {
+		name     string
+		cursor   Cursor
+		wantSQL  string
+		wantVars []any
+	}{
+		{
+			name:   "Forward Case",
+			cursor: Cursor{Sort: sort, Values: values},
+			wantSQL: `SELECT * FROM "users" WHERE ((last_version.last_name > $1) OR ` +
+				`(last_version.last_name = $2 AND users.created_at < $3) OR ` +
+				`(last_version.last_name = $4 AND users.created_at = $5 AND users.id > $6))`,
+			wantVars: []any{values[0], values[0], values[1], values[0], values[1], values[2]},
+		},
+		{
+			name:   "Backward Case",
+			cursor: Cursor{Sort: sort, Values: values, Backward: true},
+			wantSQL: `SELECT * FROM "users" WHERE ((last_version.last_name < $1) OR ` +
+				`(last_version.last_name = $2 AND users.created_at > $3) OR ` +
+				`(last_version.last_name = $4 AND users.created_at = $5 AND users.id < $6))`,
+			wantVars: []any{values[0], values[0], values[1], values[0], values[1], values[2]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := tt.cursor.Apply(db.Table("users"), columns).Find(&[]map[string]any{})
+			if stmt.Error != nil {
+				t.Fatal(stmt.Error)
+			}
+			assert.Equal(t, tt.wantSQL, stmt.Statement.SQL.String())
+			assert.Equal(t, tt.wantVars, stmt.Statement.Vars)
+		})
+	}
+}
@@ -2,42 +2,227 @@ package types
 
 import (
 	"errors"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
+// Error is an error with a stable machine-readable code for clients, its message is safe to show to clients.
+// Primary errors are the classes of errors (mapped to HTTP status codes), secondary errors belong to a class.
+type Error struct {
+	Code    string
+	message string
+	class   error
+}
+
+// NewError returns a secondary error of the class, or a primary error if the class is nil
+func NewError(class error, code, message string) *Error {
+	return &Error{Code: code, message: message, class: class}
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+func (e *Error) Unwrap() error {
+	return e.class
+}
+
 // Errors
 var (
 	// Primary Errors
-	ErrNotFound     = errors.New("not found")
-	ErrBadRequest   = errors.New("bad request")
-	ErrUnauthorized = errors.New("unauthorized")
-	ErrForbidden    = errors.New("forbidden")
-	ErrInternal     = errors.New("internal error")
+	ErrNotFound     = NewError(nil, "not_found", "not found")
+	ErrBadRequest   = NewError(nil, "bad_request", "bad request")
+	ErrUnauthorized = NewError(nil, "unauthorized", "unauthorized")
+	ErrForbidden    = NewError(nil, "forbidden", "forbidden")
+	ErrInternal     = NewError(nil, "internal", "internal error")
+	// ErrPreconditionFailed is the failure of a condition of the request, e.g. the version it is based on
+	ErrPreconditionFailed = NewError(nil, "precondition_failed", "precondition failed")
+	// ErrConflict is a request which conflicts with the current state, e.g. another request in flight
+	ErrConflict = NewError(nil, "conflict", "conflict")
+	// ErrUnprocessable is a well-formed request whose data can't be processed, e.g. violating a constraint
+	ErrUnprocessable = NewError(nil, "unprocessable", "unprocessable")
+	// ErrRateLimited is a request of a client which made too many requests, it should retry later
+	ErrRateLimited = NewError(nil, "rate_limited", "too many requests")
 
 	// ErrBadRequest Most Used Secondary Errors
-	ErrInvalidID = errors.Join(ErrBadRequest, errors.New("invalid id"))
+	ErrInvalidID             = NewError(ErrBadRequest, "invalid_id", "invalid id")
+	ErrInvalidFilter         = NewError(ErrBadRequest, "invalid_filter", "invalid filter")
+	ErrInvalidSort           = NewError(ErrBadRequest, "invalid_sort", "invalid sort")
+	ErrInvalidCursor         = NewError(ErrBadRequest, "invalid_cursor", "invalid cursor")
+	ErrInvalidFields         = NewError(ErrBadRequest, "invalid_fields", "invalid fields")
+	ErrInvalidExpand         = NewError(ErrBadRequest, "invalid_expand", "invalid expand")
+	ErrInvalidOperation      = NewError(ErrBadRequest, "invalid_operation", "invalid operation")
+	ErrInvalidIdempotencyKey = NewError(ErrBadRequest, "invalid_idempotency_key", "invalid idempotency key")
+	ErrInvalidPatch          = NewError(ErrBadRequest, "invalid_patch", "invalid patch")
+	ErrValidationFailed      = NewError(ErrBadRequest, "validation_failed", "validation failed")
+
+	// ErrPreconditionFailed Most Used Secondary Errors
+	ErrVersionMismatch = NewError(ErrPreconditionFailed, "version_mismatch", "version is not the latest")
+
+	// ErrConflict Most Used Secondary Errors
+	ErrIdempotencyKeyReused = NewError(ErrConflict, "idempotency_key_reused", "idempotency key was used for a different request")
+	ErrRequestInProgress    = NewError(ErrConflict, "request_in_progress", "request with the idempotency key is in progress")
+	ErrPatchTestFailed      = NewError(ErrConflict, "patch_test_failed", "test operation of the patch failed")
+	ErrEmailTaken           = NewError(ErrConflict, "email_taken", "email is already taken")
+
+	// ErrUnprocessable Most Used Secondary Errors
+	ErrConstraintViolated = NewError(ErrUnprocessable, "constraint_violated", "data violates a constraint")
 
 	// ErrInternal Most Used Secondary Errors
-	ErrDBUnhandled   = errors.Join(ErrInternal, errors.New("database unhandled error"))
-	ErrDataImmutable = errors.Join(ErrInternal, errors.New("data is immutable"))
-	ErrDataCorrupted = errors.Join(ErrInternal, errors.New("data corrupted"))
+	ErrDBUnhandled   = NewError(ErrInternal, "database_unhandled", "database unhandled error")
+	ErrDataImmutable = NewError(ErrInternal, "data_immutable", "data is immutable")
+	ErrDataCorrupted = NewError(ErrInternal, "data_corrupted", "data corrupted")
 )
 
-// DBError converts gorm errors to internal errors
+// primaryErrors are the classes of errors, every error belongs to the first one it is
+var primaryErrors = []error{
+	ErrNotFound,
+	ErrBadRequest,
+	ErrUnauthorized,
+	ErrForbidden,
+	ErrPreconditionFailed,
+	ErrConflict,
+	ErrUnprocessable,
+	ErrRateLimited,
+	ErrBatchAborted,
+	ErrInternal,
+}
+
+// ErrorClass returns the primary error of the error, errors without a class are internal errors
+func ErrorClass(err error) error {
+	for _, class := range primaryErrors {
+		if errors.Is(err, class) {
+			return class
+		}
+	}
+	return ErrInternal
+}
+
+// ErrorCode returns the code of the most specific error with a code, the code of the class otherwise
+func ErrorCode(err error) string {
+	var coded *Error
+	if errors.As(err, &coded) && !errors.Is(err, ErrInternal) {
+		return coded.Code
+	}
+	return ErrorClass(err).(*Error).Code
+}
+
+// internalError is the cause of an error which is only logged, e.g. an error of the database
+type internalError struct {
+	err error
+}
+
+// Internal hides the cause of an error from clients, it is only part of the message of the error for the logs
+func Internal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &internalError{err: err}
+}
+
+func (e *internalError) Error() string {
+	return e.err.Error()
+}
+
+func (e *internalError) Unwrap() error {
+	return e.err
+}
+
+// PublicMessage returns the message of the error which is safe to show to clients, made of the messages of the
+// secondary errors and other errors of the application but not of internal causes. Internal errors have no details.
+func PublicMessage(err error) string {
+	class := ErrorClass(err)
+	if class == ErrInternal {
+		return class.Error()
+	}
+	if message := publicMessage(err); message != "" {
+		return message
+	}
+	return class.Error()
+}
+
+func publicMessage(err error) string {
+	switch err := err.(type) {
+	case nil, *internalError:
+		return ""
+	case *ValidationError:
+		return err.Error()
+	case *Error:
+		if err.class == nil {
+			// the class is already shown by the status
+			return ""
+		}
+		return err.message
+	case interface{ Unwrap() []error }:
+		var messages []string
+		for _, err := range err.Unwrap() {
+			if message := publicMessage(err); message != "" {
+				messages = append(messages, message)
+			}
+		}
+		return strings.Join(messages, ": ")
+	}
+
+	cause := errors.Unwrap(err)
+	if cause == nil {
+		return err.Error()
+	}
+	// wrapping errors prefix the message of their cause, others replace it and are not shown
+	message, causeMessage := err.Error(), cause.Error()
+	if !strings.HasSuffix(message, causeMessage) {
+		return ""
+	}
+	prefix := strings.TrimSuffix(message, causeMessage)
+	causeMessage = publicMessage(cause)
+	if causeMessage == "" {
+		return strings.TrimSuffix(prefix, ": ")
+	}
+	return prefix + causeMessage
+}
+
+// uniqueViolations are the errors of violations of unique indexes by their name
+var uniqueViolations = map[string]error{
+	"idx_user_emails_email": ErrEmailTaken,
+}
+
+// DBError converts gorm errors to internal errors, the errors of the database are only internal causes
 func DBError(err error) error {
+	var pgErr *pgconn.PgError
 	switch {
 	case err == nil:
 		return nil
+	case errors.As(err, &pgErr) && pgErr.Code == "23505": // unique_violation
+		if violation, ok := uniqueViolations[pgErr.ConstraintName]; ok {
+			return violation
+		}
+		return errors.Join(ErrConflict, Internal(err))
+	case errors.As(err, &pgErr) && (pgErr.Code == "23514" || // check_violation, including domains
+		pgErr.Code == "23502" || // not_null_violation
+		pgErr.Code == "23503" || // foreign_key_violation
+		pgErr.Code == "22001"): // string_data_right_truncation
+		return errors.Join(ErrConstraintViolated, Internal(err))
+	case errors.Is(err, ErrNotFound),
+		errors.Is(err, ErrBadRequest),
+		errors.Is(err, ErrUnauthorized),
+		errors.Is(err, ErrForbidden),
+		errors.Is(err, ErrPreconditionFailed),
+		errors.Is(err, ErrConflict),
+		errors.Is(err, ErrUnprocessable),
+		errors.Is(err, ErrInternal):
+		// already converted, e.g. by query builders
+		return err
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errors.Join(ErrConflict, Internal(err))
 	case errors.Is(err, gorm.ErrCheckConstraintViolated),
 		errors.Is(err, gorm.ErrForeignKeyViolated):
-		return errors.Join(ErrBadRequest, err)
+		return errors.Join(ErrConstraintViolated, Internal(err))
 	case errors.Is(err, gorm.ErrRecordNotFound):
-		return errors.Join(ErrNotFound, err)
+		return errors.Join(ErrNotFound, Internal(err))
 	case errors.Is(err, gorm.ErrDryRunModeUnsupported),
 		errors.Is(err, gorm.ErrEmptySlice),
 		errors.Is(err, gorm.ErrInvalidDB),
-		errors.Is(err, gorm.ErrDuplicatedKey),
 		errors.Is(err, gorm.ErrInvalidData),
 		errors.Is(err, gorm.ErrInvalidField),
 		errors.Is(err, gorm.ErrInvalidTransaction),
@@ -55,5 +240,5 @@ func DBError(err error) error {
 		errors.Is(err, gorm.ErrUnsupportedRelation):
 		return errors.Join(ErrInternal, err)
 	}
-	return ErrDBUnhandled
+	return errors.Join(ErrDBUnhandled, err)
 }
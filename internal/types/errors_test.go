@@ -0,0 +1,133 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_ErrorCodeAndPublicMessage(t *testing.T) {
+	dbErr := &pgconn.PgError{Code: "23514", Message: `value for domain phone_domain violates check constraint "phone_domain_check"`}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantClass   error
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "Primary Case",
+			err:         ErrForbidden,
+			wantClass:   ErrForbidden,
+			wantCode:    "forbidden",
+			wantMessage: "forbidden",
+		},
+		{
+			name:        "Secondary Case",
+			err:         ErrEmailTaken,
+			wantClass:   ErrConflict,
+			wantCode:    "email_taken",
+			wantMessage: "email is already taken",
+		},
+		{
+			name:        "Joined Details Case",
+			err:         errors.Join(ErrBadRequest, errors.New("first_name can't be removed")),
+			wantClass:   ErrBadRequest,
+			wantCode:    "bad_request",
+			wantMessage: "first_name can't be removed",
+		},
+		{
+			name:        "Wrapped Case",
+			err:         fmt.Errorf("operation 1: %w", errors.Join(ErrInvalidPatch, errors.New("value is required"))),
+			wantClass:   ErrBadRequest,
+			wantCode:    "invalid_patch",
+			wantMessage: "operation 1: invalid patch: value is required",
+		},
+		{
+			name:        "Internal Cause Case",
+			err:         DBError(dbErr),
+			wantClass:   ErrUnprocessable,
+			wantCode:    "constraint_violated",
+			wantMessage: "data violates a constraint",
+		},
+		{
+			name:        "Not Found Cause Case",
+			err:         DBError(gorm.ErrRecordNotFound),
+			wantClass:   ErrNotFound,
+			wantCode:    "not_found",
+			wantMessage: "not found",
+		},
+		{
+			name:        "Internal Case",
+			err:         errors.Join(ErrDataCorrupted, errors.New("unexpected end of JSON input")),
+			wantClass:   ErrInternal,
+			wantCode:    "internal",
+			wantMessage: "internal error",
+		},
+		{
+			name:        "Unknown Case",
+			err:         errors.New("connection refused"),
+			wantClass:   ErrInternal,
+			wantCode:    "internal",
+			wantMessage: "internal error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantClass, ErrorClass(tt.err))
+			assert.Equal(t, tt.wantCode, ErrorCode(tt.err))
+			assert.Equal(t, tt.wantMessage, PublicMessage(tt.err))
+		})
+	}
+}
+
+func Test_DBError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "Email Unique Violation Case",
+			err:     &pgconn.PgError{Code: "23505", ConstraintName: "idx_user_emails_email"},
+			wantErr: ErrEmailTaken,
+		},
+		{
+			name:    "Other Unique Violation Case",
+			err:     &pgconn.PgError{Code: "23505", ConstraintName: "users_pkey"},
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "Duplicated Key Case",
+			err:     gorm.ErrDuplicatedKey,
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "Check Violation Case",
+			err:     &pgconn.PgError{Code: "23514"},
+			wantErr: ErrConstraintViolated,
+		},
+		{
+			name:    "Converted Case",
+			err:     ErrVersionMismatch,
+			wantErr: ErrVersionMismatch,
+		},
+		{
+			name:    "Unhandled Case",
+			err:     &pgconn.PgError{Code: "57014"},
+			wantErr: ErrDBUnhandled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, DBError(tt.err), tt.wantErr)
+		})
+	}
+}
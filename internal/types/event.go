@@ -0,0 +1,99 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+)
+
+type UserEventKind string
+
+const (
+	UserCreated     UserEventKind = "user.created"
+	UserUpdated     UserEventKind = "user.updated"
+	UserDeleted     UserEventKind = "user.deleted"
+	PasswordChanged UserEventKind = "user.password_changed"
+	LoginSucceeded  UserEventKind = "user.login_succeeded"
+	LoginFailed     UserEventKind = "user.login_failed"
+)
+
+// UserEvent is a domain event of a user, which is written to the outbox with the change it describes and published
+// to other systems at least once. Events only refer to the versions of users, the data has to be read by their ids.
+type UserEvent struct {
+	// ID identifies the event, consumers can skip events they have seen already
+	ID     uuid.UUID     `json:"id"`
+	Kind   UserEventKind `json:"kind"`
+	UserID uuid.UUID     `json:"user_id"`
+	// VersionID is the created version of created and updated users and of changed passwords, uuid.Nil otherwise
+	VersionID uuid.UUID `json:"version_id"`
+	// PreviousVersionID is the version of updated users that was the latest one before, uuid.Nil otherwise
+	PreviousVersionID uuid.UUID `json:"previous_version_id"`
+	// Email is the email logins were tried with, the user id is uuid.Nil if no user has it
+	Email      string    `json:"email,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Scan reads events stored as JSON
+func (e *UserEvent) Scan(value any) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	}
+	return errors.Newf("unsupported type for UserEvent: %T", value)
+}
+
+// Value stores events as JSON
+func (e UserEvent) Value() (driver.Value, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func newUserEvent(kind UserEventKind, userID uuid.UUID) UserEvent {
+	return UserEvent{ID: uuid.New(), Kind: kind, UserID: userID, OccurredAt: now()}
+}
+
+// NewUserCreated is the event of the first version of a user
+func NewUserCreated(user User) UserEvent {
+	event := newUserEvent(UserCreated, user.ID)
+	event.VersionID = user.VersionID
+	return event
+}
+
+// NewUserUpdated is the event of a new version of a user
+func NewUserUpdated(user User, previousVersionID uuid.UUID) UserEvent {
+	event := newUserEvent(UserUpdated, user.ID)
+	event.VersionID, event.PreviousVersionID = user.VersionID, previousVersionID
+	return event
+}
+
+func NewUserDeleted(id uuid.UUID) UserEvent {
+	return newUserEvent(UserDeleted, id)
+}
+
+// NewPasswordChanged is the event of a new version of a user with another password than the previous version
+func NewPasswordChanged(user User) UserEvent {
+	event := newUserEvent(PasswordChanged, user.ID)
+	event.VersionID = user.VersionID
+	return event
+}
+
+func NewLoginSucceeded(user User) UserEvent {
+	event := newUserEvent(LoginSucceeded, user.ID)
+	event.Email = user.Email
+	return event
+}
+
+// NewLoginFailed is the event of a login with a wrong password, or with an unknown email if id is uuid.Nil
+func NewLoginFailed(id uuid.UUID, email string) UserEvent {
+	event := newUserEvent(LoginFailed, id)
+	event.Email = email
+	return event
+}
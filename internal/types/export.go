@@ -0,0 +1,83 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatZIP  ExportFormat = "zip"
+)
+
+func (f ExportFormat) IsValid() bool {
+	return f == ExportFormatJSON || f == ExportFormatZIP
+}
+
+func (f ExportFormat) ContentType() string {
+	if f == ExportFormatZIP {
+		return "application/zip"
+	}
+	return "application/json"
+}
+
+// BulkExportFormat is the format of exports of many users, one row per user (or version)
+type BulkExportFormat string
+
+const (
+	BulkExportFormatCSV     BulkExportFormat = "csv"
+	BulkExportFormatNDJSON  BulkExportFormat = "ndjson"
+	BulkExportFormatParquet BulkExportFormat = "parquet"
+)
+
+func (f BulkExportFormat) IsValid() bool {
+	return f == BulkExportFormatCSV || f == BulkExportFormatNDJSON || f == BulkExportFormatParquet
+}
+
+func (f BulkExportFormat) ContentType() string {
+	switch f {
+	case BulkExportFormatCSV:
+		return "text/csv"
+	case BulkExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// UserExportFields are the fields of users in bulk exports, the password hash is never exported
+var UserExportFields = Fields{"id", "version_id", "is_latest_version", "first_name", "last_name", "email", "phone", "is_admin", "created_at", "updated_at"}
+
+type ExportStatus string
+
+const (
+	ExportStatusPending ExportStatus = "pending"
+	ExportStatusReady   ExportStatus = "ready"
+	ExportStatusFailed  ExportStatus = "failed"
+)
+
+// UserExport contains all the data held about a user (subject access request)
+type UserExport struct {
+	GeneratedAt time.Time
+	User        User
+	Versions    []User
+	Addresses   []UserAddress
+	// Events are the audit log of the user
+	Events []UserEvent
+}
+
+// ExportJob keeps track of an export which is generated asynchronously
+type ExportJob struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Format    ExportFormat
+	Status    ExportStatus
+	CreatedAt time.Time
+}
+
+func (j *ExportJob) FileName() string {
+	return "user-" + j.UserID.String() + "." + string(j.Format)
+}
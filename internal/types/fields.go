@@ -0,0 +1,33 @@
+package types
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Fields is a list of fields of an entity, e.g. to limit the fields of responses or to name related data to embed
+type Fields []string
+
+// ParseFields parses a comma separated list of fields, validating them against the allowed fields
+func ParseFields(s string, allowed Fields) (Fields, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var fields Fields
+	for _, field := range strings.Split(s, ",") {
+		if !allowed.Contains(field) {
+			return nil, errors.Newf("unknown field %q", field)
+		}
+		if !fields.Contains(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func (f Fields) Contains(field string) bool {
+	return slices.Contains(f, field)
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseFields(t *testing.T) {
+	allowed := Fields{"id", "first_name", "last_name"}
+
+	tests := []struct {
+		name    string
+		fields  string
+		want    Fields
+		wantErr bool
+	}{
+		{
+			name:   "Empty Case",
+			fields: "",
+			want:   nil,
+		},
+		{
+			name:   "Multiple Fields Case",
+			fields: "last_name,id",
+			want:   Fields{"last_name", "id"},
+		},
+		{
+			name:   "Duplicate Field Case",
+			fields: "id,id",
+			want:   Fields{"id"},
+		},
+		{
+			name:    "Unknown Field Case",
+			fields:  "id,password_hash",
+			wantErr: true,
+		},
+		{
+			name:    "Empty Field Case",
+			fields:  "id,",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFields(tt.fields, allowed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseFields() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Filter expressions have the form `field:operator:value`, conditions are combined with `,` (AND) and
+// `|` (OR), where OR binds stronger, e.g. `last_name:prefix:Mü|first_name:prefix:Mü,created_at:gte:2024-01-01`.
+// Within values `\` escapes the next character so that `,`, `|`, `;` and `\` can be used literally.
+// The `in` operator takes a `;` separated list of values.
+
+type FilterOperator string
+
+const (
+	FilterEq       FilterOperator = "eq"
+	FilterNe       FilterOperator = "ne"
+	FilterGt       FilterOperator = "gt"
+	FilterGte      FilterOperator = "gte"
+	FilterLt       FilterOperator = "lt"
+	FilterLte      FilterOperator = "lte"
+	FilterIn       FilterOperator = "in"
+	FilterPrefix   FilterOperator = "prefix"
+	FilterSuffix   FilterOperator = "suffix"
+	FilterContains FilterOperator = "contains"
+)
+
+// FilterType is the type of a filterable field, it decides how values are parsed and which operators are allowed
+type FilterType int
+
+const (
+	FilterString FilterType = iota
+	FilterUUID
+	FilterTime
+	FilterBool
+	FilterFloat
+)
+
+var filterOperators = map[FilterType][]FilterOperator{
+	FilterString: {FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte, FilterIn, FilterPrefix, FilterSuffix, FilterContains},
+	FilterUUID:   {FilterEq, FilterNe, FilterIn},
+	FilterTime:   {FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte},
+	FilterBool:   {FilterEq, FilterNe},
+	FilterFloat:  {FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte},
+}
+
+// maxFilterConditions limits the size of the generated queries
+const maxFilterConditions = 20
+
+// FilterFields is the allow-list of the fields of an entity which can be filtered on
+type FilterFields map[string]FilterType
+
+type FilterCondition struct {
+	Field    string
+	Operator FilterOperator
+	// Value is the parsed value, a []any for FilterIn
+	Value any
+}
+
+// Filter is an AND of ORs of conditions
+type Filter [][]FilterCondition
+
+// ParseFilter parses a filter expression, validating the fields, operators and values against the given fields
+func ParseFilter(s string, fields FilterFields) (Filter, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	p := filterParser{s: s, fields: fields}
+	var filter Filter
+	count := 0
+	for {
+		var or []FilterCondition
+		for {
+			if count++; count > maxFilterConditions {
+				return nil, errors.Join(ErrInvalidFilter, errors.Newf("more than %d conditions", maxFilterConditions))
+			}
+			c, err := p.parseCondition()
+			if err != nil {
+				return nil, errors.Join(ErrInvalidFilter, err)
+			}
+			or = append(or, c)
+			if !p.consume('|') {
+				break
+			}
+		}
+		filter = append(filter, or)
+		if p.pos == len(p.s) {
+			return filter, nil
+		}
+		if !p.consume(',') {
+			return nil, errors.Join(ErrInvalidFilter, errors.Newf("unexpected %q at position %d", p.s[p.pos], p.pos))
+		}
+	}
+}
+
+// And returns a filter matching both filters
+func (f Filter) And(other Filter) Filter {
+	return append(append(Filter{}, f...), other...)
+}
+
+// EqualFilter turns exact-equality conditions, e.g. from Mappable.ToMap, into a filter
+func EqualFilter(conditions map[string]any) Filter {
+	var filter Filter
+	for field, value := range conditions {
+		filter = append(filter, []FilterCondition{{Field: field, Operator: FilterEq, Value: value}})
+	}
+	return filter
+}
+
+type filterParser struct {
+	s      string
+	pos    int
+	fields FilterFields
+}
+
+func (p *filterParser) consume(ch byte) bool {
+	if p.pos < len(p.s) && p.s[p.pos] == ch {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// parseName reads a field or operator name up to the next colon
+func (p *filterParser) parseName(what string) (string, error) {
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] == '_' || p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') {
+		p.pos++
+	}
+	name := p.s[start:p.pos]
+	if name == "" || !p.consume(':') {
+		return "", errors.Newf("expected %s followed by ':' at position %d", what, start)
+	}
+	return name, nil
+}
+
+// parseValue reads an escaped value up to the next unescaped ',' or '|', split at unescaped ';'
+func (p *filterParser) parseValue() ([]string, error) {
+	var values []string
+	var b strings.Builder
+	for ; p.pos < len(p.s); p.pos++ {
+		switch ch := p.s[p.pos]; ch {
+		case ',', '|':
+			return append(values, b.String()), nil
+		case ';':
+			values = append(values, b.String())
+			b.Reset()
+		case '\\':
+			if p.pos++; p.pos == len(p.s) {
+				return nil, errors.New("unterminated escape at the end")
+			}
+			b.WriteByte(p.s[p.pos])
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return append(values, b.String()), nil
+}
+
+func (p *filterParser) parseCondition() (FilterCondition, error) {
+	field, err := p.parseName("field")
+	if err != nil {
+		return FilterCondition{}, err
+	}
+	typ, ok := p.fields[field]
+	if !ok {
+		return FilterCondition{}, errors.Newf("unknown field %q", field)
+	}
+	op, err := p.parseName("operator")
+	if err != nil {
+		return FilterCondition{}, err
+	}
+	operator := FilterOperator(op)
+	if !operator.allowedFor(typ) {
+		return FilterCondition{}, errors.Newf("operator %q not supported for field %q", op, field)
+	}
+	values, err := p.parseValue()
+	if err != nil {
+		return FilterCondition{}, err
+	}
+
+	c := FilterCondition{Field: field, Operator: operator}
+	if operator == FilterIn {
+		list := make([]any, len(values))
+		for i, v := range values {
+			if list[i], err = parseFilterValue(typ, v); err != nil {
+				return FilterCondition{}, errors.Wrapf(err, "field %q", field)
+			}
+		}
+		c.Value = list
+	} else if c.Value, err = parseFilterValue(typ, strings.Join(values, ";")); err != nil {
+		return FilterCondition{}, errors.Wrapf(err, "field %q", field)
+	}
+	return c, nil
+}
+
+func (o FilterOperator) allowedFor(typ FilterType) bool {
+	for _, allowed := range filterOperators[typ] {
+		if o == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func parseFilterValue(typ FilterType, s string) (any, error) {
+	switch typ {
+	case FilterUUID:
+		return uuid.Parse(s)
+	case FilterTime:
+		return parseTime(s)
+	case FilterBool:
+		return strconv.ParseBool(s)
+	case FilterFloat:
+		return strconv.ParseFloat(s, 64)
+	default:
+		return s, nil
+	}
+}
+
+// FilterColumn translates a condition on a field into a SQL expression,
+// fields of related tables can wrap the condition in a subquery
+type FilterColumn func(c FilterCondition) clause.Expression
+
+// FilterColumns maps the filterable fields of an entity to their SQL expressions
+type FilterColumns map[string]FilterColumn
+
+// Column compares the field against a plain SQL column expression
+func Column(column string) FilterColumn {
+	return func(c FilterCondition) clause.Expression {
+		return c.Expression(column)
+	}
+}
+
+// Expression builds the comparison of the condition against the given SQL column expression,
+// the pattern operators are case-insensitive
+func (c FilterCondition) Expression(column string) clause.Expression {
+	var sql string
+	value := c.Value
+	switch c.Operator {
+	case FilterNe:
+		sql = " <> ?"
+	case FilterGt:
+		sql = " > ?"
+	case FilterGte:
+		sql = " >= ?"
+	case FilterLt:
+		sql = " < ?"
+	case FilterLte:
+		sql = " <= ?"
+	case FilterIn:
+		sql = " IN ?"
+	case FilterPrefix:
+		sql, value = ` ILIKE ? ESCAPE '\'`, escapeLike(c.Value)+"%"
+	case FilterSuffix:
+		sql, value = ` ILIKE ? ESCAPE '\'`, "%"+escapeLike(c.Value)
+	case FilterContains:
+		sql, value = ` ILIKE ? ESCAPE '\'`, "%"+escapeLike(c.Value)+"%"
+	default:
+		sql = " = ?"
+	}
+	return clause.Expr{SQL: column + sql, Vars: []any{value}}
+}
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value any) string {
+	s, _ := value.(string)
+	return likeReplacer.Replace(s)
+}
+
+// Apply adds the filter as conditions to the query, fields without a column are a programming error
+func (f Filter) Apply(tx *gorm.DB, columns FilterColumns) *gorm.DB {
+	for _, or := range f {
+		expressions := make([]clause.Expression, len(or))
+		for i, c := range or {
+			column, ok := columns[c.Field]
+			if !ok {
+				_ = tx.AddError(errors.Join(ErrInternal, errors.Newf("no column for filter field %q", c.Field)))
+				return tx
+			}
+			expressions[i] = column(c)
+		}
+		if len(expressions) == 1 {
+			tx = tx.Where(expressions[0])
+		} else {
+			tx = tx.Where(clause.Or(expressions...))
+		}
+	}
+	return tx
+}
@@ -0,0 +1,198 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var testFilterFields = FilterFields{
+	"id":         FilterUUID,
+	"last_name":  FilterString,
+	"is_admin":   FilterBool,
+	"created_at": FilterTime,
+}
+
+func Test_ParseFilter(t *testing.T) {
+	id := uuid.MustParse("b05a5d28-1a51-46a8-b35c-6e160a05a0ad")
+
+	tests := []struct {
+		name    string
+		filter  string
+		want    Filter
+		wantErr bool
+	}{
+		{
+			name:   "Empty Case",
+			filter: "",
+			want:   nil,
+		},
+		{
+			name:   "Single Case",
+			filter: "last_name:prefix:Mü",
+			want:   Filter{{{Field: "last_name", Operator: FilterPrefix, Value: "Mü"}}},
+		},
+		{
+			name:   "And Or Case",
+			filter: "last_name:eq:Doe|last_name:eq:Roe,created_at:gte:2024-01-01,is_admin:eq:true",
+			want: Filter{
+				{{Field: "last_name", Operator: FilterEq, Value: "Doe"}, {Field: "last_name", Operator: FilterEq, Value: "Roe"}},
+				{{Field: "created_at", Operator: FilterGte, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+				{{Field: "is_admin", Operator: FilterEq, Value: true}},
+			},
+		},
+		{
+			name:   "Time With Colons Case",
+			filter: "created_at:lt:2024-01-01T12:30:00Z",
+			want:   Filter{{{Field: "created_at", Operator: FilterLt, Value: time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)}}},
+		},
+		{
+			name:   "Escape Case",
+			filter: `last_name:contains:a\,b\|c\;d\\`,
+			want:   Filter{{{Field: "last_name", Operator: FilterContains, Value: `a,b|c;d\`}}},
+		},
+		{
+			name:   "Unescaped Semicolon Case",
+			filter: "last_name:eq:a;b",
+			want:   Filter{{{Field: "last_name", Operator: FilterEq, Value: "a;b"}}},
+		},
+		{
+			name:   "Empty Value Case",
+			filter: "last_name:eq:",
+			want:   Filter{{{Field: "last_name", Operator: FilterEq, Value: ""}}},
+		},
+		{
+			name:   "In Case",
+			filter: "id:in:" + id.String() + ";" + uuid.Nil.String(),
+			want:   Filter{{{Field: "id", Operator: FilterIn, Value: []any{id, uuid.Nil}}}},
+		},
+		{
+			name:    "Unknown Field Case",
+			filter:  "password_hash:eq:x",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Operator Case",
+			filter:  "last_name:like:x",
+			wantErr: true,
+		},
+		{
+			name:    "Operator Not Allowed Case",
+			filter:  "id:prefix:b05a",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Value Case",
+			filter:  "created_at:gte:yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "Missing Value Case",
+			filter:  "last_name:eq",
+			wantErr: true,
+		},
+		{
+			name:    "Trailing Separator Case",
+			filter:  "last_name:eq:Doe,",
+			wantErr: true,
+		},
+		{
+			name:    "Trailing Escape Case",
+			filter:  `last_name:eq:Doe\`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.filter, testFilterFields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseFilter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				assert.True(t, errors.Is(err, ErrBadRequest))
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ParseFilter_Limit(t *testing.T) {
+	filter := "last_name:eq:a"
+	for range maxFilterConditions {
+		filter += "|last_name:eq:a"
+	}
+	_, err := ParseFilter(filter, testFilterFields)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+// testDryRunDB returns a db which only builds the statements, so that the generated SQL can be tested without postgres
+func testDryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func Test_FilterApply(t *testing.T) {
+	db := testDryRunDB(t)
+
+	columns := FilterColumns{
+		"id":        Column("users.id"),
+		"last_name": Column("last_version.last_name"),
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		wantSQL  string
+		wantVars []any
+		wantErr  bool
+	}{
+		{
+			name:     "Empty Case",
+			filter:   nil,
+			wantSQL:  `SELECT * FROM "users"`,
+			wantVars: []any{},
+		},
+		{
+			name: "And Or Case",
+			filter: Filter{
+				{{Field: "last_name", Operator: FilterPrefix, Value: "M_ü%"}, {Field: "last_name", Operator: FilterNe, Value: "Doe"}},
+				{{Field: "id", Operator: FilterIn, Value: []any{uuid.Nil}}},
+			},
+			wantSQL:  `SELECT * FROM "users" WHERE (last_version.last_name ILIKE $1 ESCAPE '\' OR last_version.last_name <> $2) AND users.id IN ($3)`,
+			wantVars: []any{`M\_ü\%%`, "Doe", uuid.Nil},
+		},
+		{
+			name:    "Missing Column Case",
+			filter:  Filter{{{Field: "email", Operator: FilterEq, Value: "abc@xyz.com"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := tt.filter.Apply(db.Table("users"), columns).Find(&[]map[string]any{})
+			if (stmt.Error != nil) != tt.wantErr {
+				t.Errorf("Filter.Apply() error = %v, wantErr %v", stmt.Error, tt.wantErr)
+				return
+			} else if stmt.Error != nil {
+				assert.ErrorIs(t, stmt.Error, ErrInternal)
+				return
+			}
+			assert.Equal(t, tt.wantSQL, stmt.Statement.SQL.String())
+			assert.Equal(t, tt.wantVars, stmt.Statement.Vars)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"github.com/google/uuid"
+)
+
+type ImportFormat string
+
+const (
+	ImportFormatCSV    ImportFormat = "csv"
+	ImportFormatNDJSON ImportFormat = "ndjson"
+)
+
+func (f ImportFormat) IsValid() bool {
+	return f == ImportFormatCSV || f == ImportFormatNDJSON
+}
+
+// ImportOptions control how an import is applied
+type ImportOptions struct {
+	// DryRun only validates the rows, nothing is saved
+	DryRun bool
+	// Upsert saves rows with the email of an existing user as a new version of that user,
+	// otherwise such rows fail
+	Upsert bool
+}
+
+type ImportStatus string
+
+const (
+	ImportStatusCreated ImportStatus = "created"
+	ImportStatusUpdated ImportStatus = "updated"
+	ImportStatusFailed  ImportStatus = "failed"
+)
+
+// ImportRow is the outcome of a single row of an import, with dry runs it is the outcome the row would have
+type ImportRow struct {
+	// Row is the line of the row in the input
+	Row    int
+	Email  string
+	Status ImportStatus
+	// ID is the id of the created or updated user
+	ID    uuid.UUID
+	Error string
+}
+
+// ImportReport is the outcome of an import by row
+type ImportReport struct {
+	DryRun  bool
+	Created int
+	Updated int
+	Failed  int
+	Rows    []ImportRow
+}
+
+func (r *ImportReport) Add(row ImportRow) {
+	switch row.Status {
+	case ImportStatusCreated:
+		r.Created++
+	case ImportStatusUpdated:
+		r.Updated++
+	case ImportStatusFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 type Optional[T any] struct {
 	Value    T
 	HasValue bool
@@ -8,3 +10,14 @@ type Optional[T any] struct {
 func ToOptional[T any](value T) Optional[T] {
 	return Optional[T]{Value: value, HasValue: true}
 }
+
+// UnmarshalJSON sets the value of members present in the JSON object, absent members are never unmarshalled.
+// A null member sets the zero value, so T has to be a pointer to distinguish null from a zero value.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*o = ToOptional(value)
+	return nil
+}
@@ -0,0 +1,257 @@
+package types
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// JSONPatchOp is an operation of a JSON Patch (RFC 6902)
+type JSONPatchOp string
+
+const (
+	JSONPatchAdd     JSONPatchOp = "add"
+	JSONPatchRemove  JSONPatchOp = "remove"
+	JSONPatchReplace JSONPatchOp = "replace"
+	JSONPatchMove    JSONPatchOp = "move"
+	JSONPatchCopy    JSONPatchOp = "copy"
+	JSONPatchTest    JSONPatchOp = "test"
+)
+
+// JSONPatchOperation changes the value at the JSON Pointer (RFC 6901) path of a document,
+// from is the source of move and copy, the value is required for add, replace and test
+type JSONPatchOperation struct {
+	Op    JSONPatchOp
+	Path  string
+	From  string
+	Value Optional[any]
+}
+
+// ApplyJSONPatch applies the operations in order to the document, which is made of the values decoded
+// by encoding/json (maps, slices, strings, float64, bools and nil). The patch is atomic, the document is
+// only changed if all operations succeed, and a failed test operation fails the whole patch.
+func ApplyJSONPatch(document any, operations []JSONPatchOperation) (any, error) {
+	document = deepCopy(document)
+	for i, operation := range operations {
+		var err error
+		if document, err = operation.apply(document); err != nil {
+			return nil, errors.Wrapf(err, "operation %d (%s %s)", i, operation.Op, operation.Path)
+		}
+	}
+	return document, nil
+}
+
+func (o JSONPatchOperation) apply(document any) (any, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Op {
+	case JSONPatchAdd, JSONPatchReplace, JSONPatchTest:
+		if !o.Value.HasValue {
+			return nil, errors.Join(ErrInvalidPatch, errors.New("value is required"))
+		}
+	}
+
+	switch o.Op {
+	case JSONPatchAdd:
+		return addValue(document, path, deepCopy(o.Value.Value))
+	case JSONPatchRemove:
+		document, _, err = removeValue(document, path)
+		return document, err
+	case JSONPatchReplace:
+		if len(path) == 0 {
+			return deepCopy(o.Value.Value), nil
+		}
+		if document, _, err = removeValue(document, path); err != nil {
+			return nil, err
+		}
+		return addValue(document, path, deepCopy(o.Value.Value))
+	case JSONPatchMove, JSONPatchCopy:
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if o.Op == JSONPatchCopy {
+			if value, err = valueAt(document, from); err != nil {
+				return nil, err
+			}
+			value = deepCopy(value)
+		} else {
+			if len(path) > len(from) && isPrefix(from, path) {
+				return nil, errors.Join(ErrInvalidPatch, errors.New("can't move a value into itself"))
+			}
+			if document, value, err = removeValue(document, from); err != nil {
+				return nil, err
+			}
+		}
+		return addValue(document, path, value)
+	case JSONPatchTest:
+		value, err := valueAt(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, o.Value.Value) {
+			return nil, ErrPatchTestFailed
+		}
+		return document, nil
+	}
+	return nil, errors.Join(ErrInvalidPatch, errors.Newf("unknown op %q", o.Op))
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens, the empty pointer is the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Join(ErrInvalidPatch, errors.Newf("path %q has to start with /", pointer))
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// isPrefix reports whether the path is below the prefix
+func isPrefix(prefix, path []string) bool {
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses the token of an array element, - is the end of the array if allowed
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.Join(ErrInvalidPatch, errors.Newf("invalid array index %q", token))
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, errors.Join(ErrInvalidPatch, errors.Newf("array index %d out of bounds", index))
+	}
+	return index, nil
+}
+
+// childValue returns the member or element of a container referenced by the token
+func childValue(container any, token string) (any, error) {
+	switch container := container.(type) {
+	case map[string]any:
+		value, ok := container[token]
+		if !ok {
+			return nil, errors.Join(ErrInvalidPatch, errors.Newf("member %q does not exist", token))
+		}
+		return value, nil
+	case []any:
+		index, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		return container[index], nil
+	}
+	return nil, errors.Join(ErrInvalidPatch, errors.Newf("%q is not in an object or array", token))
+}
+
+func valueAt(document any, path []string) (any, error) {
+	for _, token := range path {
+		var err error
+		if document, err = childValue(document, token); err != nil {
+			return nil, err
+		}
+	}
+	return document, nil
+}
+
+// updateParent replaces the parent container of the last token of the path by the result of fn,
+// as inserting into and removing from arrays creates new slices
+func updateParent(document any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(document, path[0])
+	}
+
+	value, err := childValue(document, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if value, err = updateParent(value, path[1:], fn); err != nil {
+		return nil, err
+	}
+	switch document := document.(type) {
+	case map[string]any:
+		document[path[0]] = value
+	case []any:
+		index, _ := arrayIndex(path[0], len(document), false)
+		document[index] = value
+	}
+	return document, nil
+}
+
+func addValue(document any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(document, path, func(parent any, token string) (any, error) {
+		switch parent := parent.(type) {
+		case map[string]any:
+			parent[token] = value
+			return parent, nil
+		case []any:
+			index, err := arrayIndex(token, len(parent), true)
+			if err != nil {
+				return nil, err
+			}
+			return append(parent[:index], append([]any{value}, parent[index:]...)...), nil
+		}
+		return nil, errors.Join(ErrInvalidPatch, errors.Newf("%q is not in an object or array", token))
+	})
+}
+
+func removeValue(document any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.Join(ErrInvalidPatch, errors.New("can't remove the whole document"))
+	}
+	var removed any
+	document, err := updateParent(document, path, func(parent any, token string) (any, error) {
+		var err error
+		if removed, err = childValue(parent, token); err != nil {
+			return nil, err
+		}
+		switch parent := parent.(type) {
+		case map[string]any:
+			delete(parent, token)
+			return parent, nil
+		default:
+			index, _ := arrayIndex(token, len(parent.([]any)), false)
+			return append(parent.([]any)[:index:index], parent.([]any)[index+1:]...), nil
+		}
+	})
+	return document, removed, err
+}
+
+// deepCopy copies the containers of a document, so that patching never changes values shared with the input
+func deepCopy(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for key, member := range value {
+			copied[key] = deepCopy(member)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, element := range value {
+			copied[i] = deepCopy(element)
+		}
+		return copied
+	}
+	return value
+}
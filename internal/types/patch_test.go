@@ -0,0 +1,143 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OptionalUnmarshalJSON(t *testing.T) {
+	var patch struct {
+		Absent  Optional[*string] `json:"absent"`
+		Null    Optional[*string] `json:"null"`
+		Present Optional[*string] `json:"present"`
+	}
+	err := json.Unmarshal([]byte(`{"null":null,"present":"value"}`), &patch)
+	assert.NoError(t, err)
+
+	assert.False(t, patch.Absent.HasValue)
+	assert.True(t, patch.Null.HasValue)
+	assert.Nil(t, patch.Null.Value)
+	assert.True(t, patch.Present.HasValue)
+	assert.Equal(t, "value", *patch.Present.Value)
+}
+
+func Test_ApplyJSONPatch(t *testing.T) {
+	document := `{"first_name":"John","tags":["a","b"],"address":{"city":"Berlin","extra":null}}`
+
+	tests := []struct {
+		name       string
+		operations string
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "Add Member Case",
+			operations: `[{"op":"add","path":"/last_name","value":"Doe"}]`,
+			want:       `{"first_name":"John","last_name":"Doe","tags":["a","b"],"address":{"city":"Berlin","extra":null}}`,
+		},
+		{
+			name:       "Add Element Case",
+			operations: `[{"op":"add","path":"/tags/1","value":"c"},{"op":"add","path":"/tags/-","value":"d"}]`,
+			want:       `{"first_name":"John","tags":["a","c","b","d"],"address":{"city":"Berlin","extra":null}}`,
+		},
+		{
+			name:       "Remove Case",
+			operations: `[{"op":"remove","path":"/tags/0"},{"op":"remove","path":"/address/extra"}]`,
+			want:       `{"first_name":"John","tags":["b"],"address":{"city":"Berlin"}}`,
+		},
+		{
+			name:       "Replace Case",
+			operations: `[{"op":"replace","path":"/address/extra","value":"Apartment 1"}]`,
+			want:       `{"first_name":"John","tags":["a","b"],"address":{"city":"Berlin","extra":"Apartment 1"}}`,
+		},
+		{
+			name:       "Move Case",
+			operations: `[{"op":"move","from":"/address/city","path":"/city"}]`,
+			want:       `{"first_name":"John","city":"Berlin","tags":["a","b"],"address":{"extra":null}}`,
+		},
+		{
+			name:       "Copy Case",
+			operations: `[{"op":"copy","from":"/tags","path":"/address/tags"},{"op":"add","path":"/tags/-","value":"c"}]`,
+			want:       `{"first_name":"John","tags":["a","b","c"],"address":{"city":"Berlin","extra":null,"tags":["a","b"]}}`,
+		},
+		{
+			name:       "Escaped Path Case",
+			operations: `[{"op":"add","path":"/a~1b~0c","value":1}]`,
+			want:       `{"first_name":"John","a/b~c":1,"tags":["a","b"],"address":{"city":"Berlin","extra":null}}`,
+		},
+		{
+			name:       "Test Case",
+			operations: `[{"op":"test","path":"/address/extra","value":null},{"op":"test","path":"/tags","value":["a","b"]}]`,
+			want:       document,
+		},
+		{
+			name:       "Failed Test Case",
+			operations: `[{"op":"replace","path":"/first_name","value":"Jane"},{"op":"test","path":"/first_name","value":"John"}]`,
+			wantErr:    ErrPatchTestFailed,
+		},
+		{
+			name:       "Missing Member Case",
+			operations: `[{"op":"replace","path":"/last_name","value":"Doe"}]`,
+			wantErr:    ErrInvalidPatch,
+		},
+		{
+			name:       "Missing Value Case",
+			operations: `[{"op":"add","path":"/last_name"}]`,
+			wantErr:    ErrInvalidPatch,
+		},
+		{
+			name:       "Index Out Of Bounds Case",
+			operations: `[{"op":"add","path":"/tags/3","value":"c"}]`,
+			wantErr:    ErrInvalidPatch,
+		},
+		{
+			name:       "Leading Zero Index Case",
+			operations: `[{"op":"remove","path":"/tags/01"}]`,
+			wantErr:    ErrInvalidPatch,
+		},
+		{
+			name:       "Move Into Itself Case",
+			operations: `[{"op":"move","from":"/address","path":"/address/copy"}]`,
+			wantErr:    ErrInvalidPatch,
+		},
+		{
+			name:       "Unknown Op Case",
+			operations: `[{"op":"merge","path":"/first_name","value":"Jane"}]`,
+			wantErr:    ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc any
+			assert.NoError(t, json.Unmarshal([]byte(document), &doc))
+			var operations []struct {
+				Op    JSONPatchOp   `json:"op"`
+				Path  string        `json:"path"`
+				From  string        `json:"from"`
+				Value Optional[any] `json:"value"`
+			}
+			assert.NoError(t, json.Unmarshal([]byte(tt.operations), &operations))
+			patch := make([]JSONPatchOperation, len(operations))
+			for i, operation := range operations {
+				patch[i] = JSONPatchOperation(operation)
+			}
+
+			got, err := ApplyJSONPatch(doc, patch)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got error %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			gotJSON, _ := json.Marshal(got)
+			assert.JSONEq(t, tt.want, string(gotJSON))
+
+			// The input document is never changed
+			docJSON, _ := json.Marshal(doc)
+			assert.JSONEq(t, document, string(docJSON))
+		})
+	}
+}
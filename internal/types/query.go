@@ -15,15 +15,36 @@ type Pagination struct {
 
 type QueryParams struct {
 	Conditions Mappable
+	// Filter is applied by the datasources supporting it, as only they know the columns of the fields
+	Filter Filter
+	// Search is a fuzzy full text search, applied by the datasources supporting it
+	Search string
+	// Sort is applied by the datasources supporting it, as only they know the columns of the fields
+	Sort Sort
+	// Cursor continues a previous query of a datasource supporting pages, it replaces the offset
+	Cursor *Cursor
+	// Fields limits the fields to select for datasources supporting it, all fields are selected if nil
+	Fields Fields
+	// Total requests the number of all matching rows from datasources supporting pages
+	Total bool
 	Pagination
 }
 
-const defaultLimit = 10
+const (
+	defaultLimit = 10
+	MaxLimit     = 100
+)
 
-func Query(tx *gorm.DB, params QueryParams) *gorm.DB {
-	if params.Limit == 0 {
-		params.Limit = defaultLimit
+// limit returns the limit to apply, the default if none is given and at most MaxLimit
+func (p Pagination) limit() int {
+	if p.Limit <= 0 {
+		return defaultLimit
 	}
+	return min(p.Limit, MaxLimit)
+}
+
+func Query(tx *gorm.DB, params QueryParams) *gorm.DB {
+	params.Limit = params.limit()
 
 	if params.Conditions == nil {
 		return tx.Limit(params.Limit).Offset(params.Offset)
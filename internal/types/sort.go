@@ -0,0 +1,105 @@
+package types
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sort expressions are comma separated fields, a field prefixed by `-` is sorted descending,
+// e.g. `last_name,-created_at`
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+type Sort []SortKey
+
+// SortFields is the allow-list of the fields of an entity which can be sorted by,
+// the types are needed to decode the values of cursors
+type SortFields map[string]FilterType
+
+// ParseSort parses a sort expression, validating the fields against the given fields
+func ParseSort(s string, fields SortFields) (Sort, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var sort Sort
+	for _, field := range strings.Split(s, ",") {
+		key := SortKey{Field: field}
+		if strings.HasPrefix(field, "-") {
+			key = SortKey{Field: field[1:], Desc: true}
+		}
+		if _, ok := fields[key.Field]; !ok {
+			return nil, errors.Join(ErrInvalidSort, errors.Newf("unknown field %q", key.Field))
+		}
+		if sort.contains(key.Field) {
+			return nil, errors.Join(ErrInvalidSort, errors.Newf("duplicate field %q", key.Field))
+		}
+		sort = append(sort, key)
+	}
+	return sort, nil
+}
+
+func (s Sort) contains(field string) bool {
+	return slices.ContainsFunc(s, func(k SortKey) bool { return k.Field == field })
+}
+
+func (s Sort) String() string {
+	fields := make([]string, len(s))
+	for i, key := range s {
+		fields[i] = key.Field
+		if key.Desc {
+			fields[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// SortColumns maps the sortable fields of an entity to their SQL expressions
+type SortColumns map[string]clause.Expr
+
+// WithTiebreaker appends the ascending tiebreaker field, which has to be unique for a deterministic order
+func (s Sort) WithTiebreaker(tiebreaker string) Sort {
+	if s.contains(tiebreaker) {
+		return s
+	}
+	return append(s[:len(s):len(s)], SortKey{Field: tiebreaker})
+}
+
+// Reverse inverts the direction of all sort keys
+func (s Sort) Reverse() Sort {
+	reversed := make(Sort, len(s))
+	for i, key := range s {
+		reversed[i] = SortKey{Field: key.Field, Desc: !key.Desc}
+	}
+	return reversed
+}
+
+// Apply orders the query by the sort keys followed by the tiebreaker field (see WithTiebreaker),
+// fields without a column are a programming error
+func (s Sort) Apply(tx *gorm.DB, columns SortColumns, tiebreaker string) *gorm.DB {
+	s = s.WithTiebreaker(tiebreaker)
+
+	var columnsSQL []string
+	var vars []any
+	for _, key := range s {
+		column, ok := columns[key.Field]
+		if !ok {
+			_ = tx.AddError(errors.Join(ErrInternal, errors.Newf("no column for sort field %q", key.Field)))
+			return tx
+		}
+		if key.Desc {
+			columnsSQL = append(columnsSQL, column.SQL+" DESC")
+		} else {
+			columnsSQL = append(columnsSQL, column.SQL)
+		}
+		vars = append(vars, column.Vars...)
+	}
+	return tx.Order(clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(columnsSQL, ","), Vars: vars, WithoutParentheses: true}})
+}
@@ -0,0 +1,121 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSortFields = SortFields{"id": FilterUUID, "last_name": FilterString, "created_at": FilterTime}
+
+func Test_ParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    Sort
+		wantErr bool
+	}{
+		{
+			name: "Empty Case",
+			sort: "",
+			want: nil,
+		},
+		{
+			name: "Multiple Keys Case",
+			sort: "last_name,-created_at",
+			want: Sort{{Field: "last_name"}, {Field: "created_at", Desc: true}},
+		},
+		{
+			name:    "Unknown Field Case",
+			sort:    "password_hash",
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate Field Case",
+			sort:    "last_name,-last_name",
+			wantErr: true,
+		},
+		{
+			name:    "Empty Field Case",
+			sort:    "last_name,",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.sort, testSortFields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSort() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				assert.ErrorIs(t, err, ErrBadRequest)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SortApply(t *testing.T) {
+	db := testDryRunDB(t)
+
+	columns := SortColumns{
+		"id":         {SQL: "users.id"},
+		"last_name":  {SQL: "last_version.last_name"},
+		"created_at": {SQL: "users.created_at"},
+		"relevance":  {SQL: "similarity(?, last_version.last_name)", Vars: []any{"Doe"}},
+	}
+
+	tests := []struct {
+		name     string
+		sort     Sort
+		wantSQL  string
+		wantVars []any
+		wantErr  bool
+	}{
+		{
+			name:    "Empty Case",
+			sort:    nil,
+			wantSQL: `SELECT * FROM "users" ORDER BY users.id`,
+		},
+		{
+			name:    "Multiple Keys Case",
+			sort:    Sort{{Field: "last_name"}, {Field: "created_at", Desc: true}},
+			wantSQL: `SELECT * FROM "users" ORDER BY last_version.last_name,users.created_at DESC,users.id`,
+		},
+		{
+			name:     "Expression Case",
+			sort:     Sort{{Field: "relevance", Desc: true}},
+			wantSQL:  `SELECT * FROM "users" ORDER BY similarity($1, last_version.last_name) DESC,users.id`,
+			wantVars: []any{"Doe"},
+		},
+		{
+			name:    "Tiebreaker Included Case",
+			sort:    Sort{{Field: "id", Desc: true}},
+			wantSQL: `SELECT * FROM "users" ORDER BY users.id DESC`,
+		},
+		{
+			name:    "Missing Column Case",
+			sort:    Sort{{Field: "email"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := tt.sort.Apply(db.Table("users"), columns, "id").Find(&[]map[string]any{})
+			if (stmt.Error != nil) != tt.wantErr {
+				t.Errorf("Sort.Apply() error = %v, wantErr %v", stmt.Error, tt.wantErr)
+				return
+			} else if stmt.Error != nil {
+				assert.ErrorIs(t, stmt.Error, ErrInternal)
+				return
+			}
+			assert.Equal(t, tt.wantSQL, stmt.Statement.SQL.String())
+			if tt.wantVars != nil {
+				assert.Equal(t, tt.wantVars, stmt.Statement.Vars)
+			}
+		})
+	}
+}
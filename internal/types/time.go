@@ -0,0 +1,9 @@
+package types
+
+import "time"
+
+// now returns the current time in UTC truncated to microseconds,
+// as postgres only stores microseconds this keeps saved entities equal to the stored ones
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -1,6 +1,12 @@
 package types
 
-import "github.com/google/uuid"
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type User struct {
 	ID              uuid.UUID `gorm:"column:id"`
@@ -12,21 +18,40 @@ type User struct {
 	Phone           string    `gorm:"column:phone"`
 	IsAdmin         bool      `gorm:"column:is_admin"`
 	PasswordHash    string    `gorm:"column:password_hash"`
+	// CreatedAt is the creation time of the user itself
+	CreatedAt time.Time `gorm:"column:created_at"`
+	// UpdatedAt is the creation time of this version of the user
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+	// Relevance is the rank of the user in search results, zero otherwise
+	Relevance float64 `gorm:"column:relevance"`
+	// ExpectedVersionID makes saving conditional on it still being the latest version, uuid.Nil saves unconditionally
+	ExpectedVersionID uuid.UUID `gorm:"-" json:"-"`
+}
+
+// AfterFind normalizes the timestamps to UTC as the driver returns them in the local timezone
+func (u *User) AfterFind(_ *gorm.DB) error {
+	u.CreatedAt = u.CreatedAt.UTC()
+	u.UpdatedAt = u.UpdatedAt.UTC()
+	return nil
 }
 
 func (u *User) ToSave() (base, version map[string]any) {
 	u.VersionID = uuid.New()
 	u.IsLatestVersion = true
+	u.UpdatedAt = now()
 
 	if u.ID == uuid.Nil {
 		u.ID = uuid.New()
+		u.CreatedAt = u.UpdatedAt
 		base = map[string]any{
-			"id": u.ID,
+			"id":         u.ID,
+			"created_at": u.CreatedAt,
 		}
 	}
 
 	version = map[string]any{
 		"id":            u.VersionID,
+		"created_at":    u.UpdatedAt,
 		"user_id":       u.ID,
 		"first_name":    u.FirstName,
 		"last_name":     u.LastName,
@@ -39,15 +64,45 @@ func (u *User) ToSave() (base, version map[string]any) {
 	return base, version
 }
 
+// The patterns of the database domains of the users, see the v1 migration
+var (
+	EmailPattern = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
+	PhonePattern = regexp.MustCompile(`^\+\d{5,15}$`)
+)
+
+// UserInput is a user to create or overwrite as given by a client, the usecases hash its password.
+// The validate tags are the domains of the database, see the v1 migration, and the length bcrypt supports in bytes.
+type UserInput struct {
+	FirstName string `json:"first_name" validate:"required,max=255"`
+	LastName  string `json:"last_name" validate:"required,max=255"`
+	Email     string `json:"email" validate:"required,email_address"`
+	Phone     string `json:"phone" validate:"required,phone"`
+	IsAdmin   bool   `json:"is_admin"`
+	Password  string `json:"password" validate:"required,max_bytes=72"`
+}
+
+// Validate checks the input against the rules of its validate tags, failed rules are returned as a ValidationError
+func (u UserInput) Validate() error {
+	return validateStruct(u)
+}
+
+// UserPatch is a partial user, the set fields given by clients have the rules of UserInput
 type UserPatch struct {
 	ID           Optional[uuid.UUID]
 	VersionID    Optional[uuid.UUID]
-	FirstName    Optional[string]
-	LastName     Optional[string]
-	Email        Optional[string]
-	Phone        Optional[string]
+	FirstName    Optional[string] `json:"first_name" validate:"omitnil,min=1,max=255"`
+	LastName     Optional[string] `json:"last_name" validate:"omitnil,min=1,max=255"`
+	Email        Optional[string] `json:"email" validate:"omitnil,email_address"`
+	Phone        Optional[string] `json:"phone" validate:"omitnil,phone"`
 	IsAdmin      Optional[bool]
 	PasswordHash Optional[string]
+	// Password is the plain password to set, the usecases hash it into PasswordHash
+	Password Optional[string] `json:"password" validate:"omitnil,min=1,max_bytes=72"`
+}
+
+// Validate checks the set fields against the rules of their validate tags, failed rules are returned as a ValidationError
+func (u UserPatch) Validate() error {
+	return validateStruct(u)
 }
 
 func (u *UserPatch) ToMap() map[string]any {
@@ -79,6 +134,62 @@ func (u *UserPatch) ToMap() map[string]any {
 	return m
 }
 
+// UserFilterFields are the fields users can be filtered on, city and zip_code match any of the user's addresses
+var UserFilterFields = FilterFields{
+	"id":            FilterUUID,
+	"version_id":    FilterUUID,
+	"first_name":    FilterString,
+	"last_name":     FilterString,
+	"email":         FilterString,
+	"phone":         FilterString,
+	"is_admin":      FilterBool,
+	"created_at":    FilterTime,
+	"updated_at":    FilterTime,
+	"street":        FilterString,
+	"street_number": FilterString,
+	"zip_code":      FilterString,
+	"city":          FilterString,
+}
+
+// UserFields are the fields of users responses can be limited to
+var UserFields = Fields{"id", "version_id", "first_name", "last_name", "email", "phone", "created_at", "updated_at"}
+
+// UserExpansions are the related data which can be embedded in users responses. There are no sessions to embed,
+// as the tokens are stateless.
+var UserExpansions = Fields{"versions", "addresses"}
+
+// SortValue returns the value of a field of UserSortFields for cursors
+func (u User) SortValue(field string) any {
+	switch field {
+	case "id":
+		return u.ID
+	case "first_name":
+		return u.FirstName
+	case "last_name":
+		return u.LastName
+	case "email":
+		return u.Email
+	case "created_at":
+		return u.CreatedAt
+	case "updated_at":
+		return u.UpdatedAt
+	case "relevance":
+		return u.Relevance
+	}
+	return nil
+}
+
+// UserSortFields are the fields users can be sorted by, relevance only for searches
+var UserSortFields = SortFields{
+	"id":         FilterUUID,
+	"first_name": FilterString,
+	"last_name":  FilterString,
+	"email":      FilterString,
+	"created_at": FilterTime,
+	"updated_at": FilterTime,
+	"relevance":  FilterFloat,
+}
+
 func (u *User) ApplyPatch(p UserPatch) {
 	if p.FirstName.HasValue {
 		u.FirstName = p.FirstName.Value
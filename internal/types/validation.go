@@ -0,0 +1,157 @@
+package types
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/go-playground/validator/v10"
+)
+
+// embeddedField is the name of embedded structs in the namespaces of the validator, it is not part of the JSON pointers
+const embeddedField = "~"
+
+// patternValidations are the rules of the patterns of the database domains
+var patternValidations = map[string]*regexp.Regexp{
+	"email_address": EmailPattern,
+	"phone":         PhonePattern,
+	"zip_code":      ZipCodePattern,
+	"street_number": StreetNumberPattern,
+}
+
+// validate is the validator of the validate tags of the types, e.g. of UserInput, with the rules of RegisterValidations
+var validate = validator.New(validator.WithRequiredStructEnabled())
+
+func init() {
+	RegisterValidations(validate)
+}
+
+// FieldError is the failure of a validation rule of a field of a request
+type FieldError struct {
+	// Pointer is the JSON Pointer (RFC 6901) of the field, or the name of a query parameter
+	Pointer string
+	// Rule is the name of the rule, e.g. required, max or phone
+	Rule string
+	// Param is the parameter of the rule, e.g. the maximum length of max
+	Param string
+	// Kind is the kind of the field, e.g. to tell lengths of strings and sizes of arrays apart
+	Kind reflect.Kind
+}
+
+// ValidationError is the failure of the validation of the fields of a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError converts the errors of the validator to errors of the fields by their JSON Pointers
+func NewValidationError(errs validator.ValidationErrors) *ValidationError {
+	fields := make([]FieldError, len(errs))
+	for i, fieldErr := range errs {
+		fields[i] = FieldError{
+			Pointer: fieldPointer(fieldErr.Namespace()),
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Kind:    fieldErr.Kind(),
+		}
+	}
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = field.Pointer + " (" + field.Rule + ")"
+	}
+	return ErrValidationFailed.Error() + ": " + strings.Join(fields, ", ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidationFailed
+}
+
+// RegisterValidations adds the rules of the database domains to the validator and names the fields by their JSON names
+func RegisterValidations(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" {
+			name = strings.SplitN(field.Tag.Get("form"), ",", 2)[0]
+		}
+		if name == "" && field.Anonymous {
+			return embeddedField
+		}
+		return name
+	})
+
+	for tag, pattern := range patternValidations {
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return pattern.MatchString(fl.Field().String())
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// max_bytes limits the length of strings in bytes rather than characters, e.g. of passwords for bcrypt
+	err := v.RegisterValidation("max_bytes", func(fl validator.FieldLevel) bool {
+		maxBytes, err := strconv.Atoi(fl.Param())
+		return err == nil && len(fl.Field().String()) <= maxBytes
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Fields of patches are validated by their value if they are set, unset fields are nil
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		if optional := field.Interface().(Optional[string]); optional.HasValue {
+			return &optional.Value
+		}
+		return (*string)(nil)
+	}, Optional[string]{})
+	// Members of merge patches are validated by their value, null and missing members are nil
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(Optional[*string]).Value
+	}, Optional[*string]{})
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(Optional[*bool]).Value
+	}, Optional[*bool]{})
+}
+
+// validateStruct checks the validate tags of the struct, failed rules are returned as a ValidationError
+func validateStruct(s any) error {
+	err := validate.Struct(s)
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return NewValidationError(validationErrs)
+	}
+	return err
+}
+
+// fieldPointer returns the JSON Pointer of a field by its namespace of the validator, e.g. SaveUser.operations[0].email
+// or [0].op of the elements of a validated slice
+func fieldPointer(namespace string) string {
+	names := strings.Split(namespace, ".")
+	if !strings.HasPrefix(namespace, "[") {
+		// The first name is the name of the validated struct
+		names = names[1:]
+	}
+	var pointer strings.Builder
+	for _, name := range names {
+		if name == embeddedField {
+			continue
+		}
+		// Elements of arrays and maps are suffixed with their index or key
+		for _, token := range strings.Split(strings.ReplaceAll(name, "]", ""), "[") {
+			if token != "" {
+				pointer.WriteString("/" + EscapePointerToken(token))
+			}
+		}
+	}
+	return pointer.String()
+}
+
+// EscapePointerToken escapes a reference token of a JSON Pointer (RFC 6901)
+func EscapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Validate(t *testing.T) {
+	valid := UserInput{FirstName: "John", LastName: "Doe", Email: "john@xyz.com", Phone: "+49123456789", Password: "password"}
+
+	tests := []struct {
+		name       string
+		value      interface{ Validate() error }
+		wantFields []FieldError
+	}{
+		{
+			name:  "Valid Input Case",
+			value: valid,
+		},
+		{
+			name:  "Domains Case",
+			value: UserInput{FirstName: strings.Repeat("a", 256), Email: "john@localhost", Phone: "0049123", Password: strings.Repeat("ü", 37)},
+			wantFields: []FieldError{
+				{Pointer: "/first_name", Rule: "max", Param: "255", Kind: reflect.String},
+				{Pointer: "/last_name", Rule: "required", Kind: reflect.String},
+				{Pointer: "/email", Rule: "email_address", Kind: reflect.String},
+				{Pointer: "/phone", Rule: "phone", Kind: reflect.String},
+				{Pointer: "/password", Rule: "max_bytes", Param: "72", Kind: reflect.String},
+			},
+		},
+		{
+			name:  "Empty Patch Case",
+			value: UserPatch{},
+		},
+		{
+			name:  "Patch Case",
+			value: UserPatch{FirstName: ToOptional(""), Email: ToOptional("john@xyz.com"), Phone: ToOptional("0049123"), PasswordHash: ToOptional("")},
+			wantFields: []FieldError{
+				{Pointer: "/first_name", Rule: "min", Param: "1", Kind: reflect.String},
+				{Pointer: "/phone", Rule: "phone", Kind: reflect.String},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.value.Validate()
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() = %v, want a validation error", err)
+			}
+			assert.Equal(t, tt.wantFields, validationErr.Fields)
+			assert.ErrorIs(t, err, ErrValidationFailed)
+		})
+	}
+}
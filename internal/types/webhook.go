@@ -0,0 +1,141 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook is a subscription of an endpoint to kinds of user events, the deliveries are signed with the secret
+type Webhook struct {
+	ID        uuid.UUID            `gorm:"column:id"`
+	URL       string               `gorm:"column:url"`
+	Events    Array[UserEventKind] `gorm:"column:events"`
+	Secret    string               `gorm:"column:secret"`
+	CreatedAt time.Time            `gorm:"column:created_at"`
+}
+
+// AfterFind normalizes the timestamps to UTC as the driver returns them in the local timezone
+func (w *Webhook) AfterFind(_ *gorm.DB) error {
+	w.CreatedAt = w.CreatedAt.UTC()
+	return nil
+}
+
+// ToSave sets the id and creation time of a new webhook, it reports whether the webhook is new
+func (w *Webhook) ToSave() bool {
+	if w.ID != uuid.Nil {
+		return false
+	}
+	w.ID = uuid.New()
+	w.CreatedAt = now()
+	return true
+}
+
+// NewWebhookSecret returns a random secret for webhooks created without one
+func NewWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Join(ErrInternal, err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func (w *Webhook) Subscribes(kind UserEventKind) bool {
+	return slices.Contains(w.Events, kind)
+}
+
+type WebhookDeliveryState string
+
+const (
+	// WebhookDeliveryPending deliveries are sent at their next attempt
+	WebhookDeliveryPending WebhookDeliveryState = "pending"
+	// WebhookDeliverySucceeded deliveries were answered with a 2xx status
+	WebhookDeliverySucceeded WebhookDeliveryState = "succeeded"
+	// WebhookDeliveryDead deliveries failed every attempt, they are only sent again if redelivered
+	WebhookDeliveryDead WebhookDeliveryState = "dead"
+)
+
+func (s WebhookDeliveryState) IsValid() bool {
+	return s == WebhookDeliveryPending || s == WebhookDeliverySucceeded || s == WebhookDeliveryDead
+}
+
+// WebhookDelivery is the delivery of an event to a webhook
+type WebhookDelivery struct {
+	ID        uuid.UUID            `gorm:"column:id"`
+	WebhookID uuid.UUID            `gorm:"column:webhook_id"`
+	Event     UserEvent            `gorm:"column:event"`
+	State     WebhookDeliveryState `gorm:"column:state"`
+	// Attempts counts the failed attempts since the delivery was created or redelivered
+	Attempts      int       `gorm:"column:attempts"`
+	NextAttemptAt time.Time `gorm:"column:next_attempt_at"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at"`
+	// Log are the attempts of the delivery, oldest first, only set if the delivery is read by its id
+	Log []WebhookAttempt `gorm:"-"`
+}
+
+// AfterFind normalizes the timestamps to UTC as the driver returns them in the local timezone
+func (d *WebhookDelivery) AfterFind(_ *gorm.DB) error {
+	d.NextAttemptAt = d.NextAttemptAt.UTC()
+	d.CreatedAt = d.CreatedAt.UTC()
+	d.UpdatedAt = d.UpdatedAt.UTC()
+	return nil
+}
+
+// NewWebhookDelivery is a pending delivery of the event to the webhook, which is due right away
+func NewWebhookDelivery(webhookID uuid.UUID, event UserEvent) WebhookDelivery {
+	t := now()
+	return WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     webhookID,
+		Event:         event,
+		State:         WebhookDeliveryPending,
+		NextAttemptAt: t,
+		CreatedAt:     t,
+		UpdatedAt:     t,
+	}
+}
+
+// WebhookDeliveryConditions are the conditions of queries of deliveries
+type WebhookDeliveryConditions struct {
+	WebhookID Optional[uuid.UUID]
+	State     Optional[WebhookDeliveryState]
+}
+
+func (c *WebhookDeliveryConditions) ToMap() map[string]any {
+	m := make(map[string]any)
+	if c.WebhookID.HasValue {
+		m["webhook_id"] = c.WebhookID.Value
+	}
+	if c.State.HasValue {
+		m["state"] = c.State.Value
+	}
+	return m
+}
+
+// WebhookAttempt is the log of an attempt to deliver an event to a webhook
+type WebhookAttempt struct {
+	ID          uuid.UUID `gorm:"column:id"`
+	DeliveryID  uuid.UUID `gorm:"column:delivery_id"`
+	AttemptedAt time.Time `gorm:"column:attempted_at"`
+	// StatusCode is the status of the response, zero if there was none
+	StatusCode int `gorm:"column:status_code"`
+	// Error describes why the attempt failed, empty if it succeeded
+	Error    string        `gorm:"column:error"`
+	Duration time.Duration `gorm:"column:duration"`
+}
+
+// AfterFind normalizes the timestamps to UTC as the driver returns them in the local timezone
+func (a *WebhookAttempt) AfterFind(_ *gorm.DB) error {
+	a.AttemptedAt = a.AttemptedAt.UTC()
+	return nil
+}
+
+func (a *WebhookAttempt) Succeeded() bool {
+	return a.Error == ""
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// AddressService reads and changes the addresses of users. Admins can access the addresses of all users by the id
+// of the user, every user can access their own addresses (the me variants).
+type AddressService struct {
+	getter  datasource.Getter[types.UserAddress]
+	querier datasource.Querier[types.UserAddress]
+	saver   datasource.Saver[types.UserAddress]
+	deleter datasource.Deleter[types.UserAddress]
+}
+
+func createAddressService(
+	getter datasource.Getter[types.UserAddress],
+	querier datasource.Querier[types.UserAddress],
+	saver datasource.Saver[types.UserAddress],
+	deleter datasource.Deleter[types.UserAddress],
+) *AddressService {
+	return &AddressService{
+		getter:  getter,
+		querier: querier,
+		saver:   saver,
+		deleter: deleter,
+	}
+}
+
+// Query returns the addresses of the user matching the params, conditions of the params are types.UserAddressPatch
+func (s *AddressService) Query(ctx context.Context, actor Actor, userID uuid.UUID, params types.QueryParams) ([]types.UserAddress, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return nil, err
+	}
+	return s.query(ctx, userID, params)
+}
+
+func (s *AddressService) QueryMe(ctx context.Context, actor Actor, params types.QueryParams) ([]types.UserAddress, error) {
+	return s.query(ctx, actor.ID, params)
+}
+
+func (s *AddressService) query(ctx context.Context, userID uuid.UUID, params types.QueryParams) ([]types.UserAddress, error) {
+	conditions := types.UserAddressPatch{}
+	if c, ok := params.Conditions.(*types.UserAddressPatch); ok && c != nil {
+		conditions = *c
+	}
+	conditions.UserID = types.ToOptional(userID)
+	params.Conditions = &conditions
+	return s.querier.Query(ctx, params)
+}
+
+// Create adds the address to the user, its id is ignored
+func (s *AddressService) Create(ctx context.Context, actor Actor, userID uuid.UUID, address types.UserAddress) (types.UserAddress, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.UserAddress{}, err
+	}
+	return s.create(ctx, userID, address)
+}
+
+func (s *AddressService) CreateMe(ctx context.Context, actor Actor, address types.UserAddress) (types.UserAddress, error) {
+	return s.create(ctx, actor.ID, address)
+}
+
+func (s *AddressService) create(ctx context.Context, userID uuid.UUID, address types.UserAddress) (types.UserAddress, error) {
+	address.ID, address.UserID = uuid.Nil, userID
+	return s.saver.Save(ctx, address)
+}
+
+func (s *AddressService) Get(ctx context.Context, actor Actor, userID, id uuid.UUID) (types.UserAddress, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.UserAddress{}, err
+	}
+	return s.getOwned(ctx, userID, id)
+}
+
+func (s *AddressService) GetMe(ctx context.Context, actor Actor, id uuid.UUID) (types.UserAddress, error) {
+	return s.getOwned(ctx, actor.ID, id)
+}
+
+// Update overwrites the address of the user
+func (s *AddressService) Update(ctx context.Context, actor Actor, userID, id uuid.UUID, address types.UserAddress) (types.UserAddress, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.UserAddress{}, err
+	}
+	return s.update(ctx, userID, id, address)
+}
+
+func (s *AddressService) UpdateMe(ctx context.Context, actor Actor, id uuid.UUID, address types.UserAddress) (types.UserAddress, error) {
+	return s.update(ctx, actor.ID, id, address)
+}
+
+func (s *AddressService) update(ctx context.Context, userID, id uuid.UUID, address types.UserAddress) (types.UserAddress, error) {
+	current, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return types.UserAddress{}, err
+	}
+	address.ID, address.UserID = current.ID, userID
+	return s.saver.Save(ctx, address)
+}
+
+// Patch applies the patch to the address of the user
+func (s *AddressService) Patch(ctx context.Context, actor Actor, userID, id uuid.UUID, patch types.UserAddressPatch) (types.UserAddress, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.UserAddress{}, err
+	}
+	return s.patch(ctx, userID, id, patch)
+}
+
+func (s *AddressService) PatchMe(ctx context.Context, actor Actor, id uuid.UUID, patch types.UserAddressPatch) (types.UserAddress, error) {
+	return s.patch(ctx, actor.ID, id, patch)
+}
+
+func (s *AddressService) patch(ctx context.Context, userID, id uuid.UUID, patch types.UserAddressPatch) (types.UserAddress, error) {
+	address, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return types.UserAddress{}, err
+	}
+	address.ApplyPatch(patch)
+	return s.saver.Save(ctx, address)
+}
+
+func (s *AddressService) Delete(ctx context.Context, actor Actor, userID, id uuid.UUID) error {
+	if err := actor.RequireAdmin(); err != nil {
+		return err
+	}
+	return s.delete(ctx, userID, id)
+}
+
+func (s *AddressService) DeleteMe(ctx context.Context, actor Actor, id uuid.UUID) error {
+	return s.delete(ctx, actor.ID, id)
+}
+
+func (s *AddressService) delete(ctx context.Context, userID, id uuid.UUID) error {
+	address, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return s.deleter.Delete(ctx, address.ID)
+}
+
+// getOwned gets the address and makes sure it belongs to the user
+func (s *AddressService) getOwned(ctx context.Context, userID, id uuid.UUID) (types.UserAddress, error) {
+	address, err := s.getter.Get(ctx, id)
+	if err != nil {
+		return address, err
+	}
+
+	// Addresses of other users are hidden rather than forbidden to not leak their existence
+	if address.UserID != userID {
+		return types.UserAddress{}, errors.Wrap(types.ErrNotFound, "address not found")
+	}
+
+	return address, nil
+}
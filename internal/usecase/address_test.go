@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// fakeAddresses is an in-memory datasource of addresses, queries only filter by user
+type fakeAddresses struct {
+	addresses map[uuid.UUID]types.UserAddress
+}
+
+func (f *fakeAddresses) Get(_ context.Context, id uuid.UUID) (types.UserAddress, error) {
+	if address, ok := f.addresses[id]; ok {
+		return address, nil
+	}
+	return types.UserAddress{}, types.ErrNotFound
+}
+
+func (f *fakeAddresses) Query(_ context.Context, params types.QueryParams) ([]types.UserAddress, error) {
+	conditions := params.Conditions.(*types.UserAddressPatch)
+	var addresses []types.UserAddress
+	for _, address := range f.addresses {
+		if address.UserID == conditions.UserID.Value {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
+
+func (f *fakeAddresses) Save(_ context.Context, address types.UserAddress) (types.UserAddress, error) {
+	if address.ID == uuid.Nil {
+		address.ID = uuid.New()
+	}
+	f.addresses[address.ID] = address
+	return address, nil
+}
+
+func (f *fakeAddresses) Delete(_ context.Context, id uuid.UUID) error {
+	delete(f.addresses, id)
+	return nil
+}
+
+func Test_AddressService(t *testing.T) {
+	user, admin := Actor{ID: testUser.ID}, Actor{ID: testAdmin.ID, IsAdmin: true}
+	address := types.UserAddress{ID: uuid.New(), UserID: testUser.ID, Kind: types.AddressKindHome}
+	otherAddress := types.UserAddress{ID: uuid.New(), UserID: testAdmin.ID, Kind: types.AddressKindHome}
+	patch := types.UserAddressPatch{Kind: types.ToOptional(types.AddressKindBilling)}
+
+	tests := []struct {
+		name    string
+		call    func(s *AddressService) (types.UserAddress, error)
+		wantErr error
+	}{
+		{
+			name: "Get Me Case",
+			call: func(s *AddressService) (types.UserAddress, error) {
+				return s.GetMe(context.Background(), user, address.ID)
+			},
+		},
+		{
+			name: "Get Admin Case",
+			call: func(s *AddressService) (types.UserAddress, error) {
+				return s.Get(context.Background(), admin, testUser.ID, address.ID)
+			},
+		},
+		{
+			name: "Get Self By ID Case",
+			call: func(s *AddressService) (types.UserAddress, error) {
+				return s.Get(context.Background(), user, testUser.ID, address.ID)
+			},
+			wantErr: types.ErrForbidden,
+		},
+		{
+			name: "Get Other Users Address Case",
+			call: func(s *AddressService) (types.UserAddress, error) {
+				return s.GetMe(context.Background(), user, otherAddress.ID)
+			},
+			wantErr: types.ErrNotFound,
+		},
+		{
+			name: "Create Me Case",
+			call: func(s *AddressService) (types.UserAddress, error) {
+				// the id and user of the address are ignored
+				return s.CreateMe(context.Background(), user, otherAddress)
+			},
+		},
+		{
+			name: "Update Me Case",
+			call: func(s *AddressService) (types.UserAddress, error) {
+				return s.UpdateMe(context.Background(), user, address.ID, types.UserAddress{UserID: testAdmin.ID, Kind: types.AddressKindHome})
+			},
+		},
+		{
+			name: "Patch Admin Case",
+			call: func(s *AddressService) (types.UserAddress, error) {
+				return s.Patch(context.Background(), admin, testUser.ID, address.ID, patch)
+			},
+		},
+		{
+			name: "Patch Other Users Address Case",
+			call: func(s *AddressService) (types.UserAddress, error) {
+				return s.Patch(context.Background(), admin, testUser.ID, otherAddress.ID, patch)
+			},
+			wantErr: types.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAddresses{addresses: map[uuid.UUID]types.UserAddress{address.ID: address, otherAddress.ID: otherAddress}}
+			got, err := tt.call(createAddressService(f, f, f, f))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Len(t, f.addresses, 2)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, testUser.ID, got.UserID)
+				assert.NotEqual(t, otherAddress.ID, got.ID)
+			}
+		})
+	}
+
+	t.Run("Query And Delete Case", func(t *testing.T) {
+		f := &fakeAddresses{addresses: map[uuid.UUID]types.UserAddress{address.ID: address, otherAddress.ID: otherAddress}}
+		s := createAddressService(f, f, f, f)
+
+		// the query is limited to the user, whatever the conditions say
+		got, err := s.QueryMe(context.Background(), user, types.QueryParams{Conditions: &types.UserAddressPatch{UserID: types.ToOptional(testAdmin.ID)}})
+		assert.NoError(t, err)
+		assert.Equal(t, []types.UserAddress{address}, got)
+
+		_, err = s.Query(context.Background(), user, testUser.ID, types.QueryParams{})
+		assert.ErrorIs(t, err, types.ErrForbidden)
+
+		assert.ErrorIs(t, s.DeleteMe(context.Background(), user, otherAddress.ID), types.ErrNotFound)
+		assert.NoError(t, s.DeleteMe(context.Background(), user, address.ID))
+		assert.Len(t, f.addresses, 1)
+	})
+}
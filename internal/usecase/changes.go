@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// ChangesService reads the changes of users for syncing services, only admins can read them
+type ChangesService struct {
+	getter   datasource.UserChangesGetter
+	listener datasource.UserChangesListener
+}
+
+func createChangesService(getter datasource.UserChangesGetter, listener datasource.UserChangesListener) *ChangesService {
+	return &ChangesService{
+		getter:   getter,
+		listener: listener,
+	}
+}
+
+// GetChanges returns at most limit changes made before until, after the cursor if given
+func (s *ChangesService) GetChanges(ctx context.Context, actor Actor, since *types.Cursor, until time.Time, limit int) ([]types.UserChange, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return nil, err
+	}
+	return s.getter.GetChanges(ctx, since, until, limit)
+}
+
+// ListenChanges pushes the changes as they are committed until the context is done, see datasource.UserChangesListener
+func (s *ChangesService) ListenChanges(ctx context.Context, actor Actor) (<-chan types.UserChange, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return nil, err
+	}
+	return s.listener.ListenChanges(ctx)
+}
@@ -0,0 +1,14 @@
+package usecase
+
+import (
+	"go.uber.org/fx"
+)
+
+var FXUserServiceProvide = fx.Provide(
+	fx.Annotate(createUserService, fx.ParamTags(`name:"cachedUserGetter"`, "", "", `name:"cachedUserManyGetter"`, `name:"cachedUserSaver"`, `name:"cachedUserDeleter"`, `name:"cachedUserVersionedDeleter"`, `name:"cachedUserBatchMutator"`, `name:"cachedUserByEmailGetter"`, "", "", "")),
+	createChangesService,
+)
+
+var FXAddressServiceProvide = fx.Provide(createAddressService)
+
+var FXWebhookServiceProvide = fx.Provide(createWebhookService)
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"github.com/cockroachdb/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// HashPassword returns the bcrypt hash of the password, which has been validated with the rules of types.UserInput
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.Join(types.ErrInternal, err)
+	}
+	return string(hash), nil
+}
+
+// RehashPassword keeps the current hash if the password is unchanged, so that only changed passwords are reported
+// as types.PasswordChanged, otherwise it returns the hash of the password like HashPassword
+func RehashPassword(currentHash, password string) (string, error) {
+	if currentHash != "" && checkPassword(currentHash, password) == nil {
+		return currentHash, nil
+	}
+	return HashPassword(password)
+}
+
+// checkPassword fails with types.ErrUnauthorized if the password doesn't match the hash
+func checkPassword(hash, password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return errors.Join(types.ErrUnauthorized, err)
+	}
+	return nil
+}
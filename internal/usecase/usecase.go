@@ -1 +1,23 @@
+// Package usecase holds the business rules of the service independent of the transport,
+// the routers only map requests to the usecases and their results to responses
 package usecase
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// Actor is the authenticated user a usecase is executed for
+type Actor struct {
+	ID      uuid.UUID
+	IsAdmin bool
+}
+
+// RequireAdmin fails with types.ErrForbidden unless the actor is an admin
+func (a Actor) RequireAdmin() error {
+	if !a.IsAdmin {
+		return types.ErrForbidden
+	}
+	return nil
+}
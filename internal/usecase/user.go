@@ -0,0 +1,431 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// maxPatchAttempts is how often a patch without condition is applied to the latest version of a user,
+// before concurrent changes fail it with types.ErrVersionMismatch
+const maxPatchAttempts = 3
+
+// VersionCondition reports whether a write may be based on the latest version of a user,
+// e.g. by the entity tags of an If-Match header. A nil condition allows any version.
+type VersionCondition func(versionID uuid.UUID) bool
+
+// UserPatcher returns the patch of the latest version of a user
+type UserPatcher func(user types.User) (types.UserPatch, error)
+
+// UserService reads and changes users. Admins can change all users by id, every user can change themselves
+// (the me variants) without their admin flag. Every authenticated user can read users.
+type UserService struct {
+	getter              datasource.Getter[types.User]
+	latestGetter        datasource.Getter[types.User] // reads from the database, bypassing the cache
+	querier             datasource.PageQuerier[types.User]
+	manyGetter          datasource.ManyGetter[types.User]
+	saver               datasource.Saver[types.User]
+	deleter             datasource.Deleter[types.User]
+	versionedDeleter    datasource.VersionedDeleter[types.User]
+	batchMutator        datasource.UserBatchMutator
+	byEmailGetter       datasource.UserByEmailGetter
+	latestByEmailGetter datasource.UserByEmailGetter // reads from the database, bypassing the cache
+	eventSaver          datasource.UserEventSaver
+	historyGetter       datasource.HistoryGetter[types.User]
+	addressesGetter     datasource.ByUsersGetter[types.UserAddress]
+}
+
+func createUserService(
+	getter datasource.Getter[types.User],
+	latestGetter datasource.Getter[types.User],
+	querier datasource.PageQuerier[types.User],
+	manyGetter datasource.ManyGetter[types.User],
+	saver datasource.Saver[types.User],
+	deleter datasource.Deleter[types.User],
+	versionedDeleter datasource.VersionedDeleter[types.User],
+	batchMutator datasource.UserBatchMutator,
+	byEmailGetter datasource.UserByEmailGetter,
+	latestByEmailGetter datasource.UserByEmailGetter,
+	eventSaver datasource.UserEventSaver,
+	historyGetter datasource.HistoryGetter[types.User],
+	addressesGetter datasource.ByUsersGetter[types.UserAddress],
+) *UserService {
+	return &UserService{
+		getter:              getter,
+		latestGetter:        latestGetter,
+		querier:             querier,
+		manyGetter:          manyGetter,
+		saver:               saver,
+		deleter:             deleter,
+		versionedDeleter:    versionedDeleter,
+		batchMutator:        batchMutator,
+		byEmailGetter:       byEmailGetter,
+		latestByEmailGetter: latestByEmailGetter,
+		eventSaver:          eventSaver,
+		historyGetter:       historyGetter,
+		addressesGetter:     addressesGetter,
+	}
+}
+
+func (s *UserService) Get(ctx context.Context, id uuid.UUID) (types.User, error) {
+	return s.getter.Get(ctx, id)
+}
+
+func (s *UserService) Query(ctx context.Context, params types.QueryParams) (types.Page[types.User], error) {
+	return s.querier.QueryPage(ctx, params)
+}
+
+// GetMany returns the found users in the order of the ids and the ids of the missing ones, duplicated ids once
+func (s *UserService) GetMany(ctx context.Context, ids []uuid.UUID) (users []types.User, missing []uuid.UUID, err error) {
+	unique := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	found, err := s.manyGetter.GetMany(ctx, unique)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uuid.UUID]types.User, len(found))
+	for _, user := range found {
+		byID[user.ID] = user
+	}
+	users, missing = make([]types.User, 0, len(found)), []uuid.UUID{}
+	for _, id := range unique {
+		if user, ok := byID[id]; ok {
+			users = append(users, user)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return users, missing, nil
+}
+
+// UserRelations are the related data of users by their ids, nil unless requested
+type UserRelations struct {
+	Versions  map[uuid.UUID][]types.User
+	Addresses map[uuid.UUID][]types.UserAddress
+}
+
+// Relations returns the related data of the users requested by expand, which requires admin access as the history
+// and address endpoints do. The data of all users is fetched at once per kind.
+func (s *UserService) Relations(ctx context.Context, actor Actor, ids []uuid.UUID, expand types.Fields) (UserRelations, error) {
+	relations := UserRelations{}
+	if len(expand) == 0 {
+		return relations, nil
+	}
+	if err := actor.RequireAdmin(); err != nil {
+		return relations, err
+	}
+
+	if expand.Contains("versions") {
+		versions, err := s.historyGetter.GetHistories(ctx, ids)
+		if err != nil {
+			return relations, err
+		}
+		relations.Versions = make(map[uuid.UUID][]types.User, len(ids))
+		for _, version := range versions {
+			relations.Versions[version.ID] = append(relations.Versions[version.ID], version)
+		}
+	}
+
+	if expand.Contains("addresses") {
+		addresses, err := s.addressesGetter.GetByUsers(ctx, ids)
+		if err != nil {
+			return relations, err
+		}
+		relations.Addresses = make(map[uuid.UUID][]types.UserAddress, len(ids))
+		for _, address := range addresses {
+			relations.Addresses[address.UserID] = append(relations.Addresses[address.UserID], address)
+		}
+	}
+
+	return relations, nil
+}
+
+func (s *UserService) Create(ctx context.Context, actor Actor, input types.UserInput) (types.User, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.User{}, err
+	}
+	return s.save(ctx, uuid.Nil, input, nil)
+}
+
+// Register creates a user without authentication, registered users are never admins
+func (s *UserService) Register(ctx context.Context, input types.UserInput) (types.User, error) {
+	input.IsAdmin = false
+	return s.save(ctx, uuid.Nil, input, nil)
+}
+
+// Update overwrites the user with the input, only admins can update users by id
+func (s *UserService) Update(ctx context.Context, actor Actor, id uuid.UUID, input types.UserInput, condition VersionCondition) (types.User, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.User{}, err
+	}
+	return s.save(ctx, id, input, condition)
+}
+
+// UpdateMe overwrites the actor with the input, users who aren't admins can't make themselves admins
+func (s *UserService) UpdateMe(ctx context.Context, actor Actor, input types.UserInput, condition VersionCondition) (types.User, error) {
+	if !actor.IsAdmin && input.IsAdmin {
+		return types.User{}, types.ErrForbidden
+	}
+	return s.save(ctx, actor.ID, input, condition)
+}
+
+func (s *UserService) save(ctx context.Context, id uuid.UUID, input types.UserInput, condition VersionCondition) (types.User, error) {
+	if err := input.Validate(); err != nil {
+		return types.User{}, err
+	}
+	user := types.User{
+		ID:        id,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		Email:     input.Email,
+		Phone:     input.Phone,
+		IsAdmin:   input.IsAdmin,
+	}
+	var current types.User
+	if id != uuid.Nil {
+		var err error
+		if current, err = s.base(ctx, id, condition); err != nil {
+			return types.User{}, err
+		}
+		if condition != nil {
+			user.ExpectedVersionID = current.ExpectedVersionID
+		}
+	}
+	if err := s.checkEmail(ctx, id, input.Email); err != nil {
+		return types.User{}, err
+	}
+
+	var err error
+	if user.PasswordHash, err = RehashPassword(current.PasswordHash, input.Password); err != nil {
+		return types.User{}, err
+	}
+	return s.saver.Save(ctx, user)
+}
+
+// Patch applies the patch to the latest version of the user, only admins can patch users by id
+func (s *UserService) Patch(ctx context.Context, actor Actor, id uuid.UUID, patcher UserPatcher, condition VersionCondition) (types.User, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.User{}, err
+	}
+	return s.patchLatest(ctx, actor, id, patcher, condition)
+}
+
+// PatchMe applies the patch to the latest version of the actor, users who aren't admins can't patch their admin flag
+func (s *UserService) PatchMe(ctx context.Context, actor Actor, patcher UserPatcher, condition VersionCondition) (types.User, error) {
+	return s.patchLatest(ctx, actor, actor.ID, patcher, condition)
+}
+
+// patchLatest applies the patch to the latest version of the user. Without a condition the patch is applied again
+// if the user was changed concurrently, so that no change is lost.
+func (s *UserService) patchLatest(ctx context.Context, actor Actor, id uuid.UUID, patcher UserPatcher, condition VersionCondition) (types.User, error) {
+	for attempt := 1; ; attempt++ {
+		user, err := s.patch(ctx, actor, id, patcher, condition)
+		if condition == nil && attempt < maxPatchAttempts && errors.Is(err, types.ErrVersionMismatch) {
+			continue
+		}
+		return user, err
+	}
+}
+
+func (s *UserService) patch(ctx context.Context, actor Actor, id uuid.UUID, patcher UserPatcher, condition VersionCondition) (types.User, error) {
+	user, err := s.base(ctx, id, condition)
+	if err != nil {
+		return types.User{}, err
+	}
+	patch, err := patcher(user)
+	if err != nil {
+		return types.User{}, err
+	}
+	if !actor.IsAdmin && patch.IsAdmin.HasValue {
+		return types.User{}, types.ErrForbidden
+	}
+	if err := s.preparePatch(ctx, id, user.PasswordHash, &patch); err != nil {
+		return types.User{}, err
+	}
+	patched := user
+	patched.ApplyPatch(patch)
+	if patched == user {
+		// Patches which change nothing, e.g. empty or test-only ones, don't create a version
+		return user, nil
+	}
+	return s.saver.Save(ctx, patched)
+}
+
+// preparePatch validates the patch, checks its email and hashes its password, the current hash is kept if known
+// and the password is unchanged
+func (s *UserService) preparePatch(ctx context.Context, id uuid.UUID, currentHash string, patch *types.UserPatch) error {
+	if err := patch.Validate(); err != nil {
+		return err
+	}
+	if patch.Email.HasValue {
+		if err := s.checkEmail(ctx, id, patch.Email.Value); err != nil {
+			return err
+		}
+	}
+	if patch.Password.HasValue {
+		hash, err := RehashPassword(currentHash, patch.Password.Value)
+		if err != nil {
+			return err
+		}
+		patch.PasswordHash = types.ToOptional(hash)
+	}
+	return nil
+}
+
+// Delete deletes the user, only admins can delete users by id
+func (s *UserService) Delete(ctx context.Context, actor Actor, id uuid.UUID, condition VersionCondition) error {
+	if err := actor.RequireAdmin(); err != nil {
+		return err
+	}
+	return s.delete(ctx, id, condition)
+}
+
+// DeleteMe deletes the actor
+func (s *UserService) DeleteMe(ctx context.Context, actor Actor, condition VersionCondition) error {
+	return s.delete(ctx, actor.ID, condition)
+}
+
+func (s *UserService) delete(ctx context.Context, id uuid.UUID, condition VersionCondition) error {
+	if condition == nil {
+		return s.deleter.Delete(ctx, id)
+	}
+	base, err := s.base(ctx, id, condition)
+	if err != nil {
+		return err
+	}
+	return s.versionedDeleter.DeleteVersion(ctx, id, base.VersionID)
+}
+
+// base returns the latest version of the user a write is based on. It is read from the database, as the cache
+// might lag behind, and has to satisfy the condition if given. Saving the user is then conditional on the version
+// still being the latest one.
+func (s *UserService) base(ctx context.Context, id uuid.UUID, condition VersionCondition) (types.User, error) {
+	user, err := s.latestGetter.Get(ctx, id)
+	if err != nil {
+		return types.User{}, err
+	}
+	if condition != nil && !condition(user.VersionID) {
+		return types.User{}, types.ErrVersionMismatch
+	}
+	user.ExpectedVersionID = user.VersionID
+	return user, nil
+}
+
+// checkEmail fails with types.ErrEmailTaken if another user has the email, before the password is hashed,
+// which is slow by design. The unique index of the emails still rejects concurrent saves, see the v6 migration.
+func (s *UserService) checkEmail(ctx context.Context, id uuid.UUID, email string) error {
+	user, err := s.latestByEmailGetter.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, types.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case user.ID != id:
+		return types.ErrEmailTaken
+	}
+	return nil
+}
+
+// MutateBatch applies the operations like datasource.UserBatchMutator, invalid operations fail an atomic batch
+// with their index and otherwise only themselves
+func (s *UserService) MutateBatch(ctx context.Context, actor Actor, ops []types.UserOperation, atomic bool) ([]types.UserOperationResult, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return nil, err
+	}
+
+	results := make([]types.UserOperationResult, len(ops))
+	valid := make([]types.UserOperation, 0, len(ops))
+	indexes := make([]int, 0, len(ops))
+	for i, op := range ops {
+		if err := s.prepareOperation(ctx, &op); err != nil {
+			if atomic {
+				return nil, errors.Wrapf(err, "operation %d", i)
+			}
+			results[i] = types.UserOperationResult{Err: err}
+			continue
+		}
+		valid = append(valid, op)
+		indexes = append(indexes, i)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	validResults, err := s.batchMutator.MutateBatch(ctx, valid, atomic)
+	if err != nil && len(validResults) == 0 {
+		return nil, err
+	}
+	for k, result := range validResults {
+		results[indexes[k]] = result
+	}
+	return results, err
+}
+
+func (s *UserService) prepareOperation(ctx context.Context, op *types.UserOperation) error {
+	switch op.Kind {
+	case types.UserOperationCreate:
+		if err := op.Input.Validate(); err != nil {
+			return err
+		}
+		if err := s.checkEmail(ctx, uuid.Nil, op.Input.Email); err != nil {
+			return err
+		}
+		hash, err := HashPassword(op.Input.Password)
+		if err != nil {
+			return err
+		}
+		op.User = types.User{
+			FirstName:    op.Input.FirstName,
+			LastName:     op.Input.LastName,
+			Email:        op.Input.Email,
+			Phone:        op.Input.Phone,
+			IsAdmin:      op.Input.IsAdmin,
+			PasswordHash: hash,
+		}
+	case types.UserOperationPatch:
+		// Unchanged passwords keep their hash as in single patches, unknown users fail when the batch is applied
+		var currentHash string
+		if op.Patch.Password.HasValue {
+			user, err := s.latestGetter.Get(ctx, op.ID)
+			if err != nil && !errors.Is(err, types.ErrNotFound) {
+				return err
+			}
+			currentHash = user.PasswordHash
+		}
+		return s.preparePatch(ctx, op.ID, currentHash, &op.Patch)
+	}
+	return nil
+}
+
+// Login returns the user with the email if the password matches, types.ErrUnauthorized otherwise.
+// Every login is recorded as types.LoginSucceeded or types.LoginFailed.
+func (s *UserService) Login(ctx context.Context, email, password string) (types.User, error) {
+	user, err := s.byEmailGetter.GetByEmail(ctx, email)
+	if errors.Is(err, types.ErrNotFound) {
+		return types.User{}, errors.CombineErrors(err, s.saveEvent(ctx, types.NewLoginFailed(uuid.Nil, email)))
+	} else if err != nil {
+		return types.User{}, err
+	}
+	if err := checkPassword(user.PasswordHash, password); err != nil {
+		return types.User{}, errors.CombineErrors(err, s.saveEvent(ctx, types.NewLoginFailed(user.ID, email)))
+	}
+	if err := s.saveEvent(ctx, types.NewLoginSucceeded(user)); err != nil {
+		return types.User{}, err
+	}
+	return user, nil
+}
+
+func (s *UserService) saveEvent(ctx context.Context, event types.UserEvent) error {
+	return s.eventSaver.SaveEvents(ctx, []types.UserEvent{event})
+}
@@ -0,0 +1,681 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// fakeUsers is an in-memory datasource of users, conflicts fails the given number of saves with a version mismatch
+type fakeUsers struct {
+	users     map[uuid.UUID]types.User
+	events    []types.UserEvent
+	addresses []types.UserAddress
+	conflicts int
+	saves     int
+}
+
+func (f *fakeUsers) Get(_ context.Context, id uuid.UUID) (types.User, error) {
+	if user, ok := f.users[id]; ok {
+		return user, nil
+	}
+	return types.User{}, types.ErrNotFound
+}
+
+func (f *fakeUsers) GetMany(_ context.Context, ids []uuid.UUID) ([]types.User, error) {
+	var users []types.User
+	for _, id := range ids {
+		if user, ok := f.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeUsers) QueryPage(context.Context, types.QueryParams) (types.Page[types.User], error) {
+	return types.Page[types.User]{}, nil
+}
+
+func (f *fakeUsers) Save(_ context.Context, user types.User) (types.User, error) {
+	f.saves++
+	if f.conflicts > 0 {
+		f.conflicts--
+		return types.User{}, types.ErrVersionMismatch
+	}
+	if user.ExpectedVersionID != uuid.Nil && f.users[user.ID].VersionID != user.ExpectedVersionID {
+		return types.User{}, types.ErrVersionMismatch
+	}
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	user.VersionID, user.ExpectedVersionID = uuid.New(), uuid.Nil
+	f.users[user.ID] = user
+	return user, nil
+}
+
+func (f *fakeUsers) Delete(_ context.Context, id uuid.UUID) error {
+	delete(f.users, id)
+	return nil
+}
+
+func (f *fakeUsers) DeleteVersion(_ context.Context, id, versionID uuid.UUID) error {
+	if f.users[id].VersionID != versionID {
+		return types.ErrVersionMismatch
+	}
+	delete(f.users, id)
+	return nil
+}
+
+func (f *fakeUsers) MutateBatch(ctx context.Context, ops []types.UserOperation, _ bool) ([]types.UserOperationResult, error) {
+	results := make([]types.UserOperationResult, len(ops))
+	for i, op := range ops {
+		switch op.Kind {
+		case types.UserOperationCreate:
+			results[i].User, results[i].Err = f.Save(ctx, op.User)
+		case types.UserOperationPatch:
+			user := f.users[op.ID]
+			user.ApplyPatch(op.Patch)
+			results[i].User, results[i].Err = f.Save(ctx, user)
+		}
+	}
+	return results, nil
+}
+
+func (f *fakeUsers) GetByEmail(_ context.Context, email string) (types.User, error) {
+	for _, user := range f.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return types.User{}, types.ErrNotFound
+}
+
+func (f *fakeUsers) GetHistory(ctx context.Context, id uuid.UUID) ([]types.User, error) {
+	return f.GetMany(ctx, []uuid.UUID{id})
+}
+
+// GetHistories returns the latest version of the users as their only one
+func (f *fakeUsers) GetHistories(ctx context.Context, ids []uuid.UUID) ([]types.User, error) {
+	return f.GetMany(ctx, ids)
+}
+
+func (f *fakeUsers) CountHistory(_ context.Context, id uuid.UUID) (int64, error) {
+	if _, ok := f.users[id]; ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (f *fakeUsers) GetByUsers(_ context.Context, userIDs []uuid.UUID) ([]types.UserAddress, error) {
+	var addresses []types.UserAddress
+	for _, address := range f.addresses {
+		for _, id := range userIDs {
+			if address.UserID == id {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+func (f *fakeUsers) SaveEvents(_ context.Context, events []types.UserEvent) error {
+	f.events = append(f.events, events...)
+	return nil
+}
+
+var (
+	testUser  = types.User{ID: uuid.New(), FirstName: "test", LastName: "user", Email: "test@test.com", Phone: "+49123456789"}
+	testAdmin = types.User{ID: uuid.New(), FirstName: "test", LastName: "admin", Email: "admin@test.com", Phone: "+49123456789", IsAdmin: true}
+	testInput = types.UserInput{FirstName: "new", LastName: "user", Email: "new@test.com", Phone: "+49123456789", Password: "password"}
+)
+
+func newTestService(conflicts int) (*UserService, *fakeUsers) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	f := &fakeUsers{users: map[uuid.UUID]types.User{}, conflicts: conflicts}
+	for _, user := range []types.User{testUser, testAdmin} {
+		user.VersionID, user.PasswordHash = uuid.New(), string(hash)
+		f.users[user.ID] = user
+	}
+	return createUserService(f, f, f, f, f, f, f, f, f, f, f, f, f), f
+}
+
+func Test_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   Actor
+		input   types.UserInput
+		wantErr error
+		// wantRules are the failed rules by field of validation errors
+		wantRules map[string]string
+	}{
+		{
+			name:  "Success Case",
+			actor: Actor{ID: testAdmin.ID, IsAdmin: true},
+			input: testInput,
+		},
+		{
+			name:    "Forbidden Case",
+			actor:   Actor{ID: testUser.ID},
+			input:   testInput,
+			wantErr: types.ErrForbidden,
+		},
+		{
+			name:      "Invalid Case",
+			actor:     Actor{ID: testAdmin.ID, IsAdmin: true},
+			input:     types.UserInput{FirstName: "new", LastName: "user", Email: "new", Phone: "123", Password: "password"},
+			wantErr:   types.ErrValidationFailed,
+			wantRules: map[string]string{"/email": "email_address", "/phone": "phone"},
+		},
+		{
+			name:      "Password Too Long Case",
+			actor:     Actor{ID: testAdmin.ID, IsAdmin: true},
+			input:     types.UserInput{FirstName: "new", LastName: "user", Email: "new@test.com", Phone: "+49123456789", Password: strings.Repeat("ü", 40)},
+			wantErr:   types.ErrValidationFailed,
+			wantRules: map[string]string{"/password": "max_bytes"},
+		},
+		{
+			name:    "Email Taken Case",
+			actor:   Actor{ID: testAdmin.ID, IsAdmin: true},
+			input:   types.UserInput{FirstName: "new", LastName: "user", Email: "TEST@test.com", Phone: "+49123456789", Password: "password"},
+			wantErr: types.ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestService(0)
+			got, err := service.Create(context.Background(), tt.actor, tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				var validationErr *types.ValidationError
+				if errors.As(err, &validationErr) {
+					rules := make(map[string]string)
+					for _, field := range validationErr.Fields {
+						rules[field.Pointer] = field.Rule
+					}
+					assert.Equal(t, tt.wantRules, rules)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.input.Email, got.Email)
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(got.PasswordHash), []byte(tt.input.Password)))
+		})
+	}
+}
+
+func Test_Update(t *testing.T) {
+	adminInput := testInput
+	adminInput.IsAdmin = true
+
+	tests := []struct {
+		name      string
+		actor     Actor
+		id        uuid.UUID
+		me        bool
+		input     types.UserInput
+		condition VersionCondition
+		wantErr   error
+	}{
+		{
+			name:  "Me Case",
+			actor: Actor{ID: testUser.ID},
+			id:    testUser.ID,
+			me:    true,
+			input: testInput,
+		},
+		{
+			name:  "Admin Case",
+			actor: Actor{ID: testAdmin.ID, IsAdmin: true},
+			id:    testUser.ID,
+			input: adminInput,
+		},
+		{
+			name:    "Self By ID Case",
+			actor:   Actor{ID: testUser.ID},
+			id:      testUser.ID,
+			input:   testInput,
+			wantErr: types.ErrForbidden,
+		},
+		{
+			name:    "Other User Case",
+			actor:   Actor{ID: testUser.ID},
+			id:      testAdmin.ID,
+			input:   testInput,
+			wantErr: types.ErrForbidden,
+		},
+		{
+			name:    "Me Admin Case",
+			actor:   Actor{ID: testUser.ID},
+			id:      testUser.ID,
+			me:      true,
+			input:   adminInput,
+			wantErr: types.ErrForbidden,
+		},
+		{
+			name:      "Version Mismatch Case",
+			actor:     Actor{ID: testUser.ID},
+			id:        testUser.ID,
+			me:        true,
+			input:     testInput,
+			condition: func(uuid.UUID) bool { return false },
+			wantErr:   types.ErrVersionMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, f := newTestService(0)
+			hash := f.users[tt.id].PasswordHash
+			var got types.User
+			var err error
+			if tt.me {
+				got, err = service.UpdateMe(context.Background(), tt.actor, tt.input, tt.condition)
+			} else {
+				got, err = service.Update(context.Background(), tt.actor, tt.id, tt.input, tt.condition)
+			}
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.id, got.ID)
+				assert.Equal(t, tt.input.IsAdmin, got.IsAdmin)
+				// the password is unchanged and keeps its hash
+				assert.Equal(t, hash, got.PasswordHash)
+			}
+		})
+	}
+}
+
+func Test_Patch(t *testing.T) {
+	patchName := func(types.User) (types.UserPatch, error) {
+		return types.UserPatch{FirstName: types.ToOptional("patched")}, nil
+	}
+
+	tests := []struct {
+		name      string
+		actor     Actor
+		me        bool
+		patcher   UserPatcher
+		condition VersionCondition
+		conflicts int
+		wantSaves int
+		wantErr   error
+	}{
+		{
+			name:      "Me Case",
+			actor:     Actor{ID: testUser.ID},
+			me:        true,
+			patcher:   patchName,
+			wantSaves: 1,
+		},
+		{
+			name:      "Admin Case",
+			actor:     Actor{ID: testAdmin.ID, IsAdmin: true},
+			patcher:   patchName,
+			wantSaves: 1,
+		},
+		{
+			name:    "Self By ID Case",
+			actor:   Actor{ID: testUser.ID},
+			patcher: patchName,
+			wantErr: types.ErrForbidden,
+		},
+		{
+			name:      "Concurrent Change Case",
+			actor:     Actor{ID: testUser.ID},
+			me:        true,
+			patcher:   patchName,
+			conflicts: maxPatchAttempts - 1,
+			wantSaves: maxPatchAttempts,
+		},
+		{
+			name:      "Too Many Concurrent Changes Case",
+			actor:     Actor{ID: testUser.ID},
+			me:        true,
+			patcher:   patchName,
+			conflicts: maxPatchAttempts,
+			wantSaves: maxPatchAttempts,
+			wantErr:   types.ErrVersionMismatch,
+		},
+		{
+			name:      "Conditional Concurrent Change Case",
+			actor:     Actor{ID: testUser.ID},
+			me:        true,
+			patcher:   patchName,
+			condition: func(uuid.UUID) bool { return true },
+			conflicts: 1,
+			wantSaves: 1,
+			wantErr:   types.ErrVersionMismatch,
+		},
+		{
+			name:  "Me Admin Case",
+			actor: Actor{ID: testUser.ID},
+			me:    true,
+			patcher: func(types.User) (types.UserPatch, error) {
+				return types.UserPatch{IsAdmin: types.ToOptional(false)}, nil
+			},
+			wantErr: types.ErrForbidden,
+		},
+		{
+			name:  "Invalid Case",
+			actor: Actor{ID: testAdmin.ID, IsAdmin: true},
+			patcher: func(types.User) (types.UserPatch, error) {
+				return types.UserPatch{Phone: types.ToOptional("123")}, nil
+			},
+			wantErr: types.ErrValidationFailed,
+		},
+		{
+			name:  "Email Taken Case",
+			actor: Actor{ID: testUser.ID},
+			me:    true,
+			patcher: func(types.User) (types.UserPatch, error) {
+				return types.UserPatch{Email: types.ToOptional(testAdmin.Email)}, nil
+			},
+			wantErr: types.ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, f := newTestService(tt.conflicts)
+			var got types.User
+			var err error
+			if tt.me {
+				got, err = service.PatchMe(context.Background(), tt.actor, tt.patcher, tt.condition)
+			} else {
+				got, err = service.Patch(context.Background(), tt.actor, testUser.ID, tt.patcher, tt.condition)
+			}
+			assert.Equal(t, tt.wantSaves, f.saves)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, testUser.ID, got.ID)
+				assert.Equal(t, "patched", got.FirstName)
+				assert.Equal(t, testUser.LastName, got.LastName)
+			}
+		})
+	}
+}
+
+func Test_PatchUnchanged(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch types.UserPatch
+	}{
+		{
+			name: "Empty Case",
+		},
+		{
+			name:  "Same Values Case",
+			patch: types.UserPatch{FirstName: types.ToOptional(testUser.FirstName), Email: types.ToOptional(testUser.Email)},
+		},
+		{
+			name:  "Same Password Case",
+			patch: types.UserPatch{Password: types.ToOptional("password")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, f := newTestService(0)
+			want := f.users[testUser.ID]
+			got, err := service.PatchMe(context.Background(), Actor{ID: testUser.ID}, func(types.User) (types.UserPatch, error) {
+				return tt.patch, nil
+			}, nil)
+			if assert.NoError(t, err) {
+				// the user is returned as it is without saving a new version
+				assert.Zero(t, f.saves)
+				assert.Equal(t, want.VersionID, got.VersionID)
+				assert.Equal(t, want.PasswordHash, got.PasswordHash)
+			}
+		})
+	}
+}
+
+func Test_Delete(t *testing.T) {
+	service, f := newTestService(0)
+
+	err := service.Delete(context.Background(), Actor{ID: testUser.ID}, testAdmin.ID, nil)
+	assert.ErrorIs(t, err, types.ErrForbidden)
+
+	err = service.Delete(context.Background(), Actor{ID: testUser.ID}, testUser.ID, nil)
+	assert.ErrorIs(t, err, types.ErrForbidden)
+
+	err = service.DeleteMe(context.Background(), Actor{ID: testUser.ID}, func(uuid.UUID) bool { return false })
+	assert.ErrorIs(t, err, types.ErrVersionMismatch)
+
+	err = service.DeleteMe(context.Background(), Actor{ID: testUser.ID}, func(uuid.UUID) bool { return true })
+	assert.NoError(t, err)
+	assert.NotContains(t, f.users, testUser.ID)
+
+	err = service.Delete(context.Background(), Actor{ID: testAdmin.ID, IsAdmin: true}, testAdmin.ID, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, f.users, testAdmin.ID)
+}
+
+func Test_GetMany(t *testing.T) {
+	service, _ := newTestService(0)
+	missingID := uuid.New()
+
+	users, missing, err := service.GetMany(context.Background(), []uuid.UUID{testAdmin.ID, missingID, testUser.ID, testAdmin.ID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	assert.Equal(t, []uuid.UUID{testAdmin.ID, testUser.ID}, ids)
+	assert.Equal(t, []uuid.UUID{missingID}, missing)
+}
+
+func Test_MutateBatch(t *testing.T) {
+	invalidInput := testInput
+	invalidInput.Email = "invalid"
+	ops := []types.UserOperation{
+		{Kind: types.UserOperationCreate, Input: testInput},
+		{Kind: types.UserOperationCreate, Input: invalidInput},
+	}
+
+	tests := []struct {
+		name    string
+		actor   Actor
+		atomic  bool
+		wantErr error
+	}{
+		{
+			name:  "Independent Case",
+			actor: Actor{ID: testAdmin.ID, IsAdmin: true},
+		},
+		{
+			name:    "Atomic Case",
+			actor:   Actor{ID: testAdmin.ID, IsAdmin: true},
+			atomic:  true,
+			wantErr: types.ErrValidationFailed,
+		},
+		{
+			name:    "Forbidden Case",
+			actor:   Actor{ID: testUser.ID},
+			wantErr: types.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestService(0)
+			results, err := service.MutateBatch(context.Background(), tt.actor, ops, tt.atomic)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, results)
+				return
+			}
+			if assert.NoError(t, err) && assert.Len(t, results, len(ops)) {
+				assert.NoError(t, results[0].Err)
+				assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(results[0].User.PasswordHash), []byte(testInput.Password)))
+				assert.ErrorIs(t, results[1].Err, types.ErrValidationFailed)
+			}
+		})
+	}
+}
+
+func Test_MutateBatchPatchPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantKept bool
+	}{
+		{
+			name:     "Unchanged Password Case",
+			password: "password",
+			wantKept: true,
+		},
+		{
+			name:     "Changed Password Case",
+			password: "new password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, f := newTestService(0)
+			hash := f.users[testUser.ID].PasswordHash
+			ops := []types.UserOperation{{Kind: types.UserOperationPatch, ID: testUser.ID, Patch: types.UserPatch{Password: types.ToOptional(tt.password)}}}
+
+			results, err := service.MutateBatch(context.Background(), Actor{ID: testAdmin.ID, IsAdmin: true}, ops, true)
+			if !assert.NoError(t, err) || !assert.Len(t, results, 1) || !assert.NoError(t, results[0].Err) {
+				return
+			}
+			got := results[0].User.PasswordHash
+			assert.Equal(t, tt.wantKept, got == hash)
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(got), []byte(tt.password)))
+		})
+	}
+}
+
+func Test_Relations(t *testing.T) {
+	service, f := newTestService(0)
+	address := types.UserAddress{ID: uuid.New(), UserID: testUser.ID, Kind: types.AddressKindHome}
+	f.addresses = []types.UserAddress{address}
+	ids := []uuid.UUID{testUser.ID, testAdmin.ID}
+
+	tests := []struct {
+		name          string
+		actor         Actor
+		expand        types.Fields
+		wantVersions  bool
+		wantAddresses bool
+		wantErr       error
+	}{
+		{
+			name:  "Nothing Expanded Case",
+			actor: Actor{ID: testUser.ID},
+		},
+		{
+			name:         "Versions Case",
+			actor:        Actor{ID: testAdmin.ID, IsAdmin: true},
+			expand:       types.Fields{"versions"},
+			wantVersions: true,
+		},
+		{
+			name:          "Addresses Case",
+			actor:         Actor{ID: testAdmin.ID, IsAdmin: true},
+			expand:        types.Fields{"addresses"},
+			wantAddresses: true,
+		},
+		{
+			name:    "Forbidden Case",
+			actor:   Actor{ID: testUser.ID},
+			expand:  types.Fields{"addresses"},
+			wantErr: types.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relations, err := service.Relations(context.Background(), tt.actor, ids, tt.expand)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.wantVersions, relations.Versions != nil)
+			assert.Equal(t, tt.wantAddresses, relations.Addresses != nil)
+			if tt.wantVersions {
+				assert.Len(t, relations.Versions[testUser.ID], 1)
+				assert.Len(t, relations.Versions[testAdmin.ID], 1)
+			}
+			if tt.wantAddresses {
+				assert.Equal(t, []types.UserAddress{address}, relations.Addresses[testUser.ID])
+				assert.Empty(t, relations.Addresses[testAdmin.ID])
+			}
+		})
+	}
+}
+
+func Test_Login(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		password  string
+		wantEvent types.UserEventKind
+		wantID    uuid.UUID
+		wantErr   error
+	}{
+		{
+			name:      "Success Case",
+			email:     "Test@Test.com",
+			password:  "password",
+			wantEvent: types.LoginSucceeded,
+			wantID:    testUser.ID,
+		},
+		{
+			name:      "Wrong Password Case",
+			email:     testUser.Email,
+			password:  "wrong",
+			wantEvent: types.LoginFailed,
+			wantID:    testUser.ID,
+			wantErr:   types.ErrUnauthorized,
+		},
+		{
+			name:      "Not Found Case",
+			email:     "unknown@test.com",
+			password:  "password",
+			wantEvent: types.LoginFailed,
+			wantID:    uuid.Nil,
+			wantErr:   types.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, f := newTestService(0)
+			got, err := service.Login(context.Background(), tt.email, tt.password)
+			if assert.Len(t, f.events, 1) {
+				assert.Equal(t, tt.wantEvent, f.events[0].Kind)
+				assert.Equal(t, tt.wantID, f.events[0].UserID)
+			}
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "error = %v, want %v", err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, testUser.ID, got.ID)
+			}
+		})
+	}
+}
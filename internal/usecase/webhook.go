@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// WebhookService manages the webhooks and their deliveries, only admins can access them
+type WebhookService struct {
+	getter         datasource.Getter[types.Webhook]
+	querier        datasource.Querier[types.Webhook]
+	saver          datasource.Saver[types.Webhook]
+	deleter        datasource.Deleter[types.Webhook]
+	deliveries     datasource.Querier[types.WebhookDelivery]
+	deliveryGetter datasource.WebhookDeliveryGetter
+	redeliverer    datasource.WebhookRedeliverer
+}
+
+func createWebhookService(
+	getter datasource.Getter[types.Webhook],
+	querier datasource.Querier[types.Webhook],
+	saver datasource.Saver[types.Webhook],
+	deleter datasource.Deleter[types.Webhook],
+	deliveries datasource.Querier[types.WebhookDelivery],
+	deliveryGetter datasource.WebhookDeliveryGetter,
+	redeliverer datasource.WebhookRedeliverer,
+) *WebhookService {
+	return &WebhookService{
+		getter:         getter,
+		querier:        querier,
+		saver:          saver,
+		deleter:        deleter,
+		deliveries:     deliveries,
+		deliveryGetter: deliveryGetter,
+		redeliverer:    redeliverer,
+	}
+}
+
+// Create saves a new webhook, its secret is generated if missing
+func (s *WebhookService) Create(ctx context.Context, actor Actor, webhook types.Webhook) (types.Webhook, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.Webhook{}, err
+	}
+	webhook.ID = uuid.Nil
+	if webhook.Secret == "" {
+		secret, err := types.NewWebhookSecret()
+		if err != nil {
+			return types.Webhook{}, err
+		}
+		webhook.Secret = secret
+	}
+	return s.saver.Save(ctx, webhook)
+}
+
+func (s *WebhookService) Query(ctx context.Context, actor Actor, params types.QueryParams) ([]types.Webhook, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return nil, err
+	}
+	return s.querier.Query(ctx, params)
+}
+
+func (s *WebhookService) Get(ctx context.Context, actor Actor, id uuid.UUID) (types.Webhook, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.Webhook{}, err
+	}
+	return s.getter.Get(ctx, id)
+}
+
+// Update overwrites the url and events of the webhook, and its secret if given
+func (s *WebhookService) Update(ctx context.Context, actor Actor, id uuid.UUID, webhook types.Webhook) (types.Webhook, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.Webhook{}, err
+	}
+	webhook.ID = id
+	return s.saver.Save(ctx, webhook)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, actor Actor, id uuid.UUID) error {
+	if err := actor.RequireAdmin(); err != nil {
+		return err
+	}
+	return s.deleter.Delete(ctx, id)
+}
+
+// QueryDeliveries returns the deliveries of the webhook matching the params, deliveries of unknown webhooks are
+// not found rather than empty
+func (s *WebhookService) QueryDeliveries(ctx context.Context, actor Actor, id uuid.UUID, params types.QueryParams) ([]types.WebhookDelivery, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return nil, err
+	}
+	if _, err := s.getter.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.deliveries.Query(ctx, params)
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, actor Actor, id, deliveryID uuid.UUID) (types.WebhookDelivery, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+	return s.deliveryGetter.GetDelivery(ctx, id, deliveryID)
+}
+
+// Redeliver makes the delivery pending and due right away, e.g. once a broken endpoint is fixed
+func (s *WebhookService) Redeliver(ctx context.Context, actor Actor, id, deliveryID uuid.UUID) (types.WebhookDelivery, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+	return s.redeliverer.Redeliver(ctx, id, deliveryID)
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/transaction"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
 	"go.uber.org/zap"
 )
@@ -23,23 +25,35 @@ const (
 type cache struct {
 	*redis.Client
 	datasource.Getter[types.User]
+	datasource.ManyGetter[types.User]
 	datasource.Saver[types.User]
+	datasource.BatchSaver[types.User]
 	datasource.Deleter[types.User]
+	datasource.VersionedDeleter[types.User]
+	datasource.UserBatchMutator
 	datasource.UserByEmailGetter
 }
 
 func create(
 	r *redis.Client,
 	getter datasource.Getter[types.User],
+	manyGetter datasource.ManyGetter[types.User],
 	saver datasource.Saver[types.User],
+	batchSaver datasource.BatchSaver[types.User],
 	deleter datasource.Deleter[types.User],
+	versionedDeleter datasource.VersionedDeleter[types.User],
+	batchMutator datasource.UserBatchMutator,
 	userByEmailGetter datasource.UserByEmailGetter,
 ) *cache {
 	return &cache{
 		r,
 		getter,
+		manyGetter,
 		saver,
+		batchSaver,
 		deleter,
+		versionedDeleter,
+		batchMutator,
 		userByEmailGetter,
 	}
 }
@@ -48,7 +62,17 @@ func keyFromID(id uuid.UUID) string {
 	return "user:" + id.String()
 }
 
+// keyFromEmail is case-insensitive like the emails of users
+func keyFromEmail(email string) string {
+	return "user:email:" + strings.ToLower(email)
+}
+
 func (c *cache) Get(ctx context.Context, id uuid.UUID) (types.User, error) {
+	// Reads in a unit of work see its changes, which must not be cached before they are committed
+	if transaction.InProgress(ctx) {
+		return c.Getter.Get(ctx, id)
+	}
+
 	cached, err := c.Client.Get(ctx, keyFromID(id)).Result()
 	if err == nil {
 		// Cache hit
@@ -74,35 +98,138 @@ func (c *cache) Get(ctx context.Context, id uuid.UUID) (types.User, error) {
 	return user, nil
 }
 
+// GetMany fetches all cached users at once and only the missing ones from the wrapped getter
+func (c *cache) GetMany(ctx context.Context, ids []uuid.UUID) ([]types.User, error) {
+	if len(ids) == 0 {
+		return []types.User{}, nil
+	}
+	if transaction.InProgress(ctx) {
+		return c.ManyGetter.GetMany(ctx, ids)
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = keyFromID(id)
+	}
+
+	users := make([]types.User, 0, len(ids))
+	var misses []uuid.UUID
+	cached, err := c.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		logging.FromContext(ctx).Warn("cache error", zap.Error(err))
+		misses = ids
+	} else {
+		for i, value := range cached {
+			// Missing keys are nil
+			data, ok := value.(string)
+			if !ok {
+				misses = append(misses, ids[i])
+				continue
+			}
+			var user types.User
+			if err := json.Unmarshal([]byte(data), &user); err != nil {
+				logging.FromContext(ctx).Warn("failed to unmarshal cached user data", zap.String("cached_user", data), zap.Error(err))
+				misses = append(misses, ids[i])
+				continue
+			}
+			users = append(users, user)
+		}
+	}
+	if len(misses) == 0 {
+		return users, nil
+	}
+
+	// Cache misses, fetch from wrapped getter
+	fetched, err := c.ManyGetter.GetMany(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range fetched {
+		c.setUserCache(user)
+	}
+
+	return append(users, fetched...), nil
+}
+
 func (c *cache) Save(ctx context.Context, user types.User) (types.User, error) {
 	savedUser, err := c.Saver.Save(ctx, user)
 	if err != nil {
 		return savedUser, err
 	}
 
-	c.delUserCache(savedUser.ID, &user.Email)
+	c.delUserCache(ctx, savedUser.ID, &user.Email)
 
 	return savedUser, nil
 }
 
+func (c *cache) SaveBatch(ctx context.Context, users []types.User) ([]types.User, error) {
+	savedUsers, err := c.BatchSaver.SaveBatch(ctx, users)
+	if err != nil {
+		return savedUsers, err
+	}
+
+	for i := range savedUsers {
+		c.delUserCache(ctx, savedUsers[i].ID, &savedUsers[i].Email)
+	}
+
+	return savedUsers, nil
+}
+
 func (c *cache) Delete(ctx context.Context, id uuid.UUID) error {
 	err := c.Deleter.Delete(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	c.delUserCache(id, nil)
+	c.delUserCache(ctx, id, nil)
 
 	return nil
 }
 
+func (c *cache) DeleteVersion(ctx context.Context, id, versionID uuid.UUID) error {
+	err := c.VersionedDeleter.DeleteVersion(ctx, id, versionID)
+	if err != nil {
+		return err
+	}
+
+	c.delUserCache(ctx, id, nil)
+
+	return nil
+}
+
+func (c *cache) MutateBatch(ctx context.Context, ops []types.UserOperation, atomic bool) ([]types.UserOperationResult, error) {
+	results, err := c.UserBatchMutator.MutateBatch(ctx, ops, atomic)
+
+	// Results without error were applied, new users are not cached yet
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		switch ops[i].Kind {
+		case types.UserOperationPatch:
+			c.delUserCache(ctx, result.User.ID, &result.User.Email)
+		case types.UserOperationDelete:
+			c.delUserCache(ctx, ops[i].ID, nil)
+		}
+	}
+
+	return results, err
+}
+
 func (c *cache) GetByEmail(ctx context.Context, email string) (types.User, error) {
-	cached, err := c.Client.Get(ctx, "user_id:"+email).Result()
+	if transaction.InProgress(ctx) {
+		return c.UserByEmailGetter.GetByEmail(ctx, email)
+	}
+
+	cached, err := c.Client.Get(ctx, keyFromEmail(email)).Result()
 	if err == nil {
 		// Cache hit
 		var user_id uuid.UUID
 		if err := json.Unmarshal([]byte(cached), &user_id); err == nil {
-			return c.Get(ctx, user_id)
+			// Only the key of the current email is invalidated on changes, a previous email might still point to the user
+			if user, err := c.Get(ctx, user_id); err == nil && strings.EqualFold(user.Email, email) {
+				return user, nil
+			}
 		} else {
 			logging.FromContext(ctx).Warn("failed to unmarshal cached user id", zap.String("cached_user_id", cached), zap.Error(err))
 		}
@@ -143,28 +270,32 @@ func (c *cache) setUserCache(user types.User) {
 			logging.FromContext(ctx).Warn("failed to marshal user id for caching", zap.String("caching_user_id", user.ID.String()), zap.Error(err))
 		}
 
-		err = c.Client.Set(ctx, "user:email:"+user.Email, idData, cacheTTL).Err()
+		err = c.Client.Set(ctx, keyFromEmail(user.Email), idData, cacheTTL).Err()
 		if err != nil {
 			logging.FromContext(ctx).Warn("failed to cache user id", zap.String("caching_user_id", user.ID.String()), zap.Error(err))
 		}
 	}()
 }
 
-func (c *cache) delUserCache(id uuid.UUID, email *string) {
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), cacheUpdateTimeout)
-		defer cancel()
+// delUserCache invalidates the user once the unit of work of the context is committed, reads in between would cache
+// the previous version again
+func (c *cache) delUserCache(ctx context.Context, id uuid.UUID, email *string) {
+	transaction.AfterCommit(ctx, func() { go c.del(id, email) })
+}
 
-		// Invalidate cache
-		if err := c.Client.Del(ctx, keyFromID(id)).Err(); !errors.Is(err, redis.Nil) && err != nil {
-			logging.FromContext(ctx).Error("failed to invalidate cache", zap.Error(err))
-		}
+func (c *cache) del(id uuid.UUID, email *string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheUpdateTimeout)
+	defer cancel()
 
-		// Invalidate cache by email to reduce 1 lookup
-		if email != nil {
-			if err := c.Client.Del(ctx, "user:email:"+*email).Err(); !errors.Is(err, redis.Nil) && err != nil {
-				logging.FromContext(ctx).Error("failed to invalidate cache", zap.Error(err))
-			}
+	// Invalidate cache
+	if err := c.Client.Del(ctx, keyFromID(id)).Err(); !errors.Is(err, redis.Nil) && err != nil {
+		logging.FromContext(ctx).Error("failed to invalidate cache", zap.Error(err))
+	}
+
+	// Invalidate cache by email to reduce 1 lookup
+	if email != nil {
+		if err := c.Client.Del(ctx, keyFromEmail(*email)).Err(); !errors.Is(err, redis.Nil) && err != nil {
+			logging.FromContext(ctx).Error("failed to invalidate cache", zap.Error(err))
 		}
-	}()
+	}
 }
@@ -9,7 +9,11 @@ import (
 var FXUserCacheProvide = fx.Provide(
 	create,
 	fx.Annotate(func(c *cache) datasource.Getter[types.User] { return c }, fx.ResultTags(`name:"cachedUserGetter"`)),
+	fx.Annotate(func(c *cache) datasource.ManyGetter[types.User] { return c }, fx.ResultTags(`name:"cachedUserManyGetter"`)),
 	fx.Annotate(func(c *cache) datasource.Saver[types.User] { return c }, fx.ResultTags(`name:"cachedUserSaver"`)),
+	fx.Annotate(func(c *cache) datasource.BatchSaver[types.User] { return c }, fx.ResultTags(`name:"cachedUserBatchSaver"`)),
 	fx.Annotate(func(c *cache) datasource.Deleter[types.User] { return c }, fx.ResultTags(`name:"cachedUserDeleter"`)),
+	fx.Annotate(func(c *cache) datasource.VersionedDeleter[types.User] { return c }, fx.ResultTags(`name:"cachedUserVersionedDeleter"`)),
+	fx.Annotate(func(c *cache) datasource.UserBatchMutator { return c }, fx.ResultTags(`name:"cachedUserBatchMutator"`)),
 	fx.Annotate(func(c *cache) datasource.UserByEmailGetter { return c }, fx.ResultTags(`name:"cachedUserByEmailGetter"`)),
 )
@@ -0,0 +1,227 @@
+package userDB
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// changesChannel is notified about every change of users, see the v5 migration
+const changesChannel = "user_changes"
+
+// changesQuery is the union of the creates and updates from the versions and the deletes from the base table,
+// a version created at the same time as its user is the first one, see User.ToSave
+func changesQuery(db *gorm.DB) *gorm.DB {
+	versions := db.Table("user_versions").Joins("JOIN users ON users.id = user_versions.user_id").Select(
+		"CASE WHEN user_versions.created_at = users.created_at THEN 'created' ELSE 'updated' END AS kind",
+		"user_versions.id AS change_id",
+		"user_versions.created_at AS changed_at",
+		"users.id AS id",
+		"user_versions.id AS version_id",
+		"user_versions.first_name AS first_name",
+		"user_versions.last_name AS last_name",
+		"user_versions.email AS email",
+		"user_versions.phone AS phone",
+		"user_versions.is_admin AS is_admin",
+		"users.created_at AS created_at",
+		"user_versions.created_at AS updated_at",
+	)
+	deletes := db.Table("users").Where("users.deleted_at IS NOT NULL").Select(
+		"'deleted' AS kind",
+		"users.id AS change_id",
+		"users.deleted_at AS changed_at",
+		"users.id AS id",
+		"NULL::UUID AS version_id",
+		"'' AS first_name",
+		"'' AS last_name",
+		"'' AS email",
+		"'' AS phone",
+		"false AS is_admin",
+		"users.created_at AS created_at",
+		"users.deleted_at AS updated_at",
+	)
+	return db.Table("((?) UNION ALL (?)) AS changes", versions, deletes)
+}
+
+// changeColumns maps types.UserChangeSortFields to the columns of changesQuery
+var changeColumns = types.SortColumns{
+	"changed_at": {SQL: "changes.changed_at"},
+	"change_id":  {SQL: "changes.change_id"},
+}
+
+func (d *db) GetChanges(ctx context.Context, since *types.Cursor, until time.Time, limit int) ([]types.UserChange, error) {
+	tx := changesQuery(d.conn(ctx)).Where("changes.changed_at < ?", until)
+	if since != nil {
+		tx = since.Apply(tx, changeColumns)
+	}
+	var changes []types.UserChange
+	err := tx.Order("changes.changed_at, changes.change_id").Limit(limit).Find(&changes).Error
+	return changes, types.DBError(err)
+}
+
+// changeNotification is the payload of the notifications of changesChannel
+type changeNotification struct {
+	Kind      types.UserChangeKind `json:"kind"`
+	ChangeID  uuid.UUID            `json:"change_id"`
+	ChangedAt time.Time            `json:"changed_at"`
+	ID        uuid.UUID            `json:"id"`
+	VersionID uuid.UUID            `json:"version_id"`
+	FirstName string               `json:"first_name"`
+	LastName  string               `json:"last_name"`
+	Email     string               `json:"email"`
+	Phone     string               `json:"phone"`
+	IsAdmin   bool                 `json:"is_admin"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+func (n *changeNotification) toUserChange() types.UserChange {
+	return types.UserChange{
+		Kind:      n.Kind,
+		ID:        n.ChangeID,
+		ChangedAt: n.ChangedAt.UTC(),
+		User: types.User{
+			ID:        n.ID,
+			VersionID: n.VersionID,
+			FirstName: n.FirstName,
+			LastName:  n.LastName,
+			Email:     n.Email,
+			Phone:     n.Phone,
+			IsAdmin:   n.IsAdmin,
+			CreatedAt: n.CreatedAt.UTC(),
+			UpdatedAt: n.UpdatedAt.UTC(),
+		},
+	}
+}
+
+// subscriberBuffer is the number of changes a subscriber can fall behind before it is dropped
+const subscriberBuffer = 256
+
+// listener shares one connection listening to changesChannel between all subscribers,
+// it listens as long as there are subscribers
+type listener struct {
+	db          *gorm.DB
+	mu          sync.Mutex
+	subscribers map[chan types.UserChange]struct{}
+	cancel      context.CancelFunc
+}
+
+func createListener(g *gorm.DB) *listener {
+	return &listener{
+		db:          g,
+		subscribers: make(map[chan types.UserChange]struct{}),
+	}
+}
+
+func (l *listener) ListenChanges(ctx context.Context) (<-chan types.UserChange, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel == nil {
+		sqlDB, err := l.db.DB()
+		if err != nil {
+			return nil, errors.Join(types.ErrInternal, err)
+		}
+		// The connection is dedicated to listening until the last subscriber is gone
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			return nil, types.DBError(err)
+		}
+		if _, err := conn.ExecContext(ctx, "LISTEN "+changesChannel); err != nil {
+			_ = conn.Close()
+			return nil, types.DBError(err)
+		}
+		listenCtx, cancel := context.WithCancel(context.Background())
+		l.cancel = cancel
+		go l.listen(listenCtx, conn)
+	}
+
+	ch := make(chan types.UserChange, subscriberBuffer)
+	l.subscribers[ch] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		l.unsubscribe(ch)
+	}()
+	return ch, nil
+}
+
+func (l *listener) unsubscribe(ch chan types.UserChange) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.subscribers[ch]; ok {
+		l.drop(ch)
+	}
+}
+
+// drop removes the subscriber and stops listening if it was the last one, l.mu has to be held
+func (l *listener) drop(ch chan types.UserChange) {
+	delete(l.subscribers, ch)
+	close(ch)
+	if len(l.subscribers) == 0 && l.cancel != nil {
+		l.cancel()
+		l.cancel = nil
+	}
+}
+
+// listen broadcasts the notifications to the subscribers until the context is canceled or listening fails,
+// in which case all subscribers are dropped
+func (l *listener) listen(ctx context.Context, conn *sql.Conn) {
+	var err error
+	_ = conn.Raw(func(driverConn any) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn()
+		for {
+			notification, waitErr := pgConn.WaitForNotification(ctx)
+			if waitErr != nil {
+				err = waitErr
+				// The connection still listens, it must not be reused by the pool
+				return driver.ErrBadConn
+			}
+			var payload changeNotification
+			if err := json.Unmarshal([]byte(notification.Payload), &payload); err != nil {
+				logging.FromContext(ctx).Warn("failed to unmarshal user change notification", zap.String("payload", notification.Payload), zap.Error(err))
+				continue
+			}
+			l.broadcast(payload.toUserChange())
+		}
+	})
+	_ = conn.Close()
+
+	if ctx.Err() != nil {
+		// The last subscriber is gone
+		return
+	}
+
+	logging.FromContext(ctx).Error("listening to user changes failed", zap.Error(err))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.subscribers {
+		l.drop(ch)
+	}
+}
+
+// broadcast sends the change to all subscribers, dropping the ones that fell behind
+func (l *listener) broadcast(change types.UserChange) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch := range l.subscribers {
+		select {
+		case ch <- change:
+		default:
+			l.drop(ch)
+		}
+	}
+}
@@ -2,10 +2,18 @@ package userDB
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/pedramktb/schwarzit-probearbeit/internal/transaction"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
 )
 
@@ -19,91 +27,508 @@ func create(g *gorm.DB) *db {
 	}
 }
 
-func lastVersionQuery(db, tx *gorm.DB) *gorm.DB {
-	return tx.Joins("JOIN (?) AS last_version ON users.id = last_version.user_id",
-		db.Table("user_versions").Select("DISTINCT ON (user_id) *").Order("user_id, created_at DESC"),
-	).Select(
-		"users.id as id",
-		"last_version.id as version_id",
-		"last_version.first_name as first_name",
-		"last_version.last_name as last_name",
-		"last_version.email as email",
-		"last_version.phone as phone",
-		"last_version.is_admin as is_admin",
-		"last_version.password_hash as password_hash",
-		"true as is_latest_version",
-	).Where("users.deleted_at IS NULL")
-}
-
-func allVersionsQuery(db, tx *gorm.DB) *gorm.DB {
-	return tx.Table("users").Joins("JOIN user_versions ON users.id = user_versions.user_id").Joins(
-		"JOIN (?) AS last_version ON users.id = last_version.user_id",
-		db.Table("user_versions").Select("DISTINCT ON (user_id) user_id", "id").Order("user_id, created_at DESC"),
-	).Select(
-		"users.id as id",
-		"user_versions.id as version_id",
-		"user_versions.first_name as first_name",
-		"user_versions.last_name as last_name",
-		"user_versions.email as email",
-		"user_versions.phone as phone",
-		"user_versions.is_admin as is_admin",
-		"user_versions.password_hash as password_hash",
-		"user_versions.id = last_version.id as is_latest_version",
-	).Where("users.deleted_at IS NULL")
+// conn returns the transaction of the unit of work of the context, so that the queries join it
+func (d *db) conn(ctx context.Context) *gorm.DB {
+	return transaction.DB(ctx, d.DB)
+}
+
+// userColumns are the selected columns of a user query by the fields of types.User
+type userColumns []struct{ field, column string }
+
+// lastVersionColumns are the columns of lastVersionQuery
+var lastVersionColumns = userColumns{
+	{"id", "users.id as id"},
+	{"version_id", "last_version.id as version_id"},
+	{"first_name", "last_version.first_name as first_name"},
+	{"last_name", "last_version.last_name as last_name"},
+	{"email", "last_version.email as email"},
+	{"phone", "last_version.phone as phone"},
+	{"is_admin", "last_version.is_admin as is_admin"},
+	{"password_hash", "last_version.password_hash as password_hash"},
+	{"is_latest_version", "true as is_latest_version"},
+	{"created_at", "users.created_at as created_at"},
+	{"updated_at", "last_version.created_at as updated_at"},
+}
+
+// allVersionsColumns are the columns of allVersionsQuery
+var allVersionsColumns = userColumns{
+	{"id", "users.id as id"},
+	{"version_id", "user_versions.id as version_id"},
+	{"first_name", "user_versions.first_name as first_name"},
+	{"last_name", "user_versions.last_name as last_name"},
+	{"email", "user_versions.email as email"},
+	{"phone", "user_versions.phone as phone"},
+	{"is_admin", "user_versions.is_admin as is_admin"},
+	{"password_hash", "user_versions.password_hash as password_hash"},
+	{"is_latest_version", "user_versions.id = last_version.id as is_latest_version"},
+	{"created_at", "users.created_at as created_at"},
+	{"updated_at", "user_versions.created_at as updated_at"},
+}
+
+// selectColumns returns the columns for the given fields, all columns if nil
+func (c userColumns) selectColumns(fields types.Fields) []string {
+	columns := make([]string, 0, len(c))
+	for _, column := range c {
+		if fields == nil || fields.Contains(column.field) {
+			columns = append(columns, column.column)
+		}
+	}
+	return columns
+}
+
+// lastVersionQuery joins the latest versions from user_current, see the v7 migration
+func lastVersionQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Joins("JOIN user_current AS last_version ON users.id = last_version.user_id").
+		Select(lastVersionColumns.selectColumns(nil)).Where("users.deleted_at IS NULL")
+}
+
+// searchText returns the text users are searched by for the given user_versions or user_current table alias,
+// see user_search_text in the v4 migration
+func searchText(table string) string {
+	return fmt.Sprintf("user_search_text(%[1]s.first_name, %[1]s.last_name, %[1]s.email, %[1]s.phone)", table)
+}
+
+// relevance ranks the latest versions by the word similarity of the search to their search text
+func relevance(search string) clause.Expr {
+	return clause.Expr{SQL: "word_similarity(?, " + searchText("last_version") + ")", Vars: []any{search}}
+}
+
+func allVersionsQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Table("users").Joins("JOIN user_versions ON users.id = user_versions.user_id").
+		Joins("JOIN user_current AS last_version ON users.id = last_version.user_id").
+		Select(allVersionsColumns.selectColumns(nil)).Where("users.deleted_at IS NULL")
 }
 
 func (d *db) Get(ctx context.Context, id uuid.UUID) (types.User, error) {
 	var user types.User
-	err := lastVersionQuery(d.WithContext(ctx), d.WithContext(ctx).Table("users")).
+	err := lastVersionQuery(d.conn(ctx).Table("users")).
 		Where("users.id = ?", id).First(&user).Error
 	return user, types.DBError(err)
 }
 
+func (d *db) GetMany(ctx context.Context, ids []uuid.UUID) ([]types.User, error) {
+	var users []types.User
+	err := lastVersionQuery(d.conn(ctx).Table("users")).
+		Where("users.id IN ?", ids).Find(&users).Error
+	return users, types.DBError(err)
+}
+
+// addressQuery matches users having an address satisfying the condition
+func addressQuery(db *gorm.DB, condition clause.Expression) *gorm.DB {
+	return db.Table("addresses").Joins("JOIN (?) AS last_address_version ON addresses.id = last_address_version.address_id",
+		db.Table("address_versions").Select("DISTINCT ON (address_id) *").Order("address_id, created_at DESC"),
+	).Select("1").Where("addresses.user_id = users.id AND addresses.deleted_at IS NULL").Where(condition)
+}
+
+// filterColumns maps types.UserFilterFields to the columns of lastVersionQuery
+func filterColumns(db *gorm.DB) types.FilterColumns {
+	addressColumn := func(field string) types.FilterColumn {
+		return func(c types.FilterCondition) clause.Expression {
+			return clause.Expr{SQL: "EXISTS (?)", Vars: []any{
+				addressQuery(db, c.Expression("(last_address_version.address)."+field)),
+			}}
+		}
+	}
+	return types.FilterColumns{
+		"id":            types.Column("users.id"),
+		"version_id":    types.Column("last_version.id"),
+		"first_name":    types.Column("last_version.first_name"),
+		"last_name":     types.Column("last_version.last_name"),
+		"email":         types.Column("last_version.email"),
+		"phone":         types.Column("last_version.phone"),
+		"is_admin":      types.Column("last_version.is_admin"),
+		"created_at":    types.Column("users.created_at"),
+		"updated_at":    types.Column("last_version.created_at"),
+		"street":        addressColumn("street"),
+		"street_number": addressColumn("street_number"),
+		"zip_code":      addressColumn("zip_code"),
+		"city":          addressColumn("city"),
+	}
+}
+
+// sortColumns maps types.UserSortFields to the columns of lastVersionQuery, relevance is only defined for searches
+func sortColumns(search string) types.SortColumns {
+	columns := types.SortColumns{
+		"id":         {SQL: "users.id"},
+		"first_name": {SQL: "last_version.first_name"},
+		"last_name":  {SQL: "last_version.last_name"},
+		"email":      {SQL: "last_version.email"},
+		"created_at": {SQL: "users.created_at"},
+		"updated_at": {SQL: "last_version.created_at"},
+	}
+	if search != "" {
+		columns["relevance"] = relevance(search)
+	}
+	return columns
+}
+
+// usersQuery returns the latest versions of the users matching the filter and conditions of the params,
+// with only the requested fields selected
+func usersQuery(db *gorm.DB, params types.QueryParams) *gorm.DB {
+	tx := lastVersionQuery(db.Table("users"))
+	columns := lastVersionColumns.selectColumns(nil)
+	if params.Fields != nil {
+		// the id and the sort keys are always needed for the cursors
+		fields := append(types.Fields{"id"}, params.Fields...)
+		for _, key := range params.Sort {
+			fields = append(fields, key.Field)
+		}
+		if params.Cursor != nil {
+			for _, key := range params.Cursor.Sort {
+				fields = append(fields, key.Field)
+			}
+		}
+		columns = lastVersionColumns.selectColumns(fields)
+		tx = tx.Select(columns)
+	}
+	if params.Search != "" {
+		// the latest versions are matched through the trigram index of user_current
+		rank := relevance(params.Search)
+		tx = tx.Select(strings.Join(columns, ", ")+", "+rank.SQL+" as relevance", rank.Vars...).
+			Where("? <% "+searchText("last_version"), params.Search)
+	}
+	filter := params.Filter
+	if params.Conditions != nil {
+		// conditions are applied through the filter columns as the plain names are ambiguous in the joined query
+		filter = filter.And(types.EqualFilter(params.Conditions.ToMap()))
+	}
+	return filter.Apply(tx, filterColumns(db))
+}
+
 func (d *db) Query(ctx context.Context, params types.QueryParams) ([]types.User, error) {
 	var users []types.User
-	err := types.Query(lastVersionQuery(d.WithContext(ctx), d.WithContext(ctx).Table("users")), params).Find(&users).Error
+	tx := params.Sort.Apply(usersQuery(d.conn(ctx), params), sortColumns(params.Search), "id")
+	err := types.Query(tx, types.QueryParams{Pagination: params.Pagination}).Find(&users).Error
 	return users, types.DBError(err)
 }
 
+func (d *db) QueryPage(ctx context.Context, params types.QueryParams) (types.Page[types.User], error) {
+	page, err := types.QueryPage[types.User](d.conn(ctx), usersQuery(d.conn(ctx), params), params, sortColumns(params.Search), "id")
+	return page, types.DBError(err)
+}
+
+// streamBatchSize is the amount of rows fetched from a server-side cursor at once
+const streamBatchSize = 1000
+
+// stream reads the rows of the query through a server-side cursor and passes them to fn,
+// only one batch of rows is held in memory at a time
+func stream(tx, query *gorm.DB, fn func(types.User) error) error {
+	if err := tx.Exec("DECLARE users_stream NO SCROLL CURSOR FOR ?", query).Error; err != nil {
+		return types.DBError(err)
+	}
+	for {
+		var users []types.User
+		if err := tx.Raw(fmt.Sprintf("FETCH FORWARD %d FROM users_stream", streamBatchSize)).Find(&users).Error; err != nil {
+			return types.DBError(err)
+		}
+		for _, user := range users {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+		if len(users) < streamBatchSize {
+			return nil
+		}
+	}
+}
+
+// streamTxOptions make streams read a consistent snapshot, cursors only exist within transactions
+var streamTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+func (d *db) Stream(ctx context.Context, params types.QueryParams, fn func(types.User) error) error {
+	return d.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		query := params.Sort.Apply(usersQuery(tx, params), sortColumns(params.Search), "id")
+		return stream(tx, query, fn)
+	}, streamTxOptions)
+}
+
+// StreamHistory streams all versions of the users whose latest version matches the params,
+// ordered by user and version, the sort of the params is ignored
+func (d *db) StreamHistory(ctx context.Context, params types.QueryParams, fn func(types.User) error) error {
+	return d.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := usersQuery(tx, types.QueryParams{Conditions: params.Conditions, Filter: params.Filter, Search: params.Search}).
+			Select("users.id")
+		query := allVersionsQuery(tx.Table("users")).
+			Select(allVersionsColumns.selectColumns(params.Fields)).
+			Where("users.id IN (?)", ids).Order("users.id, user_versions.created_at")
+		return stream(tx, query, fn)
+	}, streamTxOptions)
+}
+
 func (d *db) Save(ctx context.Context, user types.User) (types.User, error) {
+	expectedVersionID := user.ExpectedVersionID
+	user.ExpectedVersionID = uuid.Nil
 	base, version := user.ToSave()
-	err := d.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := d.conn(ctx).Transaction(func(tx *gorm.DB) error {
 		// Create or find the base user
 		if base != nil {
 			if err := tx.Table("users").Create(base).Error; err != nil {
 				return types.DBError(err)
 			}
-		} else if err := tx.Table("users").Where("id = ? AND deleted_at IS NULL", user.ID).First(&types.User{}).Error; err != nil {
-			return types.DBError(err)
+		} else {
+			var existing types.User
+			baseQuery := tx.Table("users")
+			if expectedVersionID != uuid.Nil {
+				baseQuery = baseQuery.Clauses(clause.Locking{Strength: "UPDATE"})
+			}
+			if err := baseQuery.Where("id = ? AND deleted_at IS NULL", user.ID).First(&existing).Error; err != nil {
+				return types.DBError(err)
+			}
+			user.CreatedAt = existing.CreatedAt
+			if expectedVersionID != uuid.Nil {
+				if err := checkLatestVersion(tx, user.ID, expectedVersionID); err != nil {
+					return err
+				}
+			}
 		}
 
-		// Create the new version
+		// Create the new version, its events and make it the current one
 		if err := tx.Table("user_versions").Create(version).Error; err != nil {
 			return types.DBError(err)
 		}
+		events, err := versionEvents(tx, []types.User{user})
+		if err != nil {
+			return err
+		}
+		if err := saveEvents(tx, events); err != nil {
+			return err
+		}
+		return saveCurrent(tx, []map[string]any{version})
+	})
+	return user, err
+}
+
+func (d *db) SaveBatch(ctx context.Context, users []types.User) ([]types.User, error) {
+	if len(users) == 0 {
+		return users, nil
+	}
+
+	users = slices.Clone(users)
+	bases := make([]map[string]any, 0, len(users))
+	versions := make([]map[string]any, 0, len(users))
+	var existingIDs []uuid.UUID
+	for i := range users {
+		base, version := users[i].ToSave()
+		if base != nil {
+			bases = append(bases, base)
+		} else {
+			existingIDs = append(existingIDs, users[i].ID)
+		}
+		versions = append(versions, version)
+	}
+
+	err := d.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Create the new base users
+		if len(bases) > 0 {
+			if err := tx.Table("users").Create(&bases).Error; err != nil {
+				return types.DBError(err)
+			}
+		}
+
+		// Find the existing base users
+		if len(existingIDs) > 0 {
+			var existing []types.User
+			if err := tx.Table("users").Select("id", "created_at").
+				Where("id IN ? AND deleted_at IS NULL", existingIDs).Find(&existing).Error; err != nil {
+				return types.DBError(err)
+			}
+			createdAt := make(map[uuid.UUID]time.Time, len(existing))
+			for _, user := range existing {
+				createdAt[user.ID] = user.CreatedAt
+			}
+			for _, id := range existingIDs {
+				if _, ok := createdAt[id]; !ok {
+					return errors.Join(types.ErrNotFound, errors.Newf("user %s not found", id))
+				}
+			}
+			for i := range users {
+				if t, ok := createdAt[users[i].ID]; ok {
+					users[i].CreatedAt = t
+				}
+			}
+		}
+
+		// Create the new versions, their events and make them the current ones
+		if err := tx.Table("user_versions").Create(&versions).Error; err != nil {
+			return types.DBError(err)
+		}
+		events, err := versionEvents(tx, users)
+		if err != nil {
+			return err
+		}
+		if err := saveEvents(tx, events); err != nil {
+			return err
+		}
+		return saveCurrent(tx, versions)
+	})
+	return users, err
+}
+
+// currentColumns are the columns of user_current updated by a new current version
+var currentColumns = []string{"id", "created_at", "first_name", "last_name", "email", "phone", "is_admin", "password_hash"}
+
+// saveCurrent copies the versions to user_current, see the v7 migration. A version which is not newer than the current
+// one (e.g. of a concurrent save committed first) doesn't replace it, of several versions of a user the last one is saved.
+func saveCurrent(tx *gorm.DB, versions []map[string]any) error {
+	indexes := make(map[any]int, len(versions))
+	current := make([]map[string]any, 0, len(versions))
+	for _, version := range versions {
+		if i, ok := indexes[version["user_id"]]; ok {
+			current[i] = version
+		} else {
+			indexes[version["user_id"]] = len(current)
+			current = append(current, version)
+		}
+	}
+	return types.DBError(tx.Table("user_current").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns(currentColumns),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "user_current.created_at <= EXCLUDED.created_at"}}},
+	}).Create(&current).Error)
+}
+
+func (d *db) MutateBatch(ctx context.Context, ops []types.UserOperation, atomic bool) ([]types.UserOperationResult, error) {
+	results := make([]types.UserOperationResult, len(ops))
+	if !atomic {
+		for i, op := range ops {
+			results[i] = d.mutate(ctx, op)
+		}
+		return results, nil
+	}
 
+	err := transaction.Do(ctx, d.DB, func(ctx context.Context) error {
+		// the operations join the transaction and nest their own transactions as savepoints
+		for i, op := range ops {
+			if results[i] = d.mutate(ctx, op); results[i].Err != nil {
+				return results[i].Err
+			}
+		}
 		return nil
 	})
-	return user, err
+	if err != nil {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = types.UserOperationResult{Err: types.ErrBatchAborted}
+			}
+		}
+	}
+	return results, err
+}
+
+func (d *db) mutate(ctx context.Context, op types.UserOperation) types.UserOperationResult {
+	switch op.Kind {
+	case types.UserOperationCreate:
+		op.User.ID = uuid.Nil
+		user, err := d.Save(ctx, op.User)
+		return types.UserOperationResult{User: user, Err: err}
+	case types.UserOperationPatch:
+		user, err := d.Get(ctx, op.ID)
+		if err != nil {
+			return types.UserOperationResult{Err: err}
+		}
+		patched := user
+		patched.ApplyPatch(op.Patch)
+		if patched == user {
+			// Patches which change nothing don't create a version, as in the usecases
+			return types.UserOperationResult{User: user}
+		}
+		patched, err = d.Save(ctx, patched)
+		return types.UserOperationResult{User: patched, Err: err}
+	case types.UserOperationDelete:
+		return types.UserOperationResult{Err: d.Delete(ctx, op.ID)}
+	}
+	return types.UserOperationResult{Err: errors.Join(types.ErrInternal, errors.Newf("unknown operation %q", op.Kind))}
 }
 
 func (d *db) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := d.WithContext(ctx).Table("users").Where("id = ? AND deleted_at IS NULL", id).First(&types.User{}).Error; err != nil {
+	return d.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("users").Where("id = ? AND deleted_at IS NULL", id).First(&types.User{}).Error; err != nil {
+			return types.DBError(err)
+		}
+		return deleteUser(tx, id)
+	})
+}
+
+// deleteUser soft deletes the user and writes its event
+func deleteUser(tx *gorm.DB, id uuid.UUID) error {
+	if err := tx.Table("users").Where("id = ? AND deleted_at IS NULL", id).Delete(nil).Error; err != nil {
 		return types.DBError(err)
 	}
-	return types.DBError(d.WithContext(ctx).Table("users").Where("id = ? AND deleted_at IS NULL", id).Delete(nil).Error)
+	return saveEvents(tx, []types.UserEvent{types.NewUserDeleted(id)})
+}
+
+func (d *db) DeleteVersion(ctx context.Context, id, versionID uuid.UUID) error {
+	return d.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("users").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", id).First(&types.User{}).Error; err != nil {
+			return types.DBError(err)
+		}
+		if err := checkLatestVersion(tx, id, versionID); err != nil {
+			return err
+		}
+		return deleteUser(tx, id)
+	})
+}
+
+// checkLatestVersion fails with types.ErrVersionMismatch if the version is not the latest one of the user,
+// the base user has to be locked for the result to hold until the end of the transaction
+func checkLatestVersion(tx *gorm.DB, id, versionID uuid.UUID) error {
+	var latestVersionID uuid.UUID
+	if err := tx.Table("user_current").Select("id").Where("user_id = ?", id).Row().Scan(&latestVersionID); err != nil {
+		return types.DBError(err)
+	}
+	if latestVersionID != versionID {
+		return types.ErrVersionMismatch
+	}
+	return nil
 }
 
 func (d *db) GetVersion(ctx context.Context, versionID uuid.UUID) (types.User, error) {
 	var user types.User
-	err := allVersionsQuery(d.WithContext(ctx), d.WithContext(ctx).Table("users")).
+	err := allVersionsQuery(d.conn(ctx).Table("users")).
 		Where("user_versions.id = ?", versionID).First(&user).Error
 	return user, types.DBError(err)
 }
 
+// GetByEmail returns the user with the email, which is unique case-insensitively, see the v6 migration
 func (d *db) GetByEmail(ctx context.Context, email string) (types.User, error) {
 	var user types.User
-	err := lastVersionQuery(d.WithContext(ctx), d.WithContext(ctx).Table("users")).
-		Where("email = ?", email).First(&user).Error
+	err := lastVersionQuery(d.conn(ctx).Table("users")).
+		Joins("JOIN user_emails ON users.id = user_emails.user_id").
+		Where("lower(user_emails.email) = lower(?)", email).First(&user).Error
 	return user, types.DBError(err)
 }
+
+// GetByEmails returns the users with any of the emails, compared case-insensitively
+func (d *db) GetByEmails(ctx context.Context, emails []string) ([]types.User, error) {
+	lowerEmails := make([]string, len(emails))
+	for i, email := range emails {
+		lowerEmails[i] = strings.ToLower(email)
+	}
+	var users []types.User
+	err := lastVersionQuery(d.conn(ctx).Table("users")).
+		Joins("JOIN user_emails ON users.id = user_emails.user_id").
+		Where("lower(user_emails.email) IN ?", lowerEmails).Find(&users).Error
+	return users, types.DBError(err)
+}
+
+func (d *db) GetHistory(ctx context.Context, id uuid.UUID) ([]types.User, error) {
+	var users []types.User
+	err := allVersionsQuery(d.conn(ctx).Table("users")).
+		Where("users.id = ?", id).Order("user_versions.created_at").Find(&users).Error
+	return users, types.DBError(err)
+}
+
+func (d *db) GetHistories(ctx context.Context, ids []uuid.UUID) ([]types.User, error) {
+	var users []types.User
+	err := allVersionsQuery(d.conn(ctx).Table("users")).
+		Where("users.id IN ?", ids).Order("users.id, user_versions.created_at").Find(&users).Error
+	return users, types.DBError(err)
+}
+
+func (d *db) CountHistory(ctx context.Context, id uuid.UUID) (int64, error) {
+	var count int64
+	err := d.conn(ctx).Table("user_versions").Joins("JOIN users ON users.id = user_versions.user_id").
+		Where("users.id = ? AND users.deleted_at IS NULL", id).Count(&count).Error
+	return count, types.DBError(err)
+}
@@ -5,13 +5,17 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	testData "github.com/pedramktb/schwarzit-probearbeit/internal/test_data"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/transaction"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
 	"github.com/pedramktb/schwarzit-probearbeit/pkg/postgres"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var postgresContainer testcontainers.Container
@@ -69,6 +73,22 @@ func Test_Get(t *testing.T) {
 	}
 }
 
+func Test_GetMany(t *testing.T) {
+	dbName := "test-user-get-many"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+
+	got, err := userDB.GetMany(context.Background(), []uuid.UUID{testData.TestUser.ID, testData.TestAdminUser.ID, uuid.New()})
+	if err != nil {
+		t.Errorf("db.GetMany() error = %v", err)
+		return
+	}
+	assert.ElementsMatch(t, []types.User{testData.TestUser, testData.TestAdminUser}, got)
+}
+
 func Test_Query(t *testing.T) {
 	dbName := "test-user-query"
 	db := postgres.Test_Create_DB(ip, port, dbName)
@@ -77,100 +97,983 @@ func Test_Query(t *testing.T) {
 
 	// test
 	tests := []struct {
-		name    string
-		query   types.QueryParams
-		want    []types.User
-		wantErr bool
+		name    string
+		query   types.QueryParams
+		want    []types.User
+		wantErr bool
+	}{
+		{
+			name:    "Success Case",
+			query:   types.QueryParams{Conditions: &types.UserPatch{LastName: types.ToOptional("user")}},
+			want:    []types.User{testData.TestUser},
+			wantErr: false,
+		},
+		{
+			name:    "Not Found Case",
+			query:   types.QueryParams{Conditions: &types.UserPatch{FirstName: types.ToOptional("not found")}},
+			want:    []types.User{},
+			wantErr: false,
+		},
+		{
+			name:    "ID Case",
+			query:   types.QueryParams{Conditions: &types.UserPatch{ID: types.ToOptional(testData.TestAdminUser.ID)}},
+			want:    []types.User{testData.TestAdminUser},
+			wantErr: false,
+		},
+		{
+			name: "Address Success Case",
+			query: types.QueryParams{Filter: types.Filter{
+				{{Field: "city", Operator: types.FilterEq, Value: "Berlin"}},
+			}},
+			want:    []types.User{testData.TestUser},
+			wantErr: false,
+		},
+		{
+			name: "Address Not Found Case",
+			query: types.QueryParams{
+				Conditions: &types.UserPatch{LastName: types.ToOptional("user")},
+				Filter:     types.Filter{{{Field: "zip_code", Operator: types.FilterEq, Value: "54321"}}},
+			},
+			want:    []types.User{},
+			wantErr: false,
+		},
+		{
+			name: "Filter Prefix Case",
+			query: types.QueryParams{Filter: types.Filter{
+				{{Field: "email", Operator: types.FilterPrefix, Value: "ADMIN@"}},
+			}},
+			want:    []types.User{testData.TestAdminUser},
+			wantErr: false,
+		},
+		{
+			name: "Filter Or Case",
+			query: types.QueryParams{Filter: types.Filter{
+				{{Field: "last_name", Operator: types.FilterEq, Value: "user"}, {Field: "is_admin", Operator: types.FilterEq, Value: true}},
+			}},
+			want:    []types.User{testData.TestUser, testData.TestAdminUser},
+			wantErr: false,
+		},
+		{
+			name: "Filter And Case",
+			query: types.QueryParams{Filter: types.Filter{
+				{{Field: "first_name", Operator: types.FilterContains, Value: "es"}},
+				{{Field: "updated_at", Operator: types.FilterGt, Value: testData.TestAdminUser.UpdatedAt}},
+			}},
+			want:    []types.User{testData.TestUser},
+			wantErr: false,
+		},
+		{
+			name: "Filter Address Or Case",
+			query: types.QueryParams{Filter: types.Filter{
+				{{Field: "city", Operator: types.FilterPrefix, Value: "ber"}, {Field: "last_name", Operator: types.FilterEq, Value: "admin"}},
+			}},
+			want:    []types.User{testData.TestUser, testData.TestAdminUser},
+			wantErr: false,
+		},
+		{
+			name:    "Filter Unknown Field Case",
+			query:   types.QueryParams{Filter: types.Filter{{{Field: "password_hash", Operator: types.FilterEq, Value: "password"}}}},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userDB.Query(context.Background(), tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.Query() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			assert.Equal(t, len(tt.want), len(got))
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func Test_QuerySort(t *testing.T) {
+	dbName := "test-user-query-sort"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	// both users are created at the same time, so that the order is decided by the id tiebreaker
+	byID := []types.User{testData.TestUser, testData.TestAdminUser}
+	if testData.TestAdminUser.ID.String() < testData.TestUser.ID.String() {
+		byID = []types.User{testData.TestAdminUser, testData.TestUser}
+	}
+
+	// test
+	tests := []struct {
+		name string
+		sort types.Sort
+		want []types.User
+	}{
+		{
+			name: "Ascending Case",
+			sort: types.Sort{{Field: "last_name"}},
+			want: []types.User{testData.TestAdminUser, testData.TestUser},
+		},
+		{
+			name: "Descending Case",
+			sort: types.Sort{{Field: "updated_at", Desc: true}},
+			want: []types.User{testData.TestUser, testData.TestAdminUser},
+		},
+		{
+			name: "Tiebreaker Case",
+			sort: types.Sort{{Field: "created_at"}, {Field: "first_name"}},
+			want: byID,
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userDB.Query(context.Background(), types.QueryParams{Sort: tt.sort})
+			if err != nil {
+				t.Errorf("db.Query() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_QueryFields(t *testing.T) {
+	dbName := "test-user-query-fields"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+
+	got, err := userDB.Query(context.Background(), types.QueryParams{
+		Fields: types.Fields{"first_name"},
+		Sort:   types.Sort{{Field: "last_name"}},
+	})
+	if err != nil {
+		t.Errorf("db.Query() error = %v", err)
+		return
+	}
+
+	// the id and the sort fields are always selected, the other fields are left empty
+	want := []types.User{
+		{ID: testData.TestAdminUser.ID, FirstName: testData.TestAdminUser.FirstName, LastName: testData.TestAdminUser.LastName},
+		{ID: testData.TestUser.ID, FirstName: testData.TestUser.FirstName, LastName: testData.TestUser.LastName},
+	}
+	assert.Equal(t, want, got)
+}
+
+func Test_QuerySearch(t *testing.T) {
+	dbName := "test-user-query-search"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	// test
+	tests := []struct {
+		name   string
+		search string
+		want   []uuid.UUID
+	}{
+		{
+			name:   "Partial Case",
+			search: "admi",
+			want:   []uuid.UUID{testData.TestAdminUser.ID},
+		},
+		{
+			name:   "Misspelled Email Case",
+			search: "admin@tset.com",
+			want:   []uuid.UUID{testData.TestAdminUser.ID},
+		},
+		{
+			name:   "Not Found Case",
+			search: "xyz",
+			want:   []uuid.UUID{},
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userDB.Query(context.Background(), types.QueryParams{
+				Search: tt.search,
+				Sort:   types.Sort{{Field: "relevance", Desc: true}},
+			})
+			if err != nil {
+				t.Errorf("db.Query() error = %v", err)
+				return
+			}
+			ids := make([]uuid.UUID, len(got))
+			for i, user := range got {
+				ids[i] = user.ID
+				assert.Positive(t, user.Relevance)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func Test_QueryPage(t *testing.T) {
+	dbName := "test-user-query-page"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+	sort := types.Sort{{Field: "last_name"}}
+
+	// first page
+	first, err := userDB.QueryPage(context.Background(), types.QueryParams{
+		Sort: sort, Total: true, Pagination: types.Pagination{Limit: 1},
+	})
+	if err != nil {
+		t.Fatalf("db.QueryPage() error = %v", err)
+	}
+	assert.Equal(t, []types.User{testData.TestAdminUser}, first.Items)
+	assert.Equal(t, types.Pointer(int64(2)), first.Total)
+	assert.Nil(t, first.Prev)
+	assert.NotNil(t, first.Next)
+
+	// forward to the last page
+	last, err := userDB.QueryPage(context.Background(), types.QueryParams{
+		Cursor: first.Next, Pagination: types.Pagination{Limit: 1},
+	})
+	if err != nil {
+		t.Fatalf("db.QueryPage() error = %v", err)
+	}
+	assert.Equal(t, []types.User{testData.TestUser}, last.Items)
+	assert.Nil(t, last.Total)
+	assert.Nil(t, last.Next)
+	assert.NotNil(t, last.Prev)
+
+	// back to the first page
+	back, err := userDB.QueryPage(context.Background(), types.QueryParams{
+		Cursor: last.Prev, Pagination: types.Pagination{Limit: 1},
+	})
+	if err != nil {
+		t.Fatalf("db.QueryPage() error = %v", err)
+	}
+	assert.Equal(t, []types.User{testData.TestAdminUser}, back.Items)
+	assert.Nil(t, back.Prev)
+	assert.NotNil(t, back.Next)
+
+	// cursors replace the offset
+	_, err = userDB.QueryPage(context.Background(), types.QueryParams{
+		Cursor: first.Next, Pagination: types.Pagination{Limit: 1, Offset: 1},
+	})
+	assert.ErrorIs(t, err, types.ErrBadRequest)
+}
+
+func Test_Stream(t *testing.T) {
+	dbName := "test-user-stream"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	withoutPassword := func(user types.User) types.User {
+		user.PasswordHash = ""
+		return user
+	}
+
+	oldVersion := withoutPassword(testData.TestUser)
+	oldVersion.VersionID = testData.TestUserOldVersionID
+	oldVersion.IsLatestVersion = false
+	oldVersion.FirstName = "old"
+	oldVersion.UpdatedAt = testData.TestUserOldVersionCreatedAt
+
+	fields := types.Fields{"id", "version_id", "is_latest_version", "first_name", "last_name", "email", "phone", "is_admin", "created_at", "updated_at"}
+	byLastName := types.Filter{{{Field: "last_name", Operator: types.FilterEq, Value: testData.TestUser.LastName}}}
+
+	// test
+	tests := []struct {
+		name    string
+		params  types.QueryParams
+		history bool
+		want    []types.User
+	}{
+		{
+			name:   "Latest Versions Case",
+			params: types.QueryParams{Fields: fields, Sort: types.Sort{{Field: "last_name"}}},
+			want:   []types.User{withoutPassword(testData.TestAdminUser), withoutPassword(testData.TestUser)},
+		},
+		{
+			name:    "History Case",
+			params:  types.QueryParams{Fields: fields, Filter: byLastName},
+			history: true,
+			want:    []types.User{oldVersion, withoutPassword(testData.TestUser)},
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := userDB.Stream
+			if tt.history {
+				stream = userDB.StreamHistory
+			}
+			var got []types.User
+			err := stream(context.Background(), tt.params, func(user types.User) error {
+				got = append(got, user)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("db.Stream() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_GetChanges(t *testing.T) {
+	dbName := "test-user-get-changes"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+	until := time.Now().Add(time.Minute)
+
+	// Both users were created at the same time, the change id breaks the tie
+	got, err := userDB.GetChanges(context.Background(), nil, until, 2)
+	if err != nil {
+		t.Errorf("db.GetChanges() error = %v", err)
+		return
+	}
+	assert.Len(t, got, 2)
+	assert.ElementsMatch(t, []uuid.UUID{testData.TestUserOldVersionID, testData.TestAdminUser.VersionID}, []uuid.UUID{got[0].ID, got[1].ID})
+	for _, change := range got {
+		assert.Equal(t, types.UserChangeCreated, change.Kind)
+		assert.Equal(t, testData.TestUserOldVersionCreatedAt, change.ChangedAt)
+		assert.Empty(t, change.User.PasswordHash)
+	}
+
+	got, err = userDB.GetChanges(context.Background(), got[1].Cursor(), until, 10)
+	if err != nil {
+		t.Errorf("db.GetChanges() error = %v", err)
+		return
+	}
+	assert.Len(t, got, 1)
+	assert.Equal(t, types.UserChangeUpdated, got[0].Kind)
+	assert.Equal(t, testData.TestUser.VersionID, got[0].ID)
+	assert.Equal(t, testData.TestUser.Email, got[0].User.Email)
+
+	// Changes are only returned until the given time
+	if err := userDB.Delete(context.Background(), testData.TestAdminUser.ID); err != nil {
+		t.Errorf("db.Delete() error = %v", err)
+		return
+	}
+	since := got[0].Cursor()
+	got, err = userDB.GetChanges(context.Background(), since, testData.TestUser.UpdatedAt.Add(time.Hour), 10)
+	if err != nil {
+		t.Errorf("db.GetChanges() error = %v", err)
+		return
+	}
+	assert.Empty(t, got)
+
+	got, err = userDB.GetChanges(context.Background(), since, until, 10)
+	if err != nil {
+		t.Errorf("db.GetChanges() error = %v", err)
+		return
+	}
+	assert.Len(t, got, 1)
+	assert.Equal(t, types.UserChangeDeleted, got[0].Kind)
+	assert.Equal(t, testData.TestAdminUser.ID, got[0].ID)
+	assert.Equal(t, testData.TestAdminUser.ID, got[0].User.ID)
+}
+
+func Test_ListenChanges(t *testing.T) {
+	dbName := "test-user-listen-changes"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+	listener := createListener(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	changes, err := listener.ListenChanges(ctx)
+	if err != nil {
+		t.Errorf("listener.ListenChanges() error = %v", err)
+		return
+	}
+
+	created := testData.TestUser
+	created.ID = uuid.Nil
+	created.Email = "new@xyz.com"
+	created, err = userDB.Save(context.Background(), created)
+	if err != nil {
+		t.Errorf("db.Save() error = %v", err)
+		return
+	}
+	updated := created
+	updated.LastName = "updated"
+	updated, err = userDB.Save(context.Background(), updated)
+	if err != nil {
+		t.Errorf("db.Save() error = %v", err)
+		return
+	}
+	if err := userDB.Delete(context.Background(), created.ID); err != nil {
+		t.Errorf("db.Delete() error = %v", err)
+		return
+	}
+
+	// The pushed changes equal the ones of the feed
+	want, err := userDB.GetChanges(context.Background(), &types.Cursor{
+		Sort:   types.UserChangeSort,
+		Values: []any{testData.TestUser.UpdatedAt, testData.TestUser.VersionID},
+	}, time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Errorf("db.GetChanges() error = %v", err)
+		return
+	}
+	assert.Equal(t, []types.UserChangeKind{types.UserChangeCreated, types.UserChangeUpdated, types.UserChangeDeleted},
+		[]types.UserChangeKind{want[0].Kind, want[1].Kind, want[2].Kind})
+	for _, w := range want {
+		select {
+		case got := <-changes:
+			assert.Equal(t, w.Kind, got.Kind)
+			assert.Equal(t, w.ID, got.ID)
+			assert.True(t, w.ChangedAt.Equal(got.ChangedAt))
+			assert.Equal(t, w.User.Email, got.User.Email)
+		case <-ctx.Done():
+			t.Errorf("change %v was not pushed", w.ID)
+			return
+		}
+	}
+	assert.Equal(t, updated.VersionID, want[1].ID)
+
+	// The channel is closed once the context is done
+	cancel()
+	for range changes {
+	}
+}
+
+func Test_Save(t *testing.T) {
+	dbName := "test-user-save"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	TestUser1 := testData.TestUser
+	TestUser1.LastName = "user 1"
+
+	TestUser2 := testData.TestUser
+	TestUser2.ID = uuid.Nil
+	TestUser2.LastName = "user 2"
+	TestUser2.Email = "user2@test.com"
+
+	// Emails are unique case-insensitively across the latest versions of users
+	DuplicateUser := TestUser2
+	DuplicateUser.Email = "ADMIN@test.com"
+
+	DuplicateUpdate := testData.TestUser
+	DuplicateUpdate.Email = testData.TestAdminUser.Email
+
+	// test
+	tests := []struct {
+		name      string
+		user      types.User
+		want      types.User
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name:    "Update Case",
+			user:    TestUser1,
+			want:    TestUser1,
+			wantErr: false,
+		},
+		{
+			name:    "New Case",
+			user:    TestUser2,
+			want:    TestUser2,
+			wantErr: false,
+		},
+		{
+			name:      "Duplicate Email Case",
+			user:      DuplicateUser,
+			wantErr:   true,
+			wantErrIs: types.ErrEmailTaken,
+		},
+		{
+			name:      "Duplicate Email Update Case",
+			user:      DuplicateUpdate,
+			wantErr:   true,
+			wantErrIs: types.ErrEmailTaken,
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved, err := userDB.Save(context.Background(), tt.user)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.Save() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				return
+			}
+			if tt.want.ID == uuid.Nil {
+				tt.want.ID = saved.ID
+				tt.want.CreatedAt = saved.CreatedAt
+			}
+			tt.want.VersionID = saved.VersionID
+			tt.want.UpdatedAt = saved.UpdatedAt
+			assert.Equal(t, tt.want.ID, saved.ID)
+			got, err := userDB.Get(context.Background(), saved.ID)
+			if err != nil {
+				t.Errorf("db.Get() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SaveConditional(t *testing.T) {
+	dbName := "test-user-save-conditional"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	stale := testData.TestUser
+	stale.LastName = "stale"
+	stale.ExpectedVersionID = testData.TestUserOldVersionID
+
+	latest := testData.TestUser
+	latest.LastName = "latest"
+	latest.ExpectedVersionID = testData.TestUser.VersionID
+
+	// test
+	tests := []struct {
+		name    string
+		user    types.User
+		wantErr error
+	}{
+		{
+			name:    "Stale Version Case",
+			user:    stale,
+			wantErr: types.ErrVersionMismatch,
+		},
+		{
+			name:    "Latest Version Case",
+			user:    latest,
+			wantErr: nil,
+		},
+		{
+			// the previous case created a new version
+			name:    "Outdated Version Case",
+			user:    latest,
+			wantErr: types.ErrVersionMismatch,
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := userDB.Get(context.Background(), tt.user.ID)
+			if err != nil {
+				t.Errorf("db.Get() error = %v", err)
+				return
+			}
+			saved, err := userDB.Save(context.Background(), tt.user)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorIs(t, err, types.ErrPreconditionFailed)
+				// nothing is saved
+				got, err := userDB.Get(context.Background(), tt.user.ID)
+				assert.NoError(t, err)
+				assert.Equal(t, before, got)
+				return
+			}
+			if err != nil {
+				t.Errorf("db.Save() error = %v", err)
+				return
+			}
+			assert.Equal(t, uuid.Nil, saved.ExpectedVersionID)
+			got, err := userDB.Get(context.Background(), tt.user.ID)
+			if err != nil {
+				t.Errorf("db.Get() error = %v", err)
+				return
+			}
+			assert.Equal(t, saved, got)
+		})
+	}
+}
+
+func Test_SaveBatch(t *testing.T) {
+	dbName := "test-user-save-batch"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	updated := testData.TestUser
+	updated.LastName = "updated"
+
+	created := testData.TestUser
+	created.ID = uuid.Nil
+	created.Email = "new@xyz.com"
+
+	missing := testData.TestUser
+	missing.ID = uuid.New()
+
+	// test
+	tests := []struct {
+		name    string
+		users   []types.User
+		wantErr bool
+	}{
+		{
+			name:    "Success Case",
+			users:   []types.User{updated, created},
+			wantErr: false,
+		},
+		{
+			name:    "Not Found Case",
+			users:   []types.User{created, missing},
+			wantErr: true,
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved, err := userDB.SaveBatch(context.Background(), tt.users)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.SaveBatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				// nothing of a failed batch is saved
+				assert.ErrorIs(t, err, types.ErrNotFound)
+				_, err := userDB.Get(context.Background(), saved[0].ID)
+				assert.ErrorIs(t, err, types.ErrNotFound)
+				return
+			}
+			for _, want := range saved {
+				got, err := userDB.Get(context.Background(), want.ID)
+				if err != nil {
+					t.Errorf("db.Get() error = %v", err)
+					return
+				}
+				assert.Equal(t, want, got)
+			}
+		})
+	}
+}
+
+func Test_MutateBatch(t *testing.T) {
+	dbName := "test-user-mutate-batch"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	created := testData.TestUser
+	created.ID = uuid.Nil
+	created.Email = "new@xyz.com"
+
+	patch := types.UserPatch{LastName: types.Optional[string]{HasValue: true, Value: "patched"}}
+
+	// test
+	tests := []struct {
+		name     string
+		ops      []types.UserOperation
+		atomic   bool
+		wantErrs []error
+		wantErr  bool
+	}{
+		{
+			name: "Atomic Rollback Case",
+			ops: []types.UserOperation{
+				{Kind: types.UserOperationPatch, ID: testData.TestUser.ID, Patch: patch},
+				{Kind: types.UserOperationDelete, ID: uuid.New()},
+				{Kind: types.UserOperationDelete, ID: testData.TestAdminUser.ID},
+			},
+			atomic:   true,
+			wantErrs: []error{types.ErrBatchAborted, types.ErrNotFound, types.ErrBatchAborted},
+			wantErr:  true,
+		},
+		{
+			name: "Per Item Case",
+			ops: []types.UserOperation{
+				{Kind: types.UserOperationCreate, User: created},
+				{Kind: types.UserOperationPatch, ID: uuid.New(), Patch: patch},
+				{Kind: types.UserOperationPatch, ID: testData.TestUser.ID, Patch: patch},
+			},
+			atomic:   false,
+			wantErrs: []error{nil, types.ErrNotFound, nil},
+			wantErr:  false,
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := userDB.MutateBatch(context.Background(), tt.ops, tt.atomic)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.MutateBatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Len(t, results, len(tt.ops))
+			for i, result := range results {
+				if tt.wantErrs[i] != nil {
+					assert.ErrorIs(t, result.Err, tt.wantErrs[i])
+					continue
+				}
+				assert.NoError(t, result.Err)
+				got, err := userDB.Get(context.Background(), result.User.ID)
+				if err != nil {
+					t.Errorf("db.Get() error = %v", err)
+					return
+				}
+				assert.Equal(t, result.User, got)
+			}
+			if tt.atomic {
+				// nothing of a failed atomic batch is applied
+				got, err := userDB.Get(context.Background(), testData.TestUser.ID)
+				assert.NoError(t, err)
+				assert.Equal(t, testData.TestUser, got)
+				_, err = userDB.Get(context.Background(), testData.TestAdminUser.ID)
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, "patched", results[2].User.LastName)
+			}
+		})
+	}
+
+	t.Run("Unchanged Patch Case", func(t *testing.T) {
+		ctx := context.Background()
+		before, err := userDB.Get(ctx, testData.TestAdminUser.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		versions, err := userDB.CountHistory(ctx, before.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		results, err := userDB.MutateBatch(ctx, []types.UserOperation{
+			{Kind: types.UserOperationPatch, ID: before.ID},
+			{Kind: types.UserOperationPatch, ID: before.ID, Patch: types.UserPatch{LastName: types.ToOptional(before.LastName)}},
+		}, true)
+		if !assert.NoError(t, err) || !assert.Len(t, results, 2) {
+			return
+		}
+		// patches which change nothing don't create a version
+		assert.Equal(t, before, results[0].User)
+		assert.Equal(t, before, results[1].User)
+		got, err := userDB.CountHistory(ctx, before.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, versions, got)
+	})
+}
+
+func Test_UnitOfWork(t *testing.T) {
+	dbName := "test-user-unit-of-work"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+	errAbort := errors.New("abort")
+	newUser := func(email string) types.User {
+		user := testData.TestUser
+		user.ID = uuid.Nil
+		user.Email = email
+		return user
+	}
+
+	// test
+	tests := []struct {
+		name string
+		// fn saves users in units of work, the kept ones have to exist afterwards and the discarded ones not
+		fn            func(ctx context.Context, onCommit func()) (kept, discarded []uuid.UUID, err error)
+		wantCommitted int
+		wantErr       error
 	}{
 		{
-			name:    "Success Case",
-			query:   types.QueryParams{Conditions: &types.UserPatch{LastName: types.ToOptional("user")}},
-			want:    []types.User{testData.TestUser},
-			wantErr: false,
+			name: "Commit Case",
+			fn: func(ctx context.Context, onCommit func()) (kept, discarded []uuid.UUID, err error) {
+				err = transaction.Do(ctx, db, func(ctx context.Context) error {
+					user, err := userDB.Save(ctx, newUser("commit@xyz.com"))
+					kept = append(kept, user.ID)
+					transaction.AfterCommit(ctx, onCommit)
+					return err
+				})
+				return kept, nil, err
+			},
+			wantCommitted: 1,
 		},
 		{
-			name:    "Not Found Case",
-			query:   types.QueryParams{Conditions: &types.UserPatch{FirstName: types.ToOptional("not found")}},
-			want:    []types.User{},
-			wantErr: false,
+			name: "Rollback Case",
+			fn: func(ctx context.Context, onCommit func()) (kept, discarded []uuid.UUID, err error) {
+				err = transaction.Do(ctx, db, func(ctx context.Context) error {
+					user, err := userDB.Save(ctx, newUser("rollback@xyz.com"))
+					if err != nil {
+						return err
+					}
+					discarded = append(discarded, user.ID)
+					transaction.AfterCommit(ctx, onCommit)
+					return errAbort
+				})
+				return nil, discarded, err
+			},
+			wantErr: errAbort,
+		},
+		{
+			name: "Nested Rollback Case",
+			fn: func(ctx context.Context, onCommit func()) (kept, discarded []uuid.UUID, err error) {
+				err = transaction.Do(ctx, db, func(ctx context.Context) error {
+					_ = transaction.Do(ctx, db, func(ctx context.Context) error {
+						user, err := userDB.Save(ctx, newUser("nested@xyz.com"))
+						if err != nil {
+							return err
+						}
+						discarded = append(discarded, user.ID)
+						transaction.AfterCommit(ctx, onCommit)
+						return errAbort
+					})
+					user, err := userDB.Save(ctx, newUser("outer@xyz.com"))
+					kept = append(kept, user.ID)
+					transaction.AfterCommit(ctx, onCommit)
+					return err
+				})
+				return kept, discarded, err
+			},
+			wantCommitted: 1,
 		},
 	}
 
-	userDB := create(db)
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := userDB.Query(context.Background(), tt.query)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("db.Query() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			} else if err != nil {
+			committed := 0
+			kept, discarded, err := tt.fn(context.Background(), func() { committed++ })
+			assert.Equal(t, tt.wantCommitted, committed)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else if !assert.NoError(t, err) {
 				return
 			}
-			assert.Equal(t, len(tt.want), len(got))
-			assert.ElementsMatch(t, tt.want, got)
+			for _, id := range kept {
+				_, err := userDB.Get(context.Background(), id)
+				assert.NoError(t, err)
+			}
+			for _, id := range discarded {
+				_, err := userDB.Get(context.Background(), id)
+				assert.ErrorIs(t, err, types.ErrNotFound)
+			}
 		})
 	}
 }
 
-func Test_Save(t *testing.T) {
-	dbName := "test-user-save"
+func Test_RelayEvents(t *testing.T) {
+	dbName := "test-user-relay-events"
 	db := postgres.Test_Create_DB(ip, port, dbName)
 	defer postgres.Test_Drop_DB(db, ip, port, dbName)
 	testData.MigrateTestData(db)
 
-	TestUser1 := testData.TestUser
-	TestUser1.LastName = "user 1"
+	userDB := create(db)
+	ctx := context.Background()
 
-	TestUser2 := testData.TestUser
-	TestUser2.ID = uuid.Nil
-	TestUser2.LastName = "user 2"
+	// create, update the password, update something else and delete a user
+	user := testData.TestUser
+	user.ID = uuid.Nil
+	user.Email = "events@xyz.com"
+	created, err := userDB.Save(ctx, user)
+	if !assert.NoError(t, err) {
+		return
+	}
+	created.PasswordHash = "new hash"
+	passwordChanged, err := userDB.Save(ctx, created)
+	if !assert.NoError(t, err) {
+		return
+	}
+	passwordChanged.LastName = "renamed"
+	renamed, err := userDB.Save(ctx, passwordChanged)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, userDB.Delete(ctx, created.ID)) {
+		return
+	}
+	want := []types.UserEvent{
+		{Kind: types.UserCreated, UserID: created.ID, VersionID: created.VersionID},
+		{Kind: types.UserUpdated, UserID: created.ID, VersionID: passwordChanged.VersionID, PreviousVersionID: created.VersionID},
+		{Kind: types.PasswordChanged, UserID: created.ID, VersionID: passwordChanged.VersionID},
+		{Kind: types.UserUpdated, UserID: created.ID, VersionID: renamed.VersionID, PreviousVersionID: passwordChanged.VersionID},
+		{Kind: types.UserDeleted, UserID: created.ID},
+	}
 
 	// test
 	tests := []struct {
-		name    string
-		user    types.User
-		want    types.User
-		wantErr bool
+		name       string
+		publishErr error
+		want       []types.UserEvent
+		wantErr    error
 	}{
 		{
-			name:    "Update Case",
-			user:    TestUser1,
-			want:    TestUser1,
-			wantErr: false,
+			name:       "Publish Failure Case",
+			publishErr: errors.New("broker unavailable"),
+			want:       want,
+			wantErr:    errors.New("broker unavailable"),
 		},
 		{
-			name:    "New Case",
-			user:    TestUser2,
-			want:    TestUser2,
-			wantErr: false,
+			name: "Success Case",
+			want: want,
+		},
+		{
+			name: "Empty Case",
+			want: nil,
 		},
 	}
 
-	userDB := create(db)
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			saved, err := userDB.Save(context.Background(), tt.user)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("db.Save() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			} else if err != nil {
-				return
-			}
-			if tt.want.ID == uuid.Nil {
-				tt.want.ID = saved.ID
+			var got []types.UserEvent
+			count, err := userDB.RelayEvents(ctx, 100, func(_ context.Context, events []types.UserEvent) error {
+				got = events
+				return tt.publishErr
+			})
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Zero(t, count)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, len(tt.want), count)
 			}
-			tt.want.VersionID = saved.VersionID
-			assert.Equal(t, tt.want.ID, saved.ID)
-			got, err := userDB.Get(context.Background(), saved.ID)
-			if err != nil {
-				t.Errorf("db.Get() error = %v", err)
-				return
+			if assert.Len(t, got, len(tt.want)) {
+				for i := range got {
+					assert.NotEqual(t, uuid.Nil, got[i].ID)
+					got[i].ID, got[i].OccurredAt = uuid.Nil, time.Time{}
+				}
+				assert.Equal(t, tt.want, got)
 			}
-			assert.Equal(t, tt.want, got)
 		})
 	}
+
+	t.Run("Audit Log Case", func(t *testing.T) {
+		// published events are kept as the audit log of the user
+		got, err := userDB.GetEvents(ctx, created.ID)
+		if assert.NoError(t, err) && assert.Len(t, got, len(want)) {
+			for i := range got {
+				got[i].ID, got[i].OccurredAt = uuid.Nil, time.Time{}
+			}
+			assert.Equal(t, want, got)
+		}
+	})
 }
 
 func Test_Delete(t *testing.T) {
@@ -219,6 +1122,83 @@ func Test_Delete(t *testing.T) {
 	}
 }
 
+func Test_DeleteReleasesEmail(t *testing.T) {
+	dbName := "test-user-delete-releases-email"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+
+	if err := userDB.Delete(context.Background(), testData.TestUser.ID); err != nil {
+		t.Errorf("db.Delete() error = %v", err)
+		return
+	}
+
+	// The email of a deleted user can be registered again
+	user := testData.TestUser
+	user.ID = uuid.Nil
+	saved, err := userDB.Save(context.Background(), user)
+	if err != nil {
+		t.Errorf("db.Save() error = %v", err)
+		return
+	}
+	got, err := userDB.GetByEmail(context.Background(), testData.TestUser.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, saved.ID, got.ID)
+}
+
+func Test_DeleteVersion(t *testing.T) {
+	dbName := "test-user-delete-version"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	// test
+	tests := []struct {
+		name      string
+		id        uuid.UUID
+		versionID uuid.UUID
+		wantErr   error
+	}{
+		{
+			name:      "Stale Version Case",
+			id:        testData.TestUser.ID,
+			versionID: testData.TestUserOldVersionID,
+			wantErr:   types.ErrVersionMismatch,
+		},
+		{
+			name:      "Not Found Case",
+			id:        uuid.New(),
+			versionID: uuid.New(),
+			wantErr:   types.ErrNotFound,
+		},
+		{
+			name:      "Success Case",
+			id:        testData.TestUser.ID,
+			versionID: testData.TestUser.VersionID,
+			wantErr:   nil,
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := userDB.DeleteVersion(context.Background(), tt.id, tt.versionID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			} else if err != nil {
+				t.Errorf("db.DeleteVersion() error = %v", err)
+				return
+			}
+			_, err = userDB.Get(context.Background(), tt.id)
+			assert.ErrorIs(t, err, types.ErrNotFound)
+		})
+	}
+}
+
 func Test_GetVersion(t *testing.T) {
 	dbName := "test-user-get-version"
 	db := postgres.Test_Create_DB(ip, port, dbName)
@@ -229,6 +1209,7 @@ func Test_GetVersion(t *testing.T) {
 	wantOldVersion.VersionID = testData.TestUserOldVersionID
 	wantOldVersion.IsLatestVersion = false
 	wantOldVersion.FirstName = "old"
+	wantOldVersion.UpdatedAt = testData.TestUserOldVersionCreatedAt
 
 	// test
 	tests := []struct {
@@ -292,6 +1273,12 @@ func Test_GetByEmail(t *testing.T) {
 			want:    testData.TestUser,
 			wantErr: false,
 		},
+		{
+			name:    "Case Insensitive Case",
+			email:   "Test@TEST.com",
+			want:    testData.TestUser,
+			wantErr: false,
+		},
 		{
 			name:    "Not Found Case",
 			email:   "not@fou.nd",
@@ -315,3 +1302,271 @@ func Test_GetByEmail(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetByEmails(t *testing.T) {
+	dbName := "test-user-get-by-emails"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+
+	got, err := userDB.GetByEmails(context.Background(), []string{testData.TestUser.Email, testData.TestAdminUser.Email, "not@fou.nd"})
+	if err != nil {
+		t.Errorf("db.GetByEmails() error = %v", err)
+		return
+	}
+	assert.ElementsMatch(t, []types.User{testData.TestUser, testData.TestAdminUser}, got)
+}
+
+func Test_GetHistory(t *testing.T) {
+	dbName := "test-user-get-history"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	wantOldVersion := testData.TestUser
+	wantOldVersion.VersionID = testData.TestUserOldVersionID
+	wantOldVersion.IsLatestVersion = false
+	wantOldVersion.FirstName = "old"
+	wantOldVersion.UpdatedAt = testData.TestUserOldVersionCreatedAt
+
+	// test
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		want    []types.User
+		wantErr bool
+	}{
+		{
+			name:    "Success Case",
+			id:      testData.TestUser.ID,
+			want:    []types.User{wantOldVersion, testData.TestUser},
+			wantErr: false,
+		},
+		{
+			name:    "Not Found Case",
+			id:      uuid.New(),
+			want:    []types.User{},
+			wantErr: false,
+		},
+	}
+
+	userDB := create(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userDB.GetHistory(context.Background(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("db.GetHistory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				return
+			}
+			assert.Equal(t, len(tt.want), len(got))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i], got[i])
+			}
+
+			count, err := userDB.CountHistory(context.Background(), tt.id)
+			if err != nil {
+				t.Errorf("db.CountHistory() error = %v", err)
+				return
+			}
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+}
+
+func Test_GetHistories(t *testing.T) {
+	dbName := "test-user-get-histories"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	testData.MigrateTestData(db)
+
+	userDB := create(db)
+
+	got, err := userDB.GetHistories(context.Background(), []uuid.UUID{testData.TestUser.ID, testData.TestAdminUser.ID, uuid.New()})
+	if err != nil {
+		t.Errorf("db.GetHistories() error = %v", err)
+		return
+	}
+
+	// the versions are grouped by user and ordered by creation within each user
+	counts := map[uuid.UUID]int{}
+	for i, version := range got {
+		counts[version.ID]++
+		if i > 0 && got[i-1].ID == version.ID {
+			assert.False(t, got[i-1].UpdatedAt.After(version.UpdatedAt))
+		}
+	}
+	assert.Equal(t, map[uuid.UUID]int{testData.TestUser.ID: 2, testData.TestAdminUser.ID: 1}, counts)
+}
+
+const (
+	benchmarkUsers    = 10000
+	benchmarkVersions = 10
+)
+
+// seedBenchmarkDB creates users with several versions each, like migrated users their latest versions are copied to
+// user_current by the query of the v7 migration
+func seedBenchmarkDB(db *gorm.DB) {
+	for _, seed := range []struct {
+		query string
+		args  []any
+	}{
+		{
+			query: "INSERT INTO users (id, created_at) SELECT gen_random_uuid(), now() - interval '1 year' FROM generate_series(1, ?)",
+			args:  []any{benchmarkUsers},
+		},
+		{
+			query: `INSERT INTO user_versions (id, created_at, user_id, first_name, last_name, email, phone, is_admin, password_hash)
+			SELECT gen_random_uuid(), users.created_at + version * interval '1 day', users.id, 'first' || version,
+				md5(users.id::text), md5(users.id::text) || '@test.com', '+49123456789', random() < 0.1, 'password'
+			FROM users CROSS JOIN generate_series(1, ?) AS version`,
+			args: []any{benchmarkVersions},
+		},
+		{
+			query: `INSERT INTO user_current (user_id, id, created_at, first_name, last_name, email, phone, is_admin, password_hash)
+			SELECT DISTINCT ON (user_id) user_id, id, created_at, first_name, last_name, email, phone, is_admin, password_hash
+			FROM user_versions ORDER BY user_id, created_at DESC`,
+		},
+		{query: "ANALYZE"},
+	} {
+		if err := db.Exec(seed.query, seed.args...).Error; err != nil {
+			panic(err)
+		}
+	}
+}
+
+// distinctOnQuery is lastVersionQuery before the v7 migration, selecting the latest versions out of all versions
+func distinctOnQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Joins("JOIN (?) AS last_version ON users.id = last_version.user_id",
+		tx.Session(&gorm.Session{NewDB: true}).Table("user_versions").
+			Select("DISTINCT ON (user_id) *").Order("user_id, created_at DESC"),
+	).Select(lastVersionColumns.selectColumns(nil)).Where("users.deleted_at IS NULL")
+}
+
+func Benchmark_LastVersionQuery(b *testing.B) {
+	dbName := "bench-user-last-version-query"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	seedBenchmarkDB(db)
+
+	var id uuid.UUID
+	if err := db.Table("users").Select("id").Limit(1).Row().Scan(&id); err != nil {
+		b.Fatal(err)
+	}
+
+	// benchmark
+	benchmarks := []struct {
+		name  string
+		query func(tx *gorm.DB) *gorm.DB
+	}{
+		{
+			name:  "Get Case",
+			query: func(tx *gorm.DB) *gorm.DB { return tx.Where("users.id = ?", id) },
+		},
+		{
+			name:  "Sorted Page Case",
+			query: func(tx *gorm.DB) *gorm.DB { return tx.Order("last_version.last_name, users.id").Limit(20) },
+		},
+		{
+			name: "Filtered Page Case",
+			query: func(tx *gorm.DB) *gorm.DB {
+				return tx.Where("last_version.is_admin").Order("users.id").Limit(20)
+			},
+		},
+		{
+			name: "Search Case",
+			query: func(tx *gorm.DB) *gorm.DB {
+				rank := relevance("first10")
+				return tx.Where("? <% "+searchText("last_version"), "first10").
+					Order(clause.OrderBy{Expression: clause.Expr{SQL: rank.SQL + " DESC", Vars: rank.Vars}}).Limit(20)
+			},
+		},
+	}
+
+	queries := []struct {
+		name  string
+		query func(tx *gorm.DB) *gorm.DB
+	}{
+		{name: "Distinct On", query: distinctOnQuery},
+		{name: "User Current", query: lastVersionQuery},
+	}
+
+	for _, bb := range benchmarks {
+		for _, q := range queries {
+			b.Run(bb.name+"/"+q.name, func(b *testing.B) {
+				for range b.N {
+					var users []types.User
+					if err := bb.query(q.query(db.Table("users"))).Find(&users).Error; err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func Benchmark_Save(b *testing.B) {
+	dbName := "bench-user-save"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+	seedBenchmarkDB(db)
+
+	userDB := create(db)
+	user, err := userDB.Save(context.Background(), types.User{
+		FirstName:    "bench",
+		LastName:     "user",
+		Email:        "bench@test.com",
+		Phone:        "+49123456789",
+		PasswordHash: "password",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// benchmark
+	// Version Only is a save before the v7 migration, the difference to Version And Current is the cost of
+	// maintaining user_current, Save adds the base user lookup and the outbox events
+	benchmarks := []struct {
+		name string
+		save func(tx *gorm.DB, version map[string]any) error
+	}{
+		{
+			name: "Version Only",
+			save: func(tx *gorm.DB, version map[string]any) error {
+				return tx.Table("user_versions").Create(version).Error
+			},
+		},
+		{
+			name: "Version And Current",
+			save: func(tx *gorm.DB, version map[string]any) error {
+				if err := tx.Table("user_versions").Create(version).Error; err != nil {
+					return err
+				}
+				return saveCurrent(tx, []map[string]any{version})
+			},
+		},
+	}
+
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			for range b.N {
+				_, version := user.ToSave()
+				if err := db.Transaction(func(tx *gorm.DB) error { return bb.save(tx, version) }); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	b.Run("Save", func(b *testing.B) {
+		for range b.N {
+			if user, err = userDB.Save(context.Background(), user); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
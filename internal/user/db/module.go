@@ -8,10 +8,25 @@ import (
 
 var FXUserDBProvide = fx.Provide(
 	create,
+	createListener,
 	func(d *db) datasource.Getter[types.User] { return d },
+	func(d *db) datasource.ManyGetter[types.User] { return d },
 	func(d *db) datasource.Querier[types.User] { return d },
+	func(d *db) datasource.PageQuerier[types.User] { return d },
+	func(d *db) datasource.Streamer[types.User] { return d },
+	func(d *db) datasource.HistoryStreamer[types.User] { return d },
 	func(d *db) datasource.Saver[types.User] { return d },
+	func(d *db) datasource.BatchSaver[types.User] { return d },
 	func(d *db) datasource.Deleter[types.User] { return d },
+	func(d *db) datasource.VersionedDeleter[types.User] { return d },
+	func(d *db) datasource.UserBatchMutator { return d },
 	func(d *db) datasource.VersionGetter[types.User] { return d },
 	func(d *db) datasource.UserByEmailGetter { return d },
+	func(d *db) datasource.UsersByEmailsGetter { return d },
+	func(d *db) datasource.HistoryGetter[types.User] { return d },
+	func(d *db) datasource.UserChangesGetter { return d },
+	func(d *db) datasource.UserEventSaver { return d },
+	func(d *db) datasource.UserEventRelayer { return d },
+	func(d *db) datasource.UserEventGetter { return d },
+	func(l *listener) datasource.UserChangesListener { return l },
 )
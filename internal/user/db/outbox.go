@@ -0,0 +1,131 @@
+package userDB
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/transaction"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// saveEvents writes the events to the outbox, see the v8 migration
+func saveEvents(tx *gorm.DB, events []types.UserEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rows := make([]map[string]any, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return errors.Join(types.ErrInternal, err)
+		}
+		rows[i] = map[string]any{
+			"id":          event.ID,
+			"kind":        string(event.Kind),
+			"user_id":     event.UserID,
+			"occurred_at": event.OccurredAt,
+			"payload":     string(payload),
+		}
+	}
+	return types.DBError(tx.Table("outbox").Create(&rows).Error)
+}
+
+// versionEvents returns the events of the new versions of the users, before they are made the current ones.
+// A version with another password hash than the version before also changed the password.
+func versionEvents(tx *gorm.DB, users []types.User) ([]types.UserEvent, error) {
+	var ids []uuid.UUID
+	for _, user := range users {
+		if !isCreated(user) {
+			ids = append(ids, user.ID)
+		}
+	}
+	previous := make(map[uuid.UUID]types.User, len(users))
+	if len(ids) > 0 {
+		var current []types.User
+		if err := tx.Table("user_current").Select("user_id AS id", "id AS version_id", "password_hash").
+			Where("user_id IN ?", ids).Find(&current).Error; err != nil {
+			return nil, types.DBError(err)
+		}
+		for _, user := range current {
+			previous[user.ID] = user
+		}
+	}
+
+	events := make([]types.UserEvent, 0, len(users))
+	for _, user := range users {
+		if isCreated(user) {
+			events = append(events, types.NewUserCreated(user))
+		} else {
+			events = append(events, types.NewUserUpdated(user, previous[user.ID].VersionID))
+			if user.PasswordHash != previous[user.ID].PasswordHash {
+				events = append(events, types.NewPasswordChanged(user))
+			}
+		}
+		previous[user.ID] = user
+	}
+	return events, nil
+}
+
+// isCreated reports whether the saved version is the first one of the user, see User.ToSave
+func isCreated(user types.User) bool {
+	return user.CreatedAt.Equal(user.UpdatedAt)
+}
+
+func (d *db) SaveEvents(ctx context.Context, events []types.UserEvent) error {
+	return saveEvents(d.conn(ctx), events)
+}
+
+// RelayEvents locks the oldest unpublished events, skipping the ones locked by other relayers, and marks them as
+// published in the same transaction once they are published, they are kept as the audit log of the users. If the transaction fails after publishing, the events are published again.
+// The transaction is a unit of work, publishers that write to the database join it.
+func (d *db) RelayEvents(ctx context.Context, limit int, publish func(ctx context.Context, events []types.UserEvent) error) (int, error) {
+	var count int
+	err := transaction.Do(ctx, d.DB, func(ctx context.Context) error {
+		tx := d.conn(ctx)
+		var rows []struct {
+			ID      uuid.UUID `gorm:"column:id"`
+			Payload string    `gorm:"column:payload"`
+		}
+		if err := tx.Table("outbox").Select("id", "payload").Where("published_at IS NULL").Order("position").Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Find(&rows).Error; err != nil {
+			return types.DBError(err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(rows))
+		events := make([]types.UserEvent, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+			if err := json.Unmarshal([]byte(row.Payload), &events[i]); err != nil {
+				return errors.Join(types.ErrInternal, errors.Wrapf(err, "event %s", row.ID))
+			}
+		}
+		if err := publish(ctx, events); err != nil {
+			return err
+		}
+		if err := tx.Table("outbox").Where("id IN ?", ids).Update("published_at", time.Now().UTC()).Error; err != nil {
+			return types.DBError(err)
+		}
+		count = len(events)
+		return nil
+	})
+	return count, err
+}
+
+// GetEvents returns the events of the user in the order they were written, published or not, see the v10 migration
+func (d *db) GetEvents(ctx context.Context, userID uuid.UUID) ([]types.UserEvent, error) {
+	var events []types.UserEvent
+	if err := d.conn(ctx).Table("outbox").Where("user_id = ?", userID).Order("position").
+		Pluck("payload", &events).Error; err != nil {
+		return nil, types.DBError(err)
+	}
+	return events, nil
+}
@@ -0,0 +1,175 @@
+package userExport
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+const (
+	// bulkFlushSize is the amount of rows after which the written rows are flushed to the output
+	bulkFlushSize = 1000
+	// parquetRowGroupSize is the amount of rows per parquet row group, which are buffered until written
+	parquetRowGroupSize = 50000
+)
+
+// BulkExporter streams the users matching a query in tabular formats, e.g. for analytics
+type BulkExporter struct {
+	streamer        datasource.Streamer[types.User]
+	historyStreamer datasource.HistoryStreamer[types.User]
+}
+
+func createBulk(
+	streamer datasource.Streamer[types.User],
+	historyStreamer datasource.HistoryStreamer[types.User],
+) *BulkExporter {
+	return &BulkExporter{
+		streamer:        streamer,
+		historyStreamer: historyStreamer,
+	}
+}
+
+// Export writes the users matching the params to w without password hashes, with history all of their versions.
+// Nothing is written to w before the first rows are flushed, so that errors of the query can still be reported.
+// Only admins can export users.
+func (e *BulkExporter) Export(ctx context.Context, actor usecase.Actor, w io.Writer, params types.QueryParams, format types.BulkExportFormat, history bool) error {
+	if err := actor.RequireAdmin(); err != nil {
+		return err
+	}
+	rows := newRowWriter(w, format)
+	params.Fields = types.UserExportFields
+
+	stream := e.streamer.Stream
+	if history {
+		stream = e.historyStreamer.StreamHistory
+	}
+
+	count := 0
+	if err := stream(ctx, params, func(user types.User) error {
+		if err := rows.Write(dtos.FromUserExportRow(&user)); err != nil {
+			return err
+		}
+		if count++; count%bulkFlushSize == 0 {
+			return rows.Flush()
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// rowWriter writes the rows of an export in a format
+type rowWriter interface {
+	Write(row dtos.UserExportRow) error
+	// Flush passes the written rows on to the output
+	Flush() error
+	// Close completes the output
+	Close() error
+}
+
+func newRowWriter(w io.Writer, format types.BulkExportFormat) rowWriter {
+	switch format {
+	case types.BulkExportFormatNDJSON:
+		buffer := bufio.NewWriter(w)
+		return &ndjsonWriter{buffer: buffer, encoder: json.NewEncoder(buffer), output: w}
+	case types.BulkExportFormatParquet:
+		return &parquetWriter{writer: parquet.NewGenericWriter[dtos.UserExportRow](w, parquet.MaxRowsPerRowGroup(parquetRowGroupSize))}
+	default:
+		return &csvWriter{writer: csv.NewWriter(w), output: w}
+	}
+}
+
+// flushOutput flushes outputs which buffer themselves, e.g. http.ResponseWriter
+func flushOutput(w io.Writer) {
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+}
+
+type csvWriter struct {
+	writer        *csv.Writer
+	output        io.Writer
+	headerWritten bool
+}
+
+func (c *csvWriter) Write(row dtos.UserExportRow) error {
+	if !c.headerWritten {
+		if err := c.writer.Write(dtos.UserExportColumns); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	return c.writer.Write(row.CSV())
+}
+
+func (c *csvWriter) Flush() error {
+	c.writer.Flush()
+	flushOutput(c.output)
+	return c.writer.Error()
+}
+
+func (c *csvWriter) Close() error {
+	if !c.headerWritten {
+		if err := c.writer.Write(dtos.UserExportColumns); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	return c.Flush()
+}
+
+type ndjsonWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	output  io.Writer
+}
+
+func (n *ndjsonWriter) Write(row dtos.UserExportRow) error {
+	return n.encoder.Encode(row)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	if err := n.buffer.Flush(); err != nil {
+		return err
+	}
+	flushOutput(n.output)
+	return nil
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.Flush()
+}
+
+// parquetWriter collects the rows to write them at once, the parquet writer itself buffers whole row groups
+type parquetWriter struct {
+	writer *parquet.GenericWriter[dtos.UserExportRow]
+	rows   []dtos.UserExportRow
+}
+
+func (p *parquetWriter) Write(row dtos.UserExportRow) error {
+	p.rows = append(p.rows, row)
+	return nil
+}
+
+func (p *parquetWriter) Flush() error {
+	_, err := p.writer.Write(p.rows)
+	p.rows = p.rows[:0]
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	return p.writer.Close()
+}
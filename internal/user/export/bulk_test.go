@@ -0,0 +1,154 @@
+package userExport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+// testStreamer streams the users from memory, the history has two versions per user
+type testStreamer struct {
+	users  []types.User
+	err    error
+	params types.QueryParams
+}
+
+func (s *testStreamer) Stream(_ context.Context, params types.QueryParams, fn func(types.User) error) error {
+	s.params = params
+	for _, user := range s.users {
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func (s *testStreamer) StreamHistory(_ context.Context, params types.QueryParams, fn func(types.User) error) error {
+	s.params = params
+	for _, user := range s.users {
+		old := user
+		old.VersionID, old.IsLatestVersion = uuid.New(), false
+		if err := fn(old); err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func Test_BulkExport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	users := make([]types.User, bulkFlushSize+1)
+	for i := range users {
+		users[i] = types.User{
+			ID:              uuid.New(),
+			VersionID:       uuid.New(),
+			IsLatestVersion: true,
+			FirstName:       "John",
+			LastName:        "Doe",
+			Email:           "abc@xyz.com",
+			Phone:           "+49123456789",
+			PasswordHash:    "secret-hash",
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		format   types.BulkExportFormat
+		history  bool
+		wantRows int
+	}{
+		{name: "CSV Case", format: types.BulkExportFormatCSV, wantRows: len(users)},
+		{name: "NDJSON Case", format: types.BulkExportFormatNDJSON, wantRows: len(users)},
+		{name: "Parquet Case", format: types.BulkExportFormatParquet, wantRows: len(users)},
+		{name: "History Case", format: types.BulkExportFormatNDJSON, history: true, wantRows: 2 * len(users)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamer := &testStreamer{users: users}
+			exporter := createBulk(streamer, streamer)
+
+			var out bytes.Buffer
+			if err := exporter.Export(context.Background(), usecase.Actor{IsAdmin: true}, &out, types.QueryParams{}, tt.format, tt.history); err != nil {
+				t.Errorf("Export() error = %v", err)
+				return
+			}
+			assert.Equal(t, types.UserExportFields, streamer.params.Fields)
+			assert.NotContains(t, out.String(), "secret-hash")
+
+			var got []dtos.UserExportRow
+			switch tt.format {
+			case types.BulkExportFormatCSV:
+				records, err := csv.NewReader(&out).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, dtos.UserExportColumns, records[0])
+				assert.Equal(t, users[0].ID.String(), records[1][0])
+				assert.Equal(t, "2024-01-01T12:00:00Z", records[1][8])
+				got = make([]dtos.UserExportRow, len(records)-1)
+			case types.BulkExportFormatNDJSON:
+				for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+					var row dtos.UserExportRow
+					if err := json.Unmarshal([]byte(line), &row); err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, row)
+				}
+			case types.BulkExportFormatParquet:
+				var err error
+				if got, err = parquet.Read[dtos.UserExportRow](bytes.NewReader(out.Bytes()), int64(out.Len())); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			assert.Equal(t, tt.wantRows, len(got))
+			if tt.format != types.BulkExportFormatCSV {
+				// with history the latest version follows the old one
+				latest := got[0]
+				if tt.history {
+					latest = got[1]
+				}
+				assert.Equal(t, dtos.FromUserExportRow(&users[0]), latest)
+			}
+		})
+	}
+}
+
+func Test_BulkExport_Error(t *testing.T) {
+	// errors before the first flush leave the output untouched, so that they can still be reported
+	for _, format := range []types.BulkExportFormat{types.BulkExportFormatCSV, types.BulkExportFormatNDJSON, types.BulkExportFormatParquet} {
+		streamer := &testStreamer{users: []types.User{{ID: uuid.New()}}, err: errors.New("query failed")}
+		exporter := createBulk(streamer, streamer)
+
+		var out bytes.Buffer
+		err := exporter.Export(context.Background(), usecase.Actor{IsAdmin: true}, &out, types.QueryParams{}, format, false)
+		assert.Error(t, err)
+		assert.Zero(t, out.Len(), format)
+	}
+
+	// only admins can export users
+	streamer := &testStreamer{users: []types.User{{ID: uuid.New()}}}
+	var out bytes.Buffer
+	err := createBulk(streamer, streamer).Export(context.Background(), usecase.Actor{ID: uuid.New()}, &out, types.QueryParams{}, types.BulkExportFormatCSV, false)
+	assert.ErrorIs(t, err, types.ErrForbidden)
+	assert.Zero(t, out.Len())
+}
@@ -0,0 +1,305 @@
+package userExport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+const (
+	// asyncThreshold is the amount of versions from which on exports are generated asynchronously
+	asyncThreshold = 100
+	// exportTTL is the time-to-live for generated exports and their jobs
+	exportTTL      = 24 * time.Hour
+	exportTimeout  = 5 * time.Minute
+	exportFileName = "user_export.json"
+	// exportQueueSize is the amount of asynchronous exports waiting to be generated, more exports are rate limited
+	exportQueueSize = 100
+)
+
+var (
+	ErrExportFailed    = types.NewError(types.ErrInternal, "export_failed", "export failed")
+	ErrExportQueueFull = types.NewError(types.ErrRateLimited, "export_queue_full", "too many exports are in progress")
+)
+
+// Exporter generates exports of all the data held about a user (GDPR subject access requests)
+type Exporter struct {
+	client        *redis.Client
+	userGetter    datasource.Getter[types.User]
+	historyGetter datasource.HistoryGetter[types.User]
+	addresses     datasource.ByUsersGetter[types.UserAddress]
+	events        datasource.UserEventGetter
+	// queue holds the jobs of asynchronous exports until Run generates them
+	queue chan types.ExportJob
+}
+
+func create(
+	r *redis.Client,
+	getter datasource.Getter[types.User],
+	historyGetter datasource.HistoryGetter[types.User],
+	addresses datasource.ByUsersGetter[types.UserAddress],
+	events datasource.UserEventGetter,
+) *Exporter {
+	return &Exporter{
+		client:        r,
+		userGetter:    getter,
+		historyGetter: historyGetter,
+		addresses:     addresses,
+		events:        events,
+		queue:         make(chan types.ExportJob, exportQueueSize),
+	}
+}
+
+func keyFromJobID(id uuid.UUID) string {
+	return "user:export:" + id.String()
+}
+
+func dataKeyFromJobID(id uuid.UUID) string {
+	return "user:export:" + id.String() + ":data"
+}
+
+// Export generates the export of a user, only admins can export users by id. Small exports are generated right away
+// and returned with a ready job, large exports are generated in the background and have to be fetched with GetExport
+// once the job is ready.
+func (e *Exporter) Export(ctx context.Context, actor usecase.Actor, userID uuid.UUID, format types.ExportFormat) (types.ExportJob, []byte, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.ExportJob{}, nil, err
+	}
+	return e.export(ctx, userID, format)
+}
+
+// ExportMe generates the export of the actor like Export
+func (e *Exporter) ExportMe(ctx context.Context, actor usecase.Actor, format types.ExportFormat) (types.ExportJob, []byte, error) {
+	return e.export(ctx, actor.ID, format)
+}
+
+func (e *Exporter) export(ctx context.Context, userID uuid.UUID, format types.ExportFormat) (types.ExportJob, []byte, error) {
+	job := types.ExportJob{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Format:    format,
+		Status:    types.ExportStatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	count, err := e.historyGetter.CountHistory(ctx, userID)
+	if err != nil {
+		return job, nil, err
+	}
+	if count == 0 {
+		return job, nil, errors.Join(types.ErrNotFound, errors.New("user not found"))
+	}
+
+	if count < asyncThreshold {
+		data, err := e.generate(ctx, userID, format)
+		if err != nil {
+			return job, nil, err
+		}
+		job.Status = types.ExportStatusReady
+		return job, data, nil
+	}
+
+	if err := e.setJob(ctx, job); err != nil {
+		return job, nil, err
+	}
+	select {
+	case e.queue <- job:
+	default:
+		// The job is removed rather than left pending, nothing would generate it
+		if err := e.client.Del(ctx, keyFromJobID(job.ID)).Err(); err != nil {
+			logging.FromContext(ctx).Error("failed to remove export job", zap.String("export_id", job.ID.String()), zap.Error(err))
+		}
+		return job, nil, ErrExportQueueFull
+	}
+
+	return job, nil, nil
+}
+
+// Run generates the queued exports one after another until the context is done. Exports which are not ready by then
+// fail, so that they can be requested again rather than being pending until they expire.
+func (e *Exporter) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		select {
+		case job := <-e.queue:
+			e.generateJob(ctx, job)
+		case <-ctx.Done():
+		}
+	}
+	for {
+		select {
+		case job := <-e.queue:
+			e.finishJob(ctx, job, ctx.Err())
+		default:
+			return
+		}
+	}
+}
+
+// generateJob generates the export of the job and stores it with the job, which is ready or failed afterwards
+func (e *Exporter) generateJob(ctx context.Context, job types.ExportJob) {
+	generateCtx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	data, err := e.generate(generateCtx, job.UserID, job.Format)
+	if err == nil {
+		err = e.client.Set(generateCtx, dataKeyFromJobID(job.ID), data, exportTTL).Err()
+	}
+	e.finishJob(ctx, job, err)
+}
+
+// finishJob makes the job ready, or failed if generating its export failed. The job is updated even if the context
+// is done, e.g. as the application stops.
+func (e *Exporter) finishJob(ctx context.Context, job types.ExportJob, err error) {
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to generate export", zap.String("export_id", job.ID.String()), zap.Error(err))
+		job.Status = types.ExportStatusFailed
+	} else {
+		job.Status = types.ExportStatusReady
+	}
+
+	if err := e.setJob(ctx, job); err != nil {
+		logging.FromContext(ctx).Error("failed to update export job", zap.String("export_id", job.ID.String()), zap.Error(err))
+	}
+}
+
+// GetExport returns the export job of the user, with the data once it is ready. Only admins can get the exports of
+// users by id.
+func (e *Exporter) GetExport(ctx context.Context, actor usecase.Actor, userID, jobID uuid.UUID) (types.ExportJob, []byte, error) {
+	if err := actor.RequireAdmin(); err != nil {
+		return types.ExportJob{}, nil, err
+	}
+	return e.getExport(ctx, userID, jobID)
+}
+
+// GetExportMe returns the export job of the actor like GetExport
+func (e *Exporter) GetExportMe(ctx context.Context, actor usecase.Actor, jobID uuid.UUID) (types.ExportJob, []byte, error) {
+	return e.getExport(ctx, actor.ID, jobID)
+}
+
+func (e *Exporter) getExport(ctx context.Context, userID, jobID uuid.UUID) (types.ExportJob, []byte, error) {
+	job, err := e.getJob(ctx, jobID)
+	if err != nil {
+		return job, nil, err
+	}
+
+	// Exports of other users are hidden rather than forbidden to not leak their existence
+	if job.UserID != userID {
+		return types.ExportJob{}, nil, errors.Join(types.ErrNotFound, errors.New("export not found"))
+	}
+
+	switch job.Status {
+	case types.ExportStatusPending:
+		return job, nil, nil
+	case types.ExportStatusFailed:
+		return job, nil, ErrExportFailed
+	}
+	data, err := e.getData(ctx, job.ID)
+	return job, data, err
+}
+
+func (e *Exporter) getJob(ctx context.Context, jobID uuid.UUID) (types.ExportJob, error) {
+	var job types.ExportJob
+	cached, err := e.client.Get(ctx, keyFromJobID(jobID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return job, errors.Join(types.ErrNotFound, errors.New("export not found"))
+	} else if err != nil {
+		return job, errors.Join(types.ErrInternal, err)
+	}
+
+	if err := json.Unmarshal(cached, &job); err != nil {
+		return job, errors.Join(types.ErrDataCorrupted, err)
+	}
+
+	return job, nil
+}
+
+func (e *Exporter) getData(ctx context.Context, jobID uuid.UUID) ([]byte, error) {
+	data, err := e.client.Get(ctx, dataKeyFromJobID(jobID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.Join(types.ErrNotFound, errors.New("export not found"))
+	} else if err != nil {
+		return nil, errors.Join(types.ErrInternal, err)
+	}
+	return data, nil
+}
+
+func (e *Exporter) setJob(ctx context.Context, job types.ExportJob) error {
+	jobData, err := json.Marshal(job)
+	if err != nil {
+		return errors.Join(types.ErrInternal, err)
+	}
+
+	if err := e.client.Set(ctx, keyFromJobID(job.ID), jobData, exportTTL).Err(); err != nil {
+		return errors.Join(types.ErrInternal, err)
+	}
+
+	return nil
+}
+
+func (e *Exporter) generate(ctx context.Context, userID uuid.UUID, format types.ExportFormat) ([]byte, error) {
+	user, err := e.userGetter.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := e.historyGetter.GetHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := e.addresses.GetByUsers(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := e.events.GetEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := types.UserExport{
+		GeneratedAt: time.Now().UTC(),
+		User:        user,
+		Versions:    versions,
+		Addresses:   addresses,
+		Events:      events,
+	}
+
+	data, err := json.MarshalIndent(dtos.FromUserExport(&export), "", "  ")
+	if err != nil {
+		return nil, errors.Join(ErrExportFailed, err)
+	}
+
+	if format != types.ExportFormatZIP {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.Create(exportFileName)
+	if err != nil {
+		return nil, errors.Join(ErrExportFailed, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return nil, errors.Join(ErrExportFailed, err)
+	}
+	if err := archive.Close(); err != nil {
+		return nil, errors.Join(ErrExportFailed, err)
+	}
+
+	return buf.Bytes(), nil
+}
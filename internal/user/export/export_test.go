@@ -0,0 +1,280 @@
+package userExport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+// testUserData holds a user with its versions, addresses and events in memory
+type testUserData struct {
+	user      types.User
+	versions  []types.User
+	addresses []types.UserAddress
+	events    []types.UserEvent
+}
+
+func (d *testUserData) Get(_ context.Context, id uuid.UUID) (types.User, error) {
+	if id != d.user.ID {
+		return types.User{}, types.ErrNotFound
+	}
+	return d.user, nil
+}
+
+func (d *testUserData) GetHistory(_ context.Context, id uuid.UUID) ([]types.User, error) {
+	if id != d.user.ID {
+		return nil, nil
+	}
+	return d.versions, nil
+}
+
+func (d *testUserData) GetHistories(ctx context.Context, ids []uuid.UUID) ([]types.User, error) {
+	return d.GetHistory(ctx, ids[0])
+}
+
+func (d *testUserData) CountHistory(_ context.Context, id uuid.UUID) (int64, error) {
+	if id != d.user.ID {
+		return 0, nil
+	}
+	return int64(len(d.versions)), nil
+}
+
+func (d *testUserData) GetByUsers(_ context.Context, userIDs []uuid.UUID) ([]types.UserAddress, error) {
+	var addresses []types.UserAddress
+	for _, address := range d.addresses {
+		for _, id := range userIDs {
+			if address.UserID == id {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+func (d *testUserData) GetEvents(_ context.Context, userID uuid.UUID) ([]types.UserEvent, error) {
+	if userID != d.user.ID {
+		return nil, nil
+	}
+	return d.events, nil
+}
+
+func Test_Export(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	user := types.User{
+		ID:              uuid.New(),
+		VersionID:       uuid.New(),
+		IsLatestVersion: true,
+		FirstName:       "John",
+		LastName:        "Doe",
+		Email:           "abc@xyz.com",
+		Phone:           "+49123456789",
+		PasswordHash:    "secret-hash",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	old := user
+	old.VersionID, old.FirstName, old.IsLatestVersion = uuid.New(), "Jon", false
+	address := types.UserAddress{
+		ID:        uuid.New(),
+		VersionID: uuid.New(),
+		UserID:    user.ID,
+		Kind:      types.AddressKindHome,
+		IsDefault: true,
+		Address:   types.Address{Street: "Main Street", StreetNumber: "123", ZipCode: "12345", City: "Berlin"},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	otherAddress := address
+	otherAddress.ID, otherAddress.UserID = uuid.New(), uuid.New()
+	login := types.UserEvent{ID: uuid.New(), Kind: types.LoginSucceeded, UserID: user.ID, Email: user.Email, OccurredAt: now}
+
+	data := &testUserData{
+		user:      user,
+		versions:  []types.User{old, user},
+		addresses: []types.UserAddress{address, otherAddress},
+		events:    []types.UserEvent{login},
+	}
+	exporter := create(nil, data, data, data, data)
+
+	admin := usecase.Actor{ID: uuid.New(), IsAdmin: true}
+
+	tests := []struct {
+		name    string
+		actor   usecase.Actor
+		userID  uuid.UUID
+		me      bool
+		format  types.ExportFormat
+		wantErr error
+	}{
+		{
+			name:   "JSON Case",
+			actor:  admin,
+			userID: user.ID,
+			format: types.ExportFormatJSON,
+		},
+		{
+			name:   "ZIP Case",
+			actor:  admin,
+			userID: user.ID,
+			format: types.ExportFormatZIP,
+		},
+		{
+			name:   "Me Case",
+			actor:  usecase.Actor{ID: user.ID},
+			userID: user.ID,
+			me:     true,
+			format: types.ExportFormatJSON,
+		},
+		{
+			name:    "Forbidden Case",
+			actor:   usecase.Actor{ID: user.ID},
+			userID:  user.ID,
+			format:  types.ExportFormatJSON,
+			wantErr: types.ErrForbidden,
+		},
+		{
+			name:    "Not Found Case",
+			actor:   admin,
+			userID:  uuid.New(),
+			format:  types.ExportFormatJSON,
+			wantErr: types.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var job types.ExportJob
+			var data []byte
+			var err error
+			if tt.me {
+				job, data, err = exporter.ExportMe(context.Background(), tt.actor, tt.format)
+			} else {
+				job, data, err = exporter.Export(context.Background(), tt.actor, tt.userID, tt.format)
+			}
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			} else if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, types.ExportStatusReady, job.Status)
+
+			if tt.format == types.ExportFormatZIP {
+				archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				if !assert.NoError(t, err) || !assert.Len(t, archive.File, 1) {
+					return
+				}
+				file, err := archive.File[0].Open()
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer file.Close()
+				if data, err = io.ReadAll(file); !assert.NoError(t, err) {
+					return
+				}
+			}
+
+			var got dtos.UserExport
+			if !assert.NoError(t, json.Unmarshal(data, &got)) {
+				return
+			}
+			assert.Equal(t, user.ID, got.Profile.ID)
+			assert.Equal(t, []dtos.UserVersion{dtos.FromUserVersion(&old), dtos.FromUserVersion(&user)}, got.Versions)
+			assert.Equal(t, []dtos.UserAddress{dtos.FromUserAddress(&address)}, got.Addresses)
+			assert.Equal(t, []dtos.UserEvent{dtos.FromUserEvent(&login)}, got.Events)
+			assert.NotContains(t, string(data), "secret-hash")
+		})
+	}
+}
+
+func Test_ExportAsync(t *testing.T) {
+	user := types.User{ID: uuid.New(), VersionID: uuid.New(), IsLatestVersion: true, FirstName: "John"}
+	data := &testUserData{user: user, versions: make([]types.User, asyncThreshold)}
+	actor := usecase.Actor{ID: user.ID}
+
+	t.Run("Generated Case", func(t *testing.T) {
+		client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		defer client.Close()
+		exporter := create(client, data, data, data, data)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			exporter.Run(ctx)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		job, got, err := exporter.ExportMe(context.Background(), actor, types.ExportFormatJSON)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, types.ExportStatusPending, job.Status)
+		assert.Nil(t, got)
+
+		assert.Eventually(t, func() bool {
+			job, got, err = exporter.GetExportMe(context.Background(), actor, job.ID)
+			return err != nil || job.Status != types.ExportStatusPending
+		}, 5*time.Second, 10*time.Millisecond)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, types.ExportStatusReady, job.Status)
+
+		var export dtos.UserExport
+		if assert.NoError(t, json.Unmarshal(got, &export)) {
+			assert.Equal(t, user.ID, export.Profile.ID)
+			assert.Len(t, export.Versions, asyncThreshold)
+		}
+	})
+
+	t.Run("Stopped Case", func(t *testing.T) {
+		client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		defer client.Close()
+		exporter := create(client, data, data, data, data)
+
+		job, _, err := exporter.ExportMe(context.Background(), actor, types.ExportFormatJSON)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Stopping fails the queued jobs instead of leaving them pending
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		exporter.Run(ctx)
+
+		job, _, err = exporter.GetExportMe(context.Background(), actor, job.ID)
+		assert.ErrorIs(t, err, ErrExportFailed)
+		assert.Equal(t, types.ExportStatusFailed, job.Status)
+	})
+
+	t.Run("Queue Full Case", func(t *testing.T) {
+		client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		defer client.Close()
+		exporter := create(client, data, data, data, data)
+		exporter.queue = make(chan types.ExportJob)
+
+		job, _, err := exporter.ExportMe(context.Background(), actor, types.ExportFormatJSON)
+		assert.ErrorIs(t, err, types.ErrRateLimited)
+
+		// The job is not left pending
+		_, _, err = exporter.GetExportMe(context.Background(), actor, job.ID)
+		assert.ErrorIs(t, err, types.ErrNotFound)
+	})
+}
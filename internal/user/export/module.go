@@ -0,0 +1,20 @@
+package userExport
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/worker"
+)
+
+// run generates the asynchronous exports in the background while the application is running
+func run(lc fx.Lifecycle, exporter *Exporter) {
+	worker.Run(lc, exporter.Run)
+}
+
+var FXUserExportModule = fx.Options(
+	fx.Provide(
+		fx.Annotate(create, fx.ParamTags("", `name:"cachedUserGetter"`, "", "", "")),
+		createBulk,
+	),
+	fx.Invoke(run),
+)
@@ -3,11 +3,19 @@ package userDI
 import (
 	"go.uber.org/fx"
 
+	"github.com/pedramktb/schwarzit-probearbeit/internal/transaction"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
 	userCache "github.com/pedramktb/schwarzit-probearbeit/internal/user/cache"
 	userDB "github.com/pedramktb/schwarzit-probearbeit/internal/user/db"
+	userExport "github.com/pedramktb/schwarzit-probearbeit/internal/user/export"
+	userImport "github.com/pedramktb/schwarzit-probearbeit/internal/user/import"
 )
 
 var FXUserModule = fx.Module("user",
+	transaction.FXTransactionProvide,
 	userDB.FXUserDBProvide,
 	userCache.FXUserCacheProvide,
+	userExport.FXUserExportModule,
+	userImport.FXUserImportProvide,
+	usecase.FXUserServiceProvide,
 )
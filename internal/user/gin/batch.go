@@ -0,0 +1,120 @@
+package userGinRouter
+
+import (
+	"net/http"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// @Summary Get many users
+// @Description Get up to 100 users by id at once, duplicated ids are returned once.
+// @Description The found users are returned in the requested order, the ids of users that don't exist are listed as missing
+// @Tags user
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param ids body BatchGetUsers true "User IDs"
+// @Success 200 {object} BatchGetUsersResponse
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/batch-get [post]
+func (r *r) BatchGet(c *gin.Context) {
+	batchDTO := dtos.BatchGetUsers{}
+	if err := c.ShouldBindJSON(&batchDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	users, missing, err := r.userService.GetMany(c.Request.Context(), batchDTO.IDs)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	response := dtos.BatchGetUsersResponse{Items: make([]dtos.User, len(users)), Missing: missing}
+	for i, user := range users {
+		response.Items[i] = dtos.FromUser(&user)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Mutate many users
+// @Description Apply up to 100 create, patch and delete operations at once and get a result per operation in the same order.
+// @Description Atomic batches are applied in one transaction: if an operation fails, nothing is applied and the response has the status of the failed operation,
+// @Description the other operations have the status 424. Otherwise each operation is applied on its own and the response is 200 even if some operations failed.
+// @Tags user
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param operations body BatchMutateUsers true "Operations"
+// @Param Idempotency-Key header string false "Unique key of the request, retries with the same key replay the first response"
+// @Success 200 {object} BatchMutateUsersResponse
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} BatchMutateUsersResponse "Not Found Error of an atomic batch"
+// @Failure 409 {object} ErrorResponse "Conflict Error of the idempotency key"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/batch [post]
+func (r *r) BatchMutate(c *gin.Context) {
+	batchDTO := dtos.BatchMutateUsers{}
+	if err := c.ShouldBindJSON(&batchDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	// Invalid operations fail a whole atomic batch, otherwise only themselves
+	results := make([]dtos.UserOperationResult, len(batchDTO.Operations))
+	ops := make([]types.UserOperation, 0, len(batchDTO.Operations))
+	indexes := make([]int, 0, len(batchDTO.Operations))
+	for i, opDTO := range batchDTO.Operations {
+		op, err := opDTO.ToUserOperation()
+		if err != nil {
+			if batchDTO.Atomic {
+				ginRouter.ErrorResponse(c, errors.Wrapf(err, "operation %d", i))
+				return
+			}
+			results[i] = operationResult(types.UserOperationResult{Err: err})
+			continue
+		}
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	status := http.StatusOK
+	opResults, err := r.userService.MutateBatch(c.Request.Context(), ginRouter.GetActor(c), ops, batchDTO.Atomic)
+	if err != nil {
+		if len(opResults) == 0 {
+			ginRouter.ErrorResponse(c, err)
+			return
+		}
+		status = ginRouter.ErrorStatus(err)
+	}
+	for i, result := range opResults {
+		results[indexes[i]] = operationResult(result)
+	}
+
+	c.JSON(status, dtos.BatchMutateUsersResponse{Results: results})
+}
+
+func operationResult(result types.UserOperationResult) dtos.UserOperationResult {
+	switch {
+	case result.Err != nil:
+		return dtos.UserOperationResult{
+			Status: ginRouter.ErrorStatus(result.Err),
+			Code:   types.ErrorCode(result.Err),
+			Error:  types.PublicMessage(result.Err),
+		}
+	case result.User.ID == uuid.Nil:
+		return dtos.UserOperationResult{Status: http.StatusOK}
+	}
+	user := dtos.FromUser(&result.User)
+	return dtos.UserOperationResult{Status: http.StatusOK, User: &user}
+}
@@ -0,0 +1,221 @@
+package userGinRouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+const (
+	defaultChangesLimit = 100
+	// changesSettleTime delays changes in the feed, so that changes of transactions which committed after a later
+	// change (their timestamps are taken before the commit) are not skipped by clients which already read past them
+	changesSettleTime = 5 * time.Second
+	// changesKeepAlive is the interval of the comments keeping idle change streams open
+	changesKeepAlive = 30 * time.Second
+)
+
+// changesCursor decodes a continuation token of the changes feed
+func (r *r) changesCursor(query url.Values, token string) (*types.Cursor, error) {
+	cursor, err := r.cursors.DecodeString(token, query, types.UserChangeSortFields)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil && (cursor.Backward || !slices.Equal(cursor.Sort, types.UserChangeSort)) {
+		return nil, errors.Join(types.ErrInvalidCursor, errors.New("not a token of the changes feed"))
+	}
+	return cursor, nil
+}
+
+// @Summary Get the changes of users
+// @Description Get the creates, updates (new versions) and deletes of users in the order they were made, starting after the since token.
+// @Description Continue with the next_since token of the response to get the following changes, a token never expires.
+// @Description Changes are only listed once they are a few seconds old, so that changes committed later with an earlier timestamp are not skipped.
+// @Tags user
+// @Security Bearer
+// @Produce json
+// @Param params query UserChangesParams false "Changes Parameters"
+// @Success 200 {object} UserChanges
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/changes [get]
+func (r *r) Changes(c *gin.Context) {
+	paramsDTO := dtos.UserChangesParams{}
+	if err := c.ShouldBindQuery(&paramsDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+	limit := paramsDTO.Limit
+	if limit == 0 {
+		limit = defaultChangesLimit
+	}
+
+	query := c.Request.URL.Query()
+	since, err := r.changesCursor(query, paramsDTO.Since)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	// One change more than the limit is fetched to know whether more follow
+	changes, err := r.changesService.GetChanges(c.Request.Context(), ginRouter.GetActor(c), since, time.Now().Add(-changesSettleTime), limit+1)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+	response := dtos.UserChanges{Items: make([]dtos.UserChange, 0, len(changes))}
+	if response.HasMore = len(changes) > limit; response.HasMore {
+		changes = changes[:limit]
+	}
+	for _, change := range changes {
+		response.Items = append(response.Items, dtos.FromUserChange(&change))
+	}
+
+	next := since
+	if len(changes) > 0 {
+		next = changes[len(changes)-1].Cursor()
+	}
+	if response.NextSince, err = r.cursors.Encode(query, next); err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Stream the changes of users
+// @Description Stream the changes of users as Server-Sent Events, the data of each event is a UserChange and its id the token to continue after it.
+// @Description All changes after the since token (or the Last-Event-ID header on reconnects) are sent first, then changes are pushed as they are committed.
+// @Description Changes are delivered at least once, a change made while catching up might be sent twice.
+// @Description The stream ends if the server can't keep up, clients should reconnect with the id of the last event.
+// @Tags user
+// @Security Bearer
+// @Produce text/event-stream
+// @Param params query UserChangesParams false "Changes Parameters, the limit is ignored"
+// @Param Last-Event-ID header string false "Token to continue after, overrides since"
+// @Success 200 {array} UserChange
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/changes/stream [get]
+func (r *r) StreamChanges(c *gin.Context) {
+	paramsDTO := dtos.UserChangesParams{}
+	if err := c.ShouldBindQuery(&paramsDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+	token := paramsDTO.Since
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		token = lastEventID
+	}
+
+	query := c.Request.URL.Query()
+	since, err := r.changesCursor(query, token)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	// Listen before catching up, so that no change committed in between is missed
+	ctx, actor := c.Request.Context(), ginRouter.GetActor(c)
+	live, err := r.changesService.ListenChanges(ctx, actor)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	// Catch up until now, the first page is read before responding so that its errors can still be returned
+	until := time.Now()
+	changes, err := r.changesService.GetChanges(ctx, actor, since, until, defaultChangesLimit)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	for {
+		for _, change := range changes {
+			if err := r.sendChange(c, query, change); err != nil {
+				abortChanges(c, err)
+				return
+			}
+		}
+		if len(changes) < defaultChangesLimit {
+			break
+		}
+		if changes, err = r.changesService.GetChanges(ctx, actor, changes[len(changes)-1].Cursor(), until, defaultChangesLimit); err != nil {
+			abortChanges(c, err)
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(changesKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change, ok := <-live:
+			if !ok {
+				// Listening failed or the client fell behind, it has to reconnect
+				return
+			}
+			if err := r.sendChange(c, query, change); err != nil {
+				abortChanges(c, err)
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				abortChanges(c, err)
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// sendChange writes the change as an event with its continuation token as id
+func (r *r) sendChange(c *gin.Context, query url.Values, change types.UserChange) error {
+	id, err := r.cursors.Encode(query, change.Cursor())
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(dtos.FromUserChange(&change))
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Writer, "id: %s\ndata: %s\n\n", *id, data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// abortChanges ends a change stream which failed after responding, errors of gone clients are expected
+func abortChanges(c *gin.Context, err error) {
+	if c.Request.Context().Err() == nil {
+		logging.FromContext(c.Request.Context()).Error("failed to stream user changes", zap.Error(err))
+	}
+	c.Abort()
+}
@@ -0,0 +1,57 @@
+package userGinRouter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+// update overwrites a user with the body by the update, conditional on the If-Match header if given
+func (r *r) update(c *gin.Context, update func(ctx context.Context, actor usecase.Actor, input types.UserInput, condition usecase.VersionCondition) (types.User, error)) {
+	userDTO := dtos.SaveUser{}
+	if err := c.ShouldBindJSON(&userDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	user, err := update(c.Request.Context(), ginRouter.GetActor(c), userDTO.ToUserInput(), ginRouter.VersionCondition(c))
+	respondSaved(c, user, err)
+}
+
+// patch applies the patch of the body to the latest version of a user by the patch, conditional on the If-Match
+// header if given
+func (r *r) patch(c *gin.Context, patch func(ctx context.Context, actor usecase.Actor, patcher usecase.UserPatcher, condition usecase.VersionCondition) (types.User, error)) {
+	patcher, err := bindPatch(c)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	user, err := patch(c.Request.Context(), ginRouter.GetActor(c), patcher, ginRouter.VersionCondition(c))
+	respondSaved(c, user, err)
+}
+
+// respondSaved responds with the new version of a saved user and its entity tag
+func respondSaved(c *gin.Context, user types.User, err error) {
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.Header("ETag", ginRouter.ETag(user.VersionID))
+		c.JSON(http.StatusOK, dtos.FromUser(&user))
+	}
+}
+
+// delete deletes a user by the delete, conditional on the If-Match header if given
+func (r *r) delete(c *gin.Context, delete func(ctx context.Context, actor usecase.Actor, condition usecase.VersionCondition) error) {
+	if err := delete(c.Request.Context(), ginRouter.GetActor(c), ginRouter.VersionCondition(c)); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,199 @@
+package userGinRouter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+// @Summary Export a user
+// @Description Export all data held about a user by id (subject access request): the profile, the version history, the addresses and the audit events.
+// @Description There are no sessions (tokens are stateless), API keys or consents to export.
+// @Description Large exports are generated asynchronously, in which case an export job is returned which can be downloaded once ready
+// @Tags user
+// @Security Bearer
+// @Produce json,application/zip
+// @Param id path string true "User ID"
+// @Param format query string false "Export format" Enums(json, zip) default(json)
+// @Success 200 {object} UserExport
+// @Success 202 {object} ExportJob
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 429 {object} ErrorResponse "Too Many Requests Error of asynchronous exports"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/{id}/export [get]
+func (r *r) Export(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	r.export(c, "/api/v1/users/"+id.String()+"/exports/", func(ctx context.Context, actor usecase.Actor, format types.ExportFormat) (types.ExportJob, []byte, error) {
+		return r.exporter.Export(ctx, actor, id, format)
+	})
+}
+
+// @Summary Export users
+// @Description Stream all users matching the filters (as in the query endpoint) as CSV, NDJSON or Parquet, one row per user without the password.
+// @Description With history every version of the matching users is exported, ordered by user and version.
+// @Description Errors after the first rows were sent can only be signaled by aborting the response.
+// @Tags user
+// @Security Bearer
+// @Produce text/csv,application/x-ndjson,application/vnd.apache.parquet
+// @Param params query UserBulkExportParams false "Export Parameters"
+// @Success 200 {array} UserExportRow
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/export [get]
+func (r *r) ExportUsers(c *gin.Context) {
+	paramsDTO := dtos.UserBulkExportParams{}
+	if err := c.ShouldBindQuery(&paramsDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+	params, err := paramsDTO.ToQueryParams()
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	format := paramsDTO.ToBulkExportFormat()
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="users.`+string(format)+`"`)
+	if err := r.bulkExporter.Export(c.Request.Context(), ginRouter.GetActor(c), c.Writer, params, format, paramsDTO.History); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			ginRouter.ErrorResponse(c, err)
+			return
+		}
+		logging.FromContext(c.Request.Context()).Error("failed to stream users export", zap.Error(err))
+		c.Abort()
+	}
+}
+
+// @Summary Get a user export
+// @Description Get the status of an asynchronously generated export of a user by id or download it once ready
+// @Tags user
+// @Security Bearer
+// @Produce json,application/zip
+// @Param id path string true "User ID"
+// @Param export_id path string true "Export ID"
+// @Success 200 {object} UserExport
+// @Success 202 {object} ExportJob
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/{id}/exports/{export_id} [get]
+func (r *r) GetExport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	r.getExport(c, "/api/v1/users/"+id.String()+"/exports/", func(ctx context.Context, actor usecase.Actor, jobID uuid.UUID) (types.ExportJob, []byte, error) {
+		return r.exporter.GetExport(ctx, actor, id, jobID)
+	})
+}
+
+// @Summary Export me (user)
+// @Description Export all data held about me as a user (subject access request): the profile, the version history, the addresses and the audit events.
+// @Description There are no sessions (tokens are stateless), API keys or consents to export.
+// @Description Large exports are generated asynchronously, in which case an export job is returned which can be downloaded once ready
+// @Tags user
+// @Security Bearer
+// @Produce json,application/zip
+// @Param format query string false "Export format" Enums(json, zip) default(json)
+// @Success 200 {object} UserExport
+// @Success 202 {object} ExportJob
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 429 {object} ErrorResponse "Too Many Requests Error of asynchronous exports"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/me/export [get]
+func (r *r) ExportMe(c *gin.Context) {
+	r.export(c, "/api/v1/users/me/exports/", r.exporter.ExportMe)
+}
+
+// @Summary Get my export (user)
+// @Description Get the status of an asynchronously generated export of me as a user or download it once ready
+// @Tags user
+// @Security Bearer
+// @Produce json,application/zip
+// @Param export_id path string true "Export ID"
+// @Success 200 {object} UserExport
+// @Success 202 {object} ExportJob
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/me/exports/{export_id} [get]
+func (r *r) GetExportMe(c *gin.Context) {
+	r.getExport(c, "/api/v1/users/me/exports/", r.exporter.GetExportMe)
+}
+
+func (r *r) export(c *gin.Context, downloadPath string, export func(ctx context.Context, actor usecase.Actor, format types.ExportFormat) (types.ExportJob, []byte, error)) {
+	format := types.ExportFormat(c.DefaultQuery("format", string(types.ExportFormatJSON)))
+	if !format.IsValid() {
+		ginRouter.ErrorResponse(c, errors.Wrap(types.ErrBadRequest, "invalid export format"))
+		return
+	}
+
+	job, data, err := export(c.Request.Context(), ginRouter.GetActor(c), format)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	if job.Status == types.ExportStatusReady {
+		exportResponse(c, &job, data)
+		return
+	}
+
+	downloadURL := downloadPath + job.ID.String()
+	c.Header("Location", downloadURL)
+	c.JSON(http.StatusAccepted, dtos.FromExportJob(&job, downloadURL))
+}
+
+func (r *r) getExport(c *gin.Context, downloadPath string, getExport func(ctx context.Context, actor usecase.Actor, jobID uuid.UUID) (types.ExportJob, []byte, error)) {
+	jobID, err := uuid.Parse(c.Param("export_id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	job, data, err := getExport(c.Request.Context(), ginRouter.GetActor(c), jobID)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else if job.Status == types.ExportStatusPending {
+		c.JSON(http.StatusAccepted, dtos.FromExportJob(&job, downloadPath+job.ID.String()))
+	} else {
+		exportResponse(c, &job, data)
+	}
+}
+
+func exportResponse(c *gin.Context, job *types.ExportJob, data []byte) {
+	c.Header("Content-Disposition", `attachment; filename="`+job.FileName()+`"`)
+	c.Data(http.StatusOK, job.Format.ContentType(), data)
+}
@@ -0,0 +1,59 @@
+package userGinRouter
+
+import (
+	"net/http"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// maxImportSize is the maximum size of the request body of imports
+const maxImportSize = 32 << 20
+
+// @Summary Import users
+// @Description Create users in bulk from CSV (with a header of the columns first_name, last_name, email, phone, password and the optional is_admin)
+// @Description or NDJSON (one SaveUser per line). Every row is validated like a created user, the valid rows are saved in batches of one transaction each.
+// @Description The report lists the outcome of every row, failing rows don't prevent the import of the others.
+// @Tags user
+// @Security Bearer
+// @Accept text/csv,application/x-ndjson
+// @Produce json
+// @Param params query ImportParams false "Import Parameters"
+// @Param users body string true "Users"
+// @Success 200 {object} ImportReport
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/users/import [post]
+func (r *r) Import(c *gin.Context) {
+	paramsDTO := dtos.ImportParams{}
+	if err := c.ShouldBindQuery(&paramsDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	format := types.ImportFormat(paramsDTO.Format)
+	if format == "" {
+		switch c.ContentType() {
+		case "text/csv":
+			format = types.ImportFormatCSV
+		case "application/x-ndjson":
+			format = types.ImportFormatNDJSON
+		default:
+			ginRouter.ErrorResponse(c, errors.Wrap(types.ErrBadRequest, "unknown import format, use text/csv or application/x-ndjson"))
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	if report, err := r.importer.Import(c.Request.Context(), ginRouter.GetActor(c), body, format, paramsDTO.ToImportOptions()); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.JSON(http.StatusOK, dtos.FromImportReport(&report))
+	}
+}
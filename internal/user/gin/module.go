@@ -5,27 +5,37 @@ import (
 	"go.uber.org/fx"
 )
 
-func provideRoutes(e gin.IRouter, r *r, authMiddleware gin.HandlerFunc) {
+func provideRoutes(e gin.IRouter, r *r, authMiddleware, idempotencyMiddleware gin.HandlerFunc) {
 	g := e.Group("/api/v1/users")
 	{
 		g.Use(authMiddleware)
-		g.POST("/", r.Create)
+		g.POST("/", idempotencyMiddleware, r.Create)
+		g.POST("/import", r.Import)
 		g.GET("/", r.Query)
+		g.GET("/export", r.ExportUsers)
+		g.POST("/batch-get", r.BatchGet)
+		g.POST("/batch", idempotencyMiddleware, r.BatchMutate)
+		g.GET("/changes", r.Changes)
+		g.GET("/changes/stream", r.StreamChanges)
 		g.GET("/:id", r.Get)
 		g.PUT("/:id", r.Update)
 		g.PATCH("/:id", r.Patch)
 		g.DELETE("/:id", r.Delete)
+		g.GET("/:id/export", r.Export)
+		g.GET("/:id/exports/:export_id", r.GetExport)
 		g.GET("/me", r.GetMe)
 		g.PUT("/me", r.UpdateMe)
 		g.PATCH("/me", r.PatchMe)
 		g.DELETE("/me", r.DeleteMe)
+		g.GET("/me/export", r.ExportMe)
+		g.GET("/me/exports/:export_id", r.GetExportMe)
 	}
 }
 
 var FXUserGinRouterModule = fx.Options(
-	fx.Provide(fx.Annotate(create, fx.ParamTags(`name:"cachedUserGetter"`, "", `name:"cachedUserSaver"`, `name:"cachedUserDeleter"`))),
+	fx.Provide(create),
 	fx.Invoke(fx.Annotate(
 		provideRoutes,
-		fx.ParamTags("", "", `name:"authMiddleware"`),
+		fx.ParamTags("", "", `name:"authMiddleware"`, `name:"idempotencyMiddleware"`),
 	)),
 )
@@ -0,0 +1,57 @@
+package userGinRouter
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+// bindPatch binds the body of a patch request by its content type, a JSON Merge Patch (RFC 7396),
+// a JSON Patch (RFC 6902) or any other JSON object of the fields to change (null fields are ignored)
+func bindPatch(c *gin.Context) (usecase.UserPatcher, error) {
+	switch c.ContentType() {
+	case mimeMergePatch:
+		userDTO := dtos.MergePatchUser{}
+		if err := c.ShouldBindJSON(&userDTO); err != nil {
+			return nil, dtos.BindingError(err)
+		}
+		patch, err := userDTO.ToUserPatch()
+		if err != nil {
+			return nil, err
+		}
+		return func(types.User) (types.UserPatch, error) { return patch, nil }, nil
+	case mimeJSONPatch:
+		operationDTOs := []dtos.JSONPatchOperation{}
+		// The binding would validate the operations without their indexes
+		if err := json.NewDecoder(c.Request.Body).Decode(&operationDTOs); err != nil {
+			return nil, dtos.BindingError(err)
+		}
+		if err := dtos.ValidateElements(operationDTOs); err != nil {
+			return nil, dtos.BindingError(err)
+		}
+		operations := dtos.ToJSONPatchOperations(operationDTOs)
+		return func(user types.User) (types.UserPatch, error) {
+			document, err := types.ApplyJSONPatch(dtos.ToUserDocument(&user), operations)
+			if err != nil {
+				return types.UserPatch{}, err
+			}
+			return dtos.UserPatchFromDocument(&user, document)
+		}, nil
+	default:
+		userDTO := dtos.PatchUser{}
+		if err := c.ShouldBindJSON(&userDTO); err != nil {
+			return nil, dtos.BindingError(err)
+		}
+		patch := userDTO.ToUserPatch()
+		return func(types.User) (types.UserPatch, error) { return patch, nil }, nil
+	}
+}
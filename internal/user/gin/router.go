@@ -1,37 +1,45 @@
 package userGinRouter
 
 import (
-	"net/http"
+	"context"
+	"strconv"
 
 	"github.com/cockroachdb/errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
-	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
 	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
-	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
 	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+	userExport "github.com/pedramktb/schwarzit-probearbeit/internal/user/export"
+	userImport "github.com/pedramktb/schwarzit-probearbeit/internal/user/import"
 )
 
 type r struct {
-	datasource.Getter[types.User]
-	datasource.Querier[types.User]
-	datasource.Saver[types.User]
-	datasource.Deleter[types.User]
+	userService    *usecase.UserService
+	changesService *usecase.ChangesService
+	exporter       *userExport.Exporter
+	bulkExporter   *userExport.BulkExporter
+	importer       *userImport.Importer
+	cursors        *ginRouter.Cursors
 }
 
 func create(
-	getter datasource.Getter[types.User],
-	querier datasource.Querier[types.User],
-	saver datasource.Saver[types.User],
-	deleter datasource.Deleter[types.User],
+	userService *usecase.UserService,
+	changesService *usecase.ChangesService,
+	exporter *userExport.Exporter,
+	bulkExporter *userExport.BulkExporter,
+	importer *userImport.Importer,
+	cursors *ginRouter.Cursors,
 ) *r {
 	return &r{
-		getter,
-		querier,
-		saver,
-		deleter,
+		userService,
+		changesService,
+		exporter,
+		bulkExporter,
+		importer,
+		cursors,
 	}
 }
 
@@ -41,40 +49,42 @@ func create(
 // @Accept json
 // @Produce json
 // @Param user body SaveUser true "User"
+// @Param Idempotency-Key header string false "Unique key of the request, retries with the same key replay the first response"
 // @Success 200 {object} User
+// @Header 200 {string} ETag "Version of the user"
+// @Header 200 {string} Idempotent-Replayed "true if the response is the replay of an earlier request"
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
 // @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 409 {object} ErrorResponse "Conflict Error, the email is taken or the idempotency key was used"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /api/v1/users [post]
 func (r *r) Create(c *gin.Context) {
-	if isAdmin := c.GetBool(string(logging.CtxUserIsAdmin)); !isAdmin {
-		ginRouter.ErrorResponse(c, types.ErrForbidden)
-		return
-	}
-
 	userDTO := dtos.SaveUser{}
 	if err := c.ShouldBindJSON(&userDTO); err != nil {
-		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
 		return
 	}
 
-	if user, err := r.Saver.Save(c.Request.Context(), userDTO.ToCreateUser()); err != nil {
-		ginRouter.ErrorResponse(c, err)
-	} else {
-		c.JSON(http.StatusOK, dtos.FromUser(&user))
-	}
+	user, err := r.userService.Create(c.Request.Context(), ginRouter.GetActor(c), userDTO.ToUserInput())
+	respondSaved(c, user, err)
 }
 
 // @Summary Query users
-// @Description Query users
+// @Description Query users, the pages can be navigated by offset or by the returned cursors
 // @Tags user
 // @Security Bearer
 // @Accept json
 // @Produce json
 // @Param params query UserQueryParams false "Query Parameters"
-// @Success 200 {array} []User
+// @Param If-None-Match header string false "ETag of a cached page, the response is 304 if the page is unchanged"
+// @Success 200 {object} UserPage
+// @Success 304 "Not Modified"
+// @Header 200 {integer} X-Total-Count "Number of all matching users, if requested with total"
+// @Header 200,304 {string} ETag "Weak entity tag of the page"
+// @Header 200,304 {string} Cache-Control "Revalidation is required before using a stored page"
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
@@ -84,27 +94,64 @@ func (r *r) Create(c *gin.Context) {
 func (r *r) Query(c *gin.Context) {
 	paramsDTO := dtos.UserQueryParams{}
 	if err := c.ShouldBindQuery(&paramsDTO); err != nil {
-		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
 		return
 	}
 
-	if users, err := r.Querier.Query(c, paramsDTO.ToQueryParams()); err != nil {
+	params, err := paramsDTO.ToQueryParams()
+	if err != nil {
 		ginRouter.ErrorResponse(c, err)
-	} else {
-		userDTOs := make([]dtos.User, len(users))
-		for i, user := range users {
-			userDTOs[i] = dtos.FromUser(&user)
-		}
-		c.JSON(http.StatusOK, userDTOs)
+		return
+	}
+	view, err := parseView(&paramsDTO.UserViewParams)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	query := c.Request.URL.Query()
+	if params.Cursor, err = r.cursors.Decode(query, types.UserSortFields); err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	page, err := r.userService.Query(c, params)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
 	}
+
+	next, err := r.cursors.Encode(query, page.Next)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+	prev, err := r.cursors.Encode(query, page.Prev)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	if page.Total != nil {
+		c.Header("X-Total-Count", strconv.FormatInt(*page.Total, 10))
+	}
+	r.respondPage(c, dtos.FromUserPage(&page, next, prev), page.Items, view)
 }
 
 // @Summary Get a user
-// @Description Get a user by id
+// @Description Get a user by id, optionally limited to some fields or with related data embedded
 // @Tags user
 // @Security Bearer
 // @Produce json
 // @Param id path string true "User ID"
+// @Param params query UserViewParams false "View Parameters"
+// @Param If-None-Match header string false "ETag of a cached user, the response is 304 if it is unchanged"
+// @Param If-Modified-Since header string false "Time of a cached user, ignored with If-None-Match"
+// @Success 200 {object} User
+// @Success 304 "Not Modified"
+// @Header 200,304 {string} ETag "Version of the user, weak if limited to some fields or with related data embedded"
+// @Header 200,304 {string} Last-Modified "Creation time of the version, only without fields and expand"
+// @Header 200,304 {string} Cache-Control "Revalidation is required before using a stored user"
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
@@ -118,10 +165,21 @@ func (r *r) Get(c *gin.Context) {
 		return
 	}
 
-	if user, err := r.Getter.Get(c, id); err != nil {
+	viewDTO := dtos.UserViewParams{}
+	if err := c.ShouldBindQuery(&viewDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+	view, err := parseView(&viewDTO)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	if user, err := r.userService.Get(c, id); err != nil {
 		ginRouter.ErrorResponse(c, err)
 	} else {
-		c.JSON(http.StatusOK, dtos.FromUser(&user))
+		r.respondUser(c, user, view)
 	}
 }
 
@@ -133,84 +191,62 @@ func (r *r) Get(c *gin.Context) {
 // @Produce json
 // @Param id path string true "User ID"
 // @Param user body SaveUser true "User"
+// @Param If-Match header string false "ETag of the version the change is based on, the change fails if it is not the latest one"
 // @Success 200 {object} User
+// @Header 200 {string} ETag "Version of the user"
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
 // @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 409 {object} ErrorResponse "Conflict Error, the email is taken"
+// @Failure 412 {object} ErrorResponse "Precondition Failed Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /api/v1/users/{id} [put]
 func (r *r) Update(c *gin.Context) {
-	if isAdmin := c.GetBool(string(logging.CtxUserIsAdmin)); !isAdmin {
-		ginRouter.ErrorResponse(c, types.ErrForbidden)
-		return
-	}
-
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
 		return
 	}
 
-	userDTO := dtos.SaveUser{}
-	if err := c.ShouldBindJSON(&userDTO); err != nil {
-		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
-		return
-	}
-
-	if user, err := r.Saver.Save(c.Request.Context(), userDTO.ToUpdateUser(id)); err != nil {
-		ginRouter.ErrorResponse(c, err)
-	} else {
-		c.JSON(http.StatusOK, dtos.FromUser(&user))
-	}
+	r.update(c, func(ctx context.Context, actor usecase.Actor, input types.UserInput, condition usecase.VersionCondition) (types.User, error) {
+		return r.userService.Update(ctx, actor, id, input, condition)
+	})
 }
 
 // @Summary Patch a user
 // @Description Patch a user by id
+// @Description The body is a partial user (null fields are ignored), a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) by the Content-Type.
+// @Description JSON Patches are applied to the writable fields first_name, last_name, email, phone and is_admin, a password can be added.
 // @Tags user
 // @Security Bearer
-// @Accept json
+// @Accept json,application/merge-patch+json,application/json-patch+json
 // @Produce json
 // @Param id path string true "User ID"
-// @Param user body PatchUser true "User"
+// @Param user body PatchUser true "User, or a JSON Patch as array of operations ({op, path, from, value}) for application/json-patch+json"
+// @Param If-Match header string false "ETag of the version the change is based on, the change fails if it is not the latest one"
 // @Success 200 {object} User
+// @Header 200 {string} ETag "Version of the user"
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
 // @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 409 {object} ErrorResponse "Conflict Error, the email is taken or a test operation failed"
+// @Failure 412 {object} ErrorResponse "Precondition Failed Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /api/v1/users/{id} [patch]
 func (r *r) Patch(c *gin.Context) {
-	if isAdmin := c.GetBool(string(logging.CtxUserIsAdmin)); !isAdmin {
-		ginRouter.ErrorResponse(c, types.ErrForbidden)
-		return
-	}
-
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
 		return
 	}
 
-	userDTO := dtos.PatchUser{}
-	if err := c.ShouldBindJSON(&userDTO); err != nil {
-		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
-		return
-	}
-
-	user, err := r.Getter.Get(c.Request.Context(), id)
-	if err != nil {
-		ginRouter.ErrorResponse(c, err)
-		return
-	}
-
-	user.ApplyPatch(userDTO.ToUserPatch())
-
-	if user, err := r.Saver.Save(c.Request.Context(), user); err != nil {
-		ginRouter.ErrorResponse(c, err)
-	} else {
-		c.JSON(http.StatusOK, dtos.FromUser(&user))
-	}
+	r.patch(c, func(ctx context.Context, actor usecase.Actor, patcher usecase.UserPatcher, condition usecase.VersionCondition) (types.User, error) {
+		return r.userService.Patch(ctx, actor, id, patcher, condition)
+	})
 }
 
 // @Summary Delete a user
@@ -219,30 +255,25 @@ func (r *r) Patch(c *gin.Context) {
 // @Security Bearer
 // @Produce json
 // @Param id path string true "User ID"
+// @Param If-Match header string false "ETag of the version the change is based on, the change fails if it is not the latest one"
 // @Success 200
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
 // @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 412 {object} ErrorResponse "Precondition Failed Error"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /api/v1/users/{id} [delete]
 func (r *r) Delete(c *gin.Context) {
-	if isAdmin := c.GetBool(string(logging.CtxUserIsAdmin)); !isAdmin {
-		ginRouter.ErrorResponse(c, types.ErrForbidden)
-		return
-	}
-
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
 		return
 	}
 
-	if err := r.Deleter.Delete(c, id); err != nil {
-		ginRouter.ErrorResponse(c, err)
-	} else {
-		c.Status(http.StatusOK)
-	}
+	r.delete(c, func(ctx context.Context, actor usecase.Actor, condition usecase.VersionCondition) error {
+		return r.userService.Delete(ctx, actor, id, condition)
+	})
 }
 
 // @Summary Get me (user)
@@ -250,7 +281,13 @@ func (r *r) Delete(c *gin.Context) {
 // @Tags user
 // @Security Bearer
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached user, the response is 304 if it is unchanged"
+// @Param If-Modified-Since header string false "Time of a cached user, ignored with If-None-Match"
 // @Success 200 {object} User
+// @Success 304 "Not Modified"
+// @Header 200,304 {string} ETag "Version of the user"
+// @Header 200,304 {string} Last-Modified "Creation time of the version"
+// @Header 200,304 {string} Cache-Control "Private, revalidation is required before using a stored user"
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
@@ -258,13 +295,12 @@ func (r *r) Delete(c *gin.Context) {
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /api/v1/users/me [get]
 func (r *r) GetMe(c *gin.Context) {
-	aid, _ := c.Get(string(logging.CtxUserID))
-	id, _ := aid.(uuid.UUID)
+	id := ginRouter.GetActor(c).ID
 
-	if user, err := r.Getter.Get(c.Request.Context(), id); err != nil {
+	if user, err := r.userService.Get(c.Request.Context(), id); err != nil {
 		ginRouter.ErrorResponse(c, err)
 	} else {
-		c.JSON(http.StatusOK, dtos.FromUser(&user))
+		ginRouter.RespondCacheable(c, ginRouter.CacheControlPrivate, ginRouter.ETag(user.VersionID), user.UpdatedAt, dtos.FromUser(&user))
 	}
 }
 
@@ -275,77 +311,45 @@ func (r *r) GetMe(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param user body SaveUser true "User"
+// @Param If-Match header string false "ETag of the version the change is based on, the change fails if it is not the latest one"
 // @Success 200 {object} User
+// @Header 200 {string} ETag "Version of the user"
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
 // @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 409 {object} ErrorResponse "Conflict Error, the email is taken"
+// @Failure 412 {object} ErrorResponse "Precondition Failed Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /api/v1/users/me [put]
 func (r *r) UpdateMe(c *gin.Context) {
-	aid, _ := c.Get(string(logging.CtxUserID))
-	id, _ := aid.(uuid.UUID)
-
-	userDTO := dtos.SaveUser{}
-	if err := c.ShouldBindJSON(&userDTO); err != nil {
-		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
-		return
-	}
-
-	if isAdmin := c.GetBool(string(logging.CtxUserIsAdmin)); !isAdmin && userDTO.IsAdmin {
-		ginRouter.ErrorResponse(c, types.ErrForbidden)
-		return
-	}
-
-	if user, err := r.Saver.Save(c.Request.Context(), userDTO.ToUpdateUser(id)); err != nil {
-		ginRouter.ErrorResponse(c, err)
-	} else {
-		c.JSON(http.StatusOK, dtos.FromUser(&user))
-	}
+	r.update(c, r.userService.UpdateMe)
 }
 
 // @Summary Patch me (user)
 // @Description Patch me as a user
+// @Description The body is a partial user (null fields are ignored), a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) by the Content-Type.
+// @Description JSON Patches are applied to the writable fields first_name, last_name, email, phone and is_admin, a password can be added.
 // @Tags user
 // @Security Bearer
-// @Accept json
+// @Accept json,application/merge-patch+json,application/json-patch+json
 // @Produce json
-// @Param user body PatchUser true "User"
+// @Param user body PatchUser true "User, or a JSON Patch as array of operations ({op, path, from, value}) for application/json-patch+json"
+// @Param If-Match header string false "ETag of the version the change is based on, the change fails if it is not the latest one"
 // @Success 200 {object} User
+// @Header 200 {string} ETag "Version of the user"
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
 // @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 409 {object} ErrorResponse "Conflict Error, the email is taken or a test operation failed"
+// @Failure 412 {object} ErrorResponse "Precondition Failed Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /api/v1/users/me [patch]
 func (r *r) PatchMe(c *gin.Context) {
-	aid, _ := c.Get(string(logging.CtxUserID))
-	id, _ := aid.(uuid.UUID)
-
-	userDTO := dtos.PatchUser{}
-	if err := c.ShouldBindJSON(&userDTO); err != nil {
-		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrBadRequest, err))
-		return
-	}
-
-	if isAdmin := c.GetBool(string(logging.CtxUserIsAdmin)); !isAdmin && userDTO.IsAdmin != nil {
-		ginRouter.ErrorResponse(c, types.ErrForbidden)
-		return
-	}
-
-	user, err := r.Getter.Get(c.Request.Context(), id)
-	if err != nil {
-		ginRouter.ErrorResponse(c, err)
-		return
-	}
-
-	user.ApplyPatch(userDTO.ToUserPatch())
-
-	if user, err := r.Saver.Save(c.Request.Context(), user); err != nil {
-		ginRouter.ErrorResponse(c, err)
-	} else {
-		c.JSON(http.StatusOK, dtos.FromUser(&user))
-	}
+	r.patch(c, r.userService.PatchMe)
 }
 
 // @Summary Delete me (user)
@@ -353,20 +357,15 @@ func (r *r) PatchMe(c *gin.Context) {
 // @Tags user
 // @Security Bearer
 // @Produce json
+// @Param If-Match header string false "ETag of the version the change is based on, the change fails if it is not the latest one"
 // @Success 200
 // @Failure 400 {object} ErrorResponse "Bad Request Error"
 // @Failure 401 {object} ErrorResponse "Unauthorized Error"
 // @Failure 403 {object} ErrorResponse "Forbidden Error"
 // @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 412 {object} ErrorResponse "Precondition Failed Error"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /api/v1/users/me [delete]
 func (r *r) DeleteMe(c *gin.Context) {
-	aid, _ := c.Get(string(logging.CtxUserID))
-	id, _ := aid.(uuid.UUID)
-
-	if err := r.Deleter.Delete(c.Request.Context(), id); err != nil {
-		ginRouter.ErrorResponse(c, err)
-	} else {
-		c.Status(http.StatusOK)
-	}
+	r.delete(c, r.userService.DeleteMe)
 }
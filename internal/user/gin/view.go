@@ -0,0 +1,120 @@
+package userGinRouter
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+// view are the fields and related data requested for a users response
+type view struct {
+	fields types.Fields
+	expand types.Fields
+}
+
+// parseView validates the requested view
+func parseView(viewDTO *dtos.UserViewParams) (view, error) {
+	fields, err := viewDTO.ToFields()
+	if err != nil {
+		return view{}, err
+	}
+	expand, err := viewDTO.ToExpand()
+	if err != nil {
+		return view{}, err
+	}
+	return view{fields: fields, expand: expand}, nil
+}
+
+// users converts the users to DTOs with the requested related data embedded
+func (r *r) users(ctx context.Context, actor usecase.Actor, users []types.User, v view) ([]dtos.User, error) {
+	userDTOs := make([]dtos.User, len(users))
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		userDTOs[i] = dtos.FromUser(&user)
+		ids[i] = user.ID
+	}
+	if len(users) == 0 {
+		return userDTOs, nil
+	}
+
+	relations, err := r.userService.Relations(ctx, actor, ids, v.expand)
+	if err != nil {
+		return nil, err
+	}
+	for i := range userDTOs {
+		if relations.Versions != nil {
+			versionDTOs := make([]dtos.UserVersion, 0, len(relations.Versions[ids[i]]))
+			for _, version := range relations.Versions[ids[i]] {
+				versionDTOs = append(versionDTOs, dtos.FromUserVersion(&version))
+			}
+			userDTOs[i].Versions = &versionDTOs
+		}
+		if relations.Addresses != nil {
+			addressDTOs := make([]dtos.UserAddress, 0, len(relations.Addresses[ids[i]]))
+			for _, address := range relations.Addresses[ids[i]] {
+				addressDTOs = append(addressDTOs, dtos.FromUserAddress(&address))
+			}
+			userDTOs[i].Addresses = &addressDTOs
+		}
+	}
+
+	return userDTOs, nil
+}
+
+// sparse limits the user to the requested fields and the embedded related data
+func (v view) sparse(user dtos.User) (any, error) {
+	if v.fields == nil {
+		return user, nil
+	}
+	return dtos.Sparse(user, append(v.fields[:len(v.fields):len(v.fields)], v.expand...))
+}
+
+// respondUser writes a single user in the requested view
+func (r *r) respondUser(c *gin.Context, user types.User, v view) {
+	userDTOs, err := r.users(c.Request.Context(), ginRouter.GetActor(c), []types.User{user}, v)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+	if body, err := v.sparse(userDTOs[0]); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else if v.fields == nil && v.expand == nil {
+		ginRouter.RespondCacheable(c, ginRouter.CacheControlShared, ginRouter.ETag(user.VersionID), user.UpdatedAt, body)
+	} else {
+		// The version only identifies the full representation, embedded related data changes independently
+		ginRouter.RespondCacheable(c, ginRouter.CacheControlShared, "", time.Time{}, body)
+	}
+}
+
+// respondPage writes a page of users in the requested view
+func (r *r) respondPage(c *gin.Context, page dtos.UserPage, users []types.User, v view) {
+	userDTOs, err := r.users(c.Request.Context(), ginRouter.GetActor(c), users, v)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+	page.Items = userDTOs
+	if v.fields == nil {
+		ginRouter.RespondCacheable(c, ginRouter.CacheControlShared, "", time.Time{}, page)
+		return
+	}
+
+	sparsePage := dtos.SparseUserPage{UserPage: page, Items: make([]map[string]json.RawMessage, len(userDTOs))}
+	for i, user := range userDTOs {
+		body, err := v.sparse(user)
+		if err != nil {
+			ginRouter.ErrorResponse(c, err)
+			return
+		}
+		sparsePage.Items[i] = body.(map[string]json.RawMessage)
+	}
+	ginRouter.RespondCacheable(c, ginRouter.CacheControlShared, "", time.Time{}, sparsePage)
+}
@@ -0,0 +1,334 @@
+package userImport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+const (
+	// batchSize is the amount of rows saved per transaction
+	batchSize = 500
+	// MaxRows is the maximum amount of rows of a single import
+	MaxRows = 10000
+	// maxLineSize is the maximum size of a single NDJSON line
+	maxLineSize = 64 * 1024
+)
+
+var ErrInvalidImport = types.NewError(types.ErrBadRequest, "invalid_import", "invalid import")
+
+// csvColumns are the columns of CSV imports, all but is_admin are required
+var csvColumns = []string{"first_name", "last_name", "email", "phone", "is_admin", "password"}
+
+// Importer creates and updates users in bulk from CSV or NDJSON
+type Importer struct {
+	emailsGetter datasource.UsersByEmailsGetter
+	batchSaver   datasource.BatchSaver[types.User]
+}
+
+func create(
+	emailsGetter datasource.UsersByEmailsGetter,
+	batchSaver datasource.BatchSaver[types.User],
+) *Importer {
+	return &Importer{
+		emailsGetter: emailsGetter,
+		batchSaver:   batchSaver,
+	}
+}
+
+// record is a decoded row of an import
+type record struct {
+	line int
+	user dtos.SaveUser
+	// hasIsAdmin reports whether the row sets is_admin, upserts keep the flag of the existing user otherwise
+	hasIsAdmin bool
+	err        error
+}
+
+// Import validates all rows and saves the valid ones in batches, every batch in its own transaction.
+// Rows fail individually, an error is only returned if the input as a whole can't be read. Only admins can import.
+func (i *Importer) Import(ctx context.Context, actor usecase.Actor, r io.Reader, format types.ImportFormat, opts types.ImportOptions) (types.ImportReport, error) {
+	report := types.ImportReport{DryRun: opts.DryRun}
+	if err := actor.RequireAdmin(); err != nil {
+		return report, err
+	}
+
+	var records []record
+	var err error
+	switch format {
+	case types.ImportFormatCSV:
+		records, err = readCSV(r)
+	case types.ImportFormatNDJSON:
+		records, err = readNDJSON(r)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return report, errors.Join(ErrInvalidImport, err)
+	}
+
+	// Emails have to be unique within an import, later rows with the same email fail
+	lines := make(map[string]int, len(records))
+	for batch := range slices.Chunk(records, batchSize) {
+		for j := range batch {
+			rec := &batch[j]
+			if rec.err == nil {
+				rec.err = rec.user.Validate()
+			}
+			if rec.err == nil {
+				// Emails are unique case-insensitively
+				email := strings.ToLower(rec.user.Email)
+				if line, ok := lines[email]; ok {
+					rec.err = fmt.Errorf("email is already used in line %d", line)
+				} else {
+					lines[email] = rec.line
+				}
+			}
+		}
+
+		rows, err := i.importBatch(ctx, batch, opts)
+		if err != nil {
+			return report, err
+		}
+		for _, row := range rows {
+			report.Add(row)
+		}
+	}
+
+	return report, nil
+}
+
+func (i *Importer) importBatch(ctx context.Context, batch []record, opts types.ImportOptions) ([]types.ImportRow, error) {
+	rows := make([]types.ImportRow, len(batch))
+	var emails []string
+	for j, rec := range batch {
+		rows[j] = types.ImportRow{Row: rec.line, Email: rec.user.Email}
+		if rec.err != nil {
+			rows[j].Status, rows[j].Error = types.ImportStatusFailed, rec.err.Error()
+		} else {
+			emails = append(emails, rec.user.Email)
+		}
+	}
+	if len(emails) == 0 {
+		return rows, nil
+	}
+
+	existing, err := i.emailsGetter.GetByEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	// Emails are unique case-insensitively
+	byEmail := make(map[string]types.User, len(existing))
+	for _, user := range existing {
+		byEmail[strings.ToLower(user.Email)] = user
+	}
+
+	// indexes are the rows of the users to save
+	var indexes []int
+	for j, rec := range batch {
+		if rec.err != nil {
+			continue
+		}
+		user, exists := byEmail[strings.ToLower(rec.user.Email)]
+		switch {
+		case exists && !opts.Upsert:
+			rows[j].Status, rows[j].Error = types.ImportStatusFailed, "a user with this email already exists"
+		case exists:
+			rows[j].Status, rows[j].ID = types.ImportStatusUpdated, user.ID
+			indexes = append(indexes, j)
+		default:
+			rows[j].Status = types.ImportStatusCreated
+			indexes = append(indexes, j)
+		}
+	}
+	if opts.DryRun || len(indexes) == 0 {
+		return rows, nil
+	}
+
+	users, indexes := toUsers(batch, rows, indexes, byEmail)
+	if len(users) == 0 {
+		return rows, nil
+	}
+
+	// A failing batch fails all of its rows, the following batches are still imported
+	saved, err := i.batchSaver.SaveBatch(ctx, users)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	for k, j := range indexes {
+		if err != nil {
+			rows[j].Status, rows[j].ID, rows[j].Error = types.ImportStatusFailed, uuid.Nil, types.PublicMessage(err)
+		} else {
+			rows[j].ID = saved[k].ID
+		}
+	}
+	return rows, nil
+}
+
+// toUsers converts the rows to save to users, the passwords are hashed in parallel as hashing is slow by design.
+// Updated users keep their admin flag unless the row sets it and their password hash if the password is unchanged,
+// existing are the users by their lowercase email.
+// Rows whose password can't be hashed fail, the indexes of the converted rows are returned with the users.
+func toUsers(batch []record, rows []types.ImportRow, indexes []int, existing map[string]types.User) ([]types.User, []int) {
+	users := make([]types.User, len(indexes))
+	errs := make([]error, len(indexes))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for k, j := range indexes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			input := batch[j].user.ToUserInput()
+			users[k] = types.User{
+				FirstName: input.FirstName,
+				LastName:  input.LastName,
+				Email:     input.Email,
+				Phone:     input.Phone,
+				IsAdmin:   input.IsAdmin,
+			}
+			var currentHash string
+			if rows[j].Status == types.ImportStatusUpdated {
+				current := existing[strings.ToLower(input.Email)]
+				users[k].ID, currentHash = rows[j].ID, current.PasswordHash
+				if !batch[j].hasIsAdmin {
+					users[k].IsAdmin = current.IsAdmin
+				}
+			}
+			users[k].PasswordHash, errs[k] = usecase.RehashPassword(currentHash, input.Password)
+		}()
+	}
+	wg.Wait()
+
+	hashed := make([]types.User, 0, len(users))
+	hashedIndexes := make([]int, 0, len(indexes))
+	for k, j := range indexes {
+		if errs[k] != nil {
+			rows[j].Status, rows[j].ID, rows[j].Error = types.ImportStatusFailed, uuid.Nil, types.PublicMessage(errs[k])
+			continue
+		}
+		hashed = append(hashed, users[k])
+		hashedIndexes = append(hashedIndexes, j)
+	}
+	return hashed, hashedIndexes
+}
+
+func readCSV(r io.Reader) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("missing header")
+	} else if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for j, column := range header {
+		column = strings.TrimSpace(column)
+		if !slices.Contains(csvColumns, column) {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		if _, ok := columns[column]; ok {
+			return nil, fmt.Errorf("duplicate column %q", column)
+		}
+		columns[column] = j
+	}
+	for _, column := range csvColumns {
+		if _, ok := columns[column]; !ok && column != "is_admin" {
+			return nil, fmt.Errorf("missing column %q", column)
+		}
+	}
+
+	var records []record
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		} else if len(records) == MaxRows {
+			return nil, fmt.Errorf("more than %d rows", MaxRows)
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			records = append(records, record{line: parseErr.StartLine, err: parseErr.Err})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(values) != len(header) {
+			records = append(records, record{line: line, err: fmt.Errorf("expected %d values, got %d", len(header), len(values))})
+		} else {
+			value := func(column string) string {
+				if j, ok := columns[column]; ok {
+					return strings.TrimSpace(values[j])
+				}
+				return ""
+			}
+			rec := record{line: line, user: dtos.SaveUser{
+				FirstName: value("first_name"),
+				LastName:  value("last_name"),
+				Email:     value("email"),
+				Phone:     value("phone"),
+				Password:  value("password"),
+			}}
+			if isAdmin := value("is_admin"); isAdmin != "" {
+				rec.hasIsAdmin = true
+				if rec.user.IsAdmin, err = strconv.ParseBool(isAdmin); err != nil {
+					rec.err = fmt.Errorf("is_admin is not a boolean: %q", isAdmin)
+				}
+			}
+			records = append(records, rec)
+		}
+	}
+}
+
+func readNDJSON(r io.Reader) ([]record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+
+	var records []record
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		} else if len(records) == MaxRows {
+			return nil, fmt.Errorf("more than %d rows", MaxRows)
+		}
+		rec := record{line: line}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&rec.user); err != nil {
+			rec.err = err
+		} else if decoder.More() {
+			rec.err = errors.New("more than one JSON value in the line")
+		} else {
+			var isAdmin struct {
+				IsAdmin *bool `json:"is_admin"`
+			}
+			_ = json.Unmarshal(data, &isAdmin)
+			rec.hasIsAdmin = isAdmin.IsAdmin != nil
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,245 @@
+package userImport
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+// testStore keeps the users in memory instead of postgres
+type testStore struct {
+	users   map[string]types.User
+	batches int
+}
+
+func (s *testStore) GetByEmails(_ context.Context, emails []string) ([]types.User, error) {
+	var users []types.User
+	for _, user := range s.users {
+		// Emails are compared case-insensitively like in postgres
+		if slices.ContainsFunc(emails, func(email string) bool { return strings.EqualFold(email, user.Email) }) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (s *testStore) SaveBatch(_ context.Context, users []types.User) ([]types.User, error) {
+	s.batches++
+	for i := range users {
+		if users[i].ID == uuid.Nil {
+			users[i].ID = uuid.New()
+		}
+		s.users[users[i].Email] = users[i]
+	}
+	return users, nil
+}
+
+func Test_Import(t *testing.T) {
+	existing := types.User{ID: uuid.New(), Email: "old@xyz.com"}
+
+	csv := "first_name,last_name,email,phone,password,is_admin\n" +
+		"John,Doe,john@xyz.com,+49123456789,password,false\n" +
+		"Jane,Doe,old@xyz.com,+49123456789,password,\n" +
+		"Max,Mustermann,max@xyz.com,0049123,password,false\n" +
+		"Erika,Mustermann,john@xyz.com,+49123456789,password,true\n" +
+		"Bad,Admin,bad@xyz.com,+49123456789,password,maybe\n" +
+		"Too,Few\n"
+
+	ndjson := `{"first_name":"John","last_name":"Doe","email":"john@xyz.com","phone":"+49123456789","password":"password"}` + "\n" +
+		"\n" +
+		`{"first_name":"Jane","last_name":"Doe","email":"old@xyz.com","phone":"+49123456789","password":"password"}` + "\n" +
+		`{"first_name":"","last_name":"Doe","email":"no-name@xyz.com","phone":"+49123456789","password":"password"}` + "\n" +
+		`{"first_name":"Max","last_name":"Mustermann","email":"max@xyz","phone":"+49123456789","password":"password"}` + "\n" +
+		`{"first_name":"Max","last_name":"Mustermann","role":"admin"}` + "\n" +
+		`not json` + "\n"
+
+	tests := []struct {
+		name        string
+		format      types.ImportFormat
+		input       string
+		opts        types.ImportOptions
+		wantRows    []types.ImportRow
+		wantBatches int
+		wantErr     bool
+	}{
+		{
+			name:   "CSV Case",
+			format: types.ImportFormatCSV,
+			input:  csv,
+			wantRows: []types.ImportRow{
+				{Row: 2, Email: "john@xyz.com", Status: types.ImportStatusCreated},
+				{Row: 3, Email: "old@xyz.com", Status: types.ImportStatusFailed},
+				{Row: 4, Email: "max@xyz.com", Status: types.ImportStatusFailed},
+				{Row: 5, Email: "john@xyz.com", Status: types.ImportStatusFailed},
+				{Row: 6, Email: "bad@xyz.com", Status: types.ImportStatusFailed},
+				{Row: 7, Status: types.ImportStatusFailed},
+			},
+			wantBatches: 1,
+		},
+		{
+			name:   "NDJSON Upsert Case",
+			format: types.ImportFormatNDJSON,
+			input:  ndjson,
+			opts:   types.ImportOptions{Upsert: true},
+			wantRows: []types.ImportRow{
+				{Row: 1, Email: "john@xyz.com", Status: types.ImportStatusCreated},
+				{Row: 3, Email: "old@xyz.com", Status: types.ImportStatusUpdated, ID: existing.ID},
+				{Row: 4, Email: "no-name@xyz.com", Status: types.ImportStatusFailed},
+				{Row: 5, Email: "max@xyz", Status: types.ImportStatusFailed},
+				{Row: 6, Status: types.ImportStatusFailed},
+				{Row: 7, Status: types.ImportStatusFailed},
+			},
+			wantBatches: 1,
+		},
+		{
+			name:   "Duplicate Email Case",
+			format: types.ImportFormatCSV,
+			input: "first_name,last_name,email,phone,password\n" +
+				"John,Doe,john@xyz.com,+49123456789,password\n" +
+				"Johnny,Doe,JOHN@xyz.com,+49123456789,password\n" +
+				"Jane,Doe,OLD@xyz.com,+49123456789,password\n",
+			wantRows: []types.ImportRow{
+				{Row: 2, Email: "john@xyz.com", Status: types.ImportStatusCreated},
+				{Row: 3, Email: "JOHN@xyz.com", Status: types.ImportStatusFailed},
+				{Row: 4, Email: "OLD@xyz.com", Status: types.ImportStatusFailed},
+			},
+			wantBatches: 1,
+		},
+		{
+			name:   "Dry Run Case",
+			format: types.ImportFormatCSV,
+			input:  "first_name,last_name,email,phone,password\nJohn,Doe,john@xyz.com,+49123456789,password\n",
+			opts:   types.ImportOptions{DryRun: true},
+			wantRows: []types.ImportRow{
+				{Row: 2, Email: "john@xyz.com", Status: types.ImportStatusCreated},
+			},
+			wantBatches: 0,
+		},
+		{
+			name:    "Missing Column Case",
+			format:  types.ImportFormatCSV,
+			input:   "first_name,last_name,email,phone\n",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Column Case",
+			format:  types.ImportFormatCSV,
+			input:   "first_name,last_name,email,phone,password,password_hash\n",
+			wantErr: true,
+		},
+		{
+			name:    "Too Many Rows Case",
+			format:  types.ImportFormatNDJSON,
+			input:   strings.Repeat("{}\n", MaxRows+1),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &testStore{users: map[string]types.User{existing.Email: existing}}
+			importer := create(store, store)
+
+			got, err := importer.Import(context.Background(), usecase.Actor{IsAdmin: true}, strings.NewReader(tt.input), tt.format, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			} else if err != nil {
+				assert.ErrorIs(t, err, types.ErrBadRequest)
+				return
+			}
+
+			assert.Equal(t, tt.opts.DryRun, got.DryRun)
+			assert.Equal(t, len(tt.wantRows), len(got.Rows))
+			for i, want := range tt.wantRows {
+				row := got.Rows[i]
+				assert.Equal(t, want.Row, row.Row)
+				assert.Equal(t, want.Email, row.Email)
+				assert.Equal(t, want.Status, row.Status)
+				if want.Status == types.ImportStatusFailed {
+					assert.NotEmpty(t, row.Error)
+				} else if want.ID != uuid.Nil {
+					assert.Equal(t, want.ID, row.ID)
+				} else if !tt.opts.DryRun {
+					assert.Equal(t, store.users[row.Email].ID, row.ID)
+				}
+			}
+			assert.Equal(t, got.Created+got.Updated+got.Failed, len(got.Rows))
+			assert.Equal(t, tt.wantBatches, store.batches)
+		})
+	}
+}
+
+func Test_ImportKeepsAdmin(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	admin := types.User{ID: uuid.New(), Email: "admin@xyz.com", IsAdmin: true, PasswordHash: string(hash)}
+
+	tests := []struct {
+		name        string
+		format      types.ImportFormat
+		input       string
+		wantIsAdmin bool
+	}{
+		{
+			name:        "CSV Without Column Case",
+			format:      types.ImportFormatCSV,
+			input:       "first_name,last_name,email,phone,password\nAd,Min,admin@xyz.com,+49123456789,password\n",
+			wantIsAdmin: true,
+		},
+		{
+			name:        "CSV Empty Value Case",
+			format:      types.ImportFormatCSV,
+			input:       "first_name,last_name,email,phone,password,is_admin\nAd,Min,ADMIN@xyz.com,+49123456789,password,\n",
+			wantIsAdmin: true,
+		},
+		{
+			name:        "CSV Demote Case",
+			format:      types.ImportFormatCSV,
+			input:       "first_name,last_name,email,phone,password,is_admin\nAd,Min,admin@xyz.com,+49123456789,password,false\n",
+			wantIsAdmin: false,
+		},
+		{
+			name:        "NDJSON Without Field Case",
+			format:      types.ImportFormatNDJSON,
+			input:       `{"first_name":"Ad","last_name":"Min","email":"admin@xyz.com","phone":"+49123456789","password":"password"}` + "\n",
+			wantIsAdmin: true,
+		},
+		{
+			name:        "NDJSON Demote Case",
+			format:      types.ImportFormatNDJSON,
+			input:       `{"first_name":"Ad","last_name":"Min","email":"admin@xyz.com","phone":"+49123456789","password":"password","is_admin":false}` + "\n",
+			wantIsAdmin: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &testStore{users: map[string]types.User{admin.Email: admin}}
+			importer := create(store, store)
+
+			got, err := importer.Import(context.Background(), usecase.Actor{IsAdmin: true}, strings.NewReader(tt.input), tt.format, types.ImportOptions{Upsert: true})
+			if !assert.NoError(t, err) || !assert.Len(t, got.Rows, 1) {
+				return
+			}
+			assert.Equal(t, types.ImportStatusUpdated, got.Rows[0].Status)
+			var saved types.User
+			for _, user := range store.users {
+				if user.ID == admin.ID && user.FirstName == "Ad" {
+					saved = user
+				}
+			}
+			assert.Equal(t, admin.ID, saved.ID)
+			assert.Equal(t, tt.wantIsAdmin, saved.IsAdmin)
+			// the password is unchanged, so is its hash
+			assert.Equal(t, admin.PasswordHash, saved.PasswordHash)
+		})
+	}
+}
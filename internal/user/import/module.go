@@ -0,0 +1,9 @@
+package userImport
+
+import (
+	"go.uber.org/fx"
+)
+
+var FXUserImportProvide = fx.Provide(
+	fx.Annotate(create, fx.ParamTags("", `name:"cachedUserBatchSaver"`)),
+)
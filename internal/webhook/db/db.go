@@ -0,0 +1,183 @@
+package webhookDB
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/transaction"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+type db struct {
+	*gorm.DB
+}
+
+// deliveries queries the deliveries of webhooks, db queries the webhooks themselves
+type deliveries struct {
+	*db
+}
+
+func create(g *gorm.DB) *db {
+	return &db{
+		DB: g,
+	}
+}
+
+// conn returns the transaction of the unit of work of the context, so that the queries join it
+func (d *db) conn(ctx context.Context) *gorm.DB {
+	return transaction.DB(ctx, d.DB)
+}
+
+func (d *db) Get(ctx context.Context, id uuid.UUID) (types.Webhook, error) {
+	var webhook types.Webhook
+	err := d.conn(ctx).Table("webhooks").Where("id = ?", id).First(&webhook).Error
+	return webhook, types.DBError(err)
+}
+
+func (d *db) Query(ctx context.Context, params types.QueryParams) ([]types.Webhook, error) {
+	var webhooks []types.Webhook
+	err := types.Query(d.conn(ctx).Table("webhooks"), params).Order("created_at, id").Find(&webhooks).Error
+	return webhooks, types.DBError(err)
+}
+
+// Save creates a new webhook or overwrites the url and events of an existing one, and its secret if given
+func (d *db) Save(ctx context.Context, webhook types.Webhook) (types.Webhook, error) {
+	if webhook.ToSave() {
+		return webhook, types.DBError(d.conn(ctx).Table("webhooks").Create(&webhook).Error)
+	}
+
+	updates := map[string]any{"url": webhook.URL, "events": webhook.Events}
+	if webhook.Secret != "" {
+		updates["secret"] = webhook.Secret
+	}
+	result := d.conn(ctx).Table("webhooks").Where("id = ?", webhook.ID).Updates(updates)
+	if result.Error != nil {
+		return types.Webhook{}, types.DBError(result.Error)
+	} else if result.RowsAffected == 0 {
+		return types.Webhook{}, types.ErrNotFound
+	}
+	return d.Get(ctx, webhook.ID)
+}
+
+// Delete deletes the webhook with its deliveries
+func (d *db) Delete(ctx context.Context, id uuid.UUID) error {
+	result := d.conn(ctx).Table("webhooks").Where("id = ?", id).Delete(nil)
+	if result.Error != nil {
+		return types.DBError(result.Error)
+	} else if result.RowsAffected == 0 {
+		return types.ErrNotFound
+	}
+	return nil
+}
+
+func (d *db) EnqueueDeliveries(ctx context.Context, events []types.UserEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	kinds := make(types.Array[types.UserEventKind], 0, len(events))
+	for _, event := range events {
+		kinds = append(kinds, event.Kind)
+	}
+	var webhooks []types.Webhook
+	if err := d.conn(ctx).Table("webhooks").Where("events && ?", kinds).Find(&webhooks).Error; err != nil {
+		return types.DBError(err)
+	}
+
+	var rows []map[string]any
+	for _, event := range events {
+		for _, webhook := range webhooks {
+			if webhook.Subscribes(event.Kind) {
+				rows = append(rows, deliveryRow(types.NewWebhookDelivery(webhook.ID, event)))
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	// Events relayed again are already enqueued
+	return types.DBError(d.conn(ctx).Table("webhook_deliveries").
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "webhook_id"}, {Name: "event_id"}}, DoNothing: true}).
+		Create(&rows).Error)
+}
+
+func deliveryRow(delivery types.WebhookDelivery) map[string]any {
+	return map[string]any{
+		"id":              delivery.ID,
+		"webhook_id":      delivery.WebhookID,
+		"event_id":        delivery.Event.ID,
+		"event":           delivery.Event,
+		"state":           delivery.State,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"created_at":      delivery.CreatedAt,
+		"updated_at":      delivery.UpdatedAt,
+	}
+}
+
+// deliveryColumns are the selected columns of deliveries
+const deliveryColumns = "id, webhook_id, event, state, attempts, next_attempt_at, created_at, updated_at"
+
+// ClaimDeliveries claims the deliveries that are due the longest, skipping the ones locked by concurrent claimers
+func (d *db) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]types.WebhookDelivery, error) {
+	now := time.Now().UTC()
+	var deliveries []types.WebhookDelivery
+	err := d.conn(ctx).Raw(`UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id IN (
+		SELECT id FROM webhook_deliveries WHERE state = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at LIMIT ? FOR UPDATE SKIP LOCKED
+	) RETURNING `+deliveryColumns, now.Add(lease), types.WebhookDeliveryPending, now, limit).Find(&deliveries).Error
+	return deliveries, types.DBError(err)
+}
+
+func (d *db) SaveAttempt(ctx context.Context, delivery types.WebhookDelivery, attempt types.WebhookAttempt) error {
+	attempt.ID, attempt.DeliveryID = uuid.New(), delivery.ID
+	return transaction.Do(ctx, d.DB, func(ctx context.Context) error {
+		if err := d.conn(ctx).Table("webhook_attempts").Create(&attempt).Error; err != nil {
+			return types.DBError(err)
+		}
+		return types.DBError(d.conn(ctx).Table("webhook_deliveries").Where("id = ?", delivery.ID).Updates(map[string]any{
+			"state":           delivery.State,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"updated_at":      attempt.AttemptedAt,
+		}).Error)
+	})
+}
+
+// Query returns the deliveries matching the conditions, newest first
+func (d *deliveries) Query(ctx context.Context, params types.QueryParams) ([]types.WebhookDelivery, error) {
+	var deliveries []types.WebhookDelivery
+	err := types.Query(d.conn(ctx).Table("webhook_deliveries").Select(deliveryColumns), params).
+		Order("created_at DESC, id").Find(&deliveries).Error
+	return deliveries, types.DBError(err)
+}
+
+func (d *db) GetDelivery(ctx context.Context, webhookID, id uuid.UUID) (types.WebhookDelivery, error) {
+	var delivery types.WebhookDelivery
+	if err := d.conn(ctx).Table("webhook_deliveries").Select(deliveryColumns).
+		Where("id = ? AND webhook_id = ?", id, webhookID).First(&delivery).Error; err != nil {
+		return types.WebhookDelivery{}, types.DBError(err)
+	}
+	delivery.Log = []types.WebhookAttempt{}
+	err := d.conn(ctx).Table("webhook_attempts").Where("delivery_id = ?", id).Order("attempted_at, id").Find(&delivery.Log).Error
+	return delivery, types.DBError(err)
+}
+
+func (d *db) Redeliver(ctx context.Context, webhookID, id uuid.UUID) (types.WebhookDelivery, error) {
+	now := time.Now().UTC()
+	result := d.conn(ctx).Table("webhook_deliveries").Where("id = ? AND webhook_id = ?", id, webhookID).Updates(map[string]any{
+		"state":           types.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	})
+	if result.Error != nil {
+		return types.WebhookDelivery{}, types.DBError(result.Error)
+	} else if result.RowsAffected == 0 {
+		return types.WebhookDelivery{}, types.ErrNotFound
+	}
+	return d.GetDelivery(ctx, webhookID, id)
+}
@@ -0,0 +1,289 @@
+package webhookDB
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+var postgresContainer testcontainers.Container
+var ip, port string
+
+func TestMain(m *testing.M) {
+	postgresContainer, ip, port = postgres.Test_Create_Container()
+	defer func(postgresContainer testcontainers.Container, ctx context.Context) {
+		_ = postgresContainer.Terminate(ctx)
+	}(postgresContainer, context.Background())
+
+	defer os.Exit(m.Run())
+}
+
+func Test_Save(t *testing.T) {
+	dbName := "test-webhook-save"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+
+	webhookDB := create(db)
+	ctx := context.Background()
+	created, err := webhookDB.Save(ctx, types.Webhook{
+		URL:    "https://example.com/hooks",
+		Events: types.Array[types.UserEventKind]{types.UserCreated},
+		Secret: "0123456789abcdef",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// test
+	tests := []struct {
+		name    string
+		webhook types.Webhook
+		want    types.Webhook
+		wantErr error
+	}{
+		{
+			name:    "Update Case",
+			webhook: types.Webhook{ID: created.ID, URL: "https://example.com/v2", Events: types.Array[types.UserEventKind]{types.UserDeleted}, Secret: "fedcba9876543210"},
+			want:    types.Webhook{ID: created.ID, URL: "https://example.com/v2", Events: types.Array[types.UserEventKind]{types.UserDeleted}, Secret: "fedcba9876543210", CreatedAt: created.CreatedAt},
+		},
+		{
+			name:    "Keep Secret Case",
+			webhook: types.Webhook{ID: created.ID, URL: "https://example.com/v3", Events: types.Array[types.UserEventKind]{types.UserCreated, types.UserDeleted}},
+			want:    types.Webhook{ID: created.ID, URL: "https://example.com/v3", Events: types.Array[types.UserEventKind]{types.UserCreated, types.UserDeleted}, Secret: "fedcba9876543210", CreatedAt: created.CreatedAt},
+		},
+		{
+			name:    "Not Found Case",
+			webhook: types.Webhook{ID: uuid.New(), URL: "https://example.com", Events: types.Array[types.UserEventKind]{types.UserCreated}},
+			wantErr: types.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := webhookDB.Save(ctx, tt.webhook)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			} else if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_EnqueueDeliveries(t *testing.T) {
+	dbName := "test-webhook-enqueue-deliveries"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+
+	webhookDB := create(db)
+	ctx := context.Background()
+	created, err := webhookDB.Save(ctx, types.Webhook{
+		URL:    "https://example.com/created",
+		Events: types.Array[types.UserEventKind]{types.UserCreated},
+		Secret: "0123456789abcdef",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	all, err := webhookDB.Save(ctx, types.Webhook{
+		URL:    "https://example.com/all",
+		Events: types.Array[types.UserEventKind]{types.UserCreated, types.UserDeleted},
+		Secret: "0123456789abcdef",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	userCreated, userDeleted := types.NewUserCreated(types.User{ID: uuid.New()}), types.NewUserDeleted(uuid.New())
+
+	// test
+	tests := []struct {
+		name    string
+		events  []types.UserEvent
+		webhook uuid.UUID
+		want    []types.UserEvent
+	}{
+		{
+			name:    "Subscribed Case",
+			events:  []types.UserEvent{userCreated, userDeleted},
+			webhook: created.ID,
+			want:    []types.UserEvent{userCreated},
+		},
+		{
+			name:    "Relayed Again Case",
+			events:  []types.UserEvent{userCreated, userDeleted},
+			webhook: all.ID,
+			want:    []types.UserEvent{userDeleted, userCreated},
+		},
+		{
+			name:    "Unsubscribed Case",
+			events:  []types.UserEvent{types.NewLoginFailed(uuid.Nil, "abc@xyz.com")},
+			webhook: all.ID,
+			want:    []types.UserEvent{userDeleted, userCreated},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !assert.NoError(t, webhookDB.EnqueueDeliveries(ctx, tt.events)) {
+				return
+			}
+			deliveries, err := (&deliveries{db: webhookDB}).Query(ctx, types.QueryParams{
+				Conditions: &types.WebhookDeliveryConditions{WebhookID: types.ToOptional(tt.webhook)},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			got := make([]types.UserEvent, len(deliveries))
+			for i, delivery := range deliveries {
+				assert.Equal(t, types.WebhookDeliveryPending, delivery.State)
+				got[i] = delivery.Event
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ClaimDeliveries(t *testing.T) {
+	dbName := "test-webhook-claim-deliveries"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+
+	webhookDB := create(db)
+	ctx := context.Background()
+	webhook, err := webhookDB.Save(ctx, types.Webhook{
+		URL:    "https://example.com/hooks",
+		Events: types.Array[types.UserEventKind]{types.UserDeleted},
+		Secret: "0123456789abcdef",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, webhookDB.EnqueueDeliveries(ctx, []types.UserEvent{
+		types.NewUserDeleted(uuid.New()), types.NewUserDeleted(uuid.New()), types.NewUserDeleted(uuid.New()),
+	})) {
+		return
+	}
+
+	// test
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{
+			name:  "Limit Case",
+			limit: 2,
+			want:  2,
+		},
+		{
+			name:  "Leased Case",
+			limit: 10,
+			want:  1,
+		},
+		{
+			name:  "Empty Case",
+			limit: 10,
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := webhookDB.ClaimDeliveries(ctx, tt.limit, time.Minute)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Len(t, got, tt.want)
+			for _, delivery := range got {
+				assert.Equal(t, webhook.ID, delivery.WebhookID)
+				assert.True(t, delivery.NextAttemptAt.After(time.Now()))
+			}
+		})
+	}
+}
+
+func Test_Redeliver(t *testing.T) {
+	dbName := "test-webhook-redeliver"
+	db := postgres.Test_Create_DB(ip, port, dbName)
+	defer postgres.Test_Drop_DB(db, ip, port, dbName)
+
+	webhookDB := create(db)
+	ctx := context.Background()
+	webhook, err := webhookDB.Save(ctx, types.Webhook{
+		URL:    "https://example.com/hooks",
+		Events: types.Array[types.UserEventKind]{types.UserDeleted},
+		Secret: "0123456789abcdef",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, webhookDB.EnqueueDeliveries(ctx, []types.UserEvent{types.NewUserDeleted(uuid.New())})) {
+		return
+	}
+	claimed, err := webhookDB.ClaimDeliveries(ctx, 1, time.Minute)
+	if !assert.NoError(t, err) || !assert.Len(t, claimed, 1) {
+		return
+	}
+	dead := claimed[0]
+	dead.State, dead.Attempts = types.WebhookDeliveryDead, 10
+	attempt := types.WebhookAttempt{AttemptedAt: time.Now().UTC().Truncate(time.Microsecond), StatusCode: 500, Error: "unexpected status 500", Duration: time.Millisecond}
+	if !assert.NoError(t, webhookDB.SaveAttempt(ctx, dead, attempt)) {
+		return
+	}
+
+	// test
+	tests := []struct {
+		name      string
+		webhookID uuid.UUID
+		id        uuid.UUID
+		wantErr   error
+	}{
+		{
+			name:      "Success Case",
+			webhookID: webhook.ID,
+			id:        dead.ID,
+		},
+		{
+			name:      "Other Webhook Case",
+			webhookID: uuid.New(),
+			id:        dead.ID,
+			wantErr:   types.ErrNotFound,
+		},
+		{
+			name:      "Not Found Case",
+			webhookID: webhook.ID,
+			id:        uuid.New(),
+			wantErr:   types.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := webhookDB.Redeliver(ctx, tt.webhookID, tt.id)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			} else if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, types.WebhookDeliveryPending, got.State)
+			assert.Zero(t, got.Attempts)
+			assert.False(t, got.NextAttemptAt.After(time.Now()))
+			if assert.Len(t, got.Log, 1) {
+				assert.Equal(t, dead.ID, got.Log[0].DeliveryID)
+				assert.Equal(t, 500, got.Log[0].StatusCode)
+				assert.Equal(t, attempt.Error, got.Log[0].Error)
+				assert.Equal(t, attempt.Duration, got.Log[0].Duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package webhookDB
+
+import (
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"go.uber.org/fx"
+)
+
+var FXWebhookDBProvide = fx.Provide(
+	create,
+	func(d *db) datasource.Getter[types.Webhook] { return d },
+	func(d *db) datasource.Querier[types.Webhook] { return d },
+	func(d *db) datasource.Saver[types.Webhook] { return d },
+	func(d *db) datasource.Deleter[types.Webhook] { return d },
+	func(d *db) datasource.Querier[types.WebhookDelivery] { return &deliveries{db: d} },
+	func(d *db) datasource.WebhookDeliveryEnqueuer { return d },
+	func(d *db) datasource.WebhookDeliveryClaimer { return d },
+	func(d *db) datasource.WebhookAttemptSaver { return d },
+	func(d *db) datasource.WebhookDeliveryGetter { return d },
+	func(d *db) datasource.WebhookRedeliverer { return d },
+)
@@ -0,0 +1,190 @@
+package webhookDelivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/worker"
+)
+
+const (
+	// dispatchBatchSize is the amount of deliveries sent at once
+	dispatchBatchSize = 20
+	// dispatchInterval is how often due deliveries are polled once there are none
+	dispatchInterval = time.Second
+	// deliveryTimeout is how long a webhook may take to respond
+	deliveryTimeout = 10 * time.Second
+	// deliveryLease is how long claimed deliveries are skipped by other dispatchers, longer than sending them takes
+	deliveryLease = time.Minute
+	// maxAttempts is the amount of failed attempts after which a delivery is dead
+	maxAttempts = 10
+	// baseBackoff is the delay after the first failed attempt, it doubles with each further one
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+	// maxResponseExcerpt is the amount of bytes of the response of a failed attempt that is logged
+	maxResponseExcerpt = 512
+)
+
+// Dispatcher sends the due deliveries to their webhooks, several dispatchers can run at once
+type Dispatcher struct {
+	claimer     datasource.WebhookDeliveryClaimer
+	getter      datasource.Getter[types.Webhook]
+	saver       datasource.WebhookAttemptSaver
+	client      *http.Client
+	interval    time.Duration
+	backoff     time.Duration
+	maxAttempts int
+}
+
+func createDispatcher(
+	claimer datasource.WebhookDeliveryClaimer,
+	getter datasource.Getter[types.Webhook],
+	saver datasource.WebhookAttemptSaver,
+) *Dispatcher {
+	return &Dispatcher{
+		claimer: claimer,
+		getter:  getter,
+		saver:   saver,
+		client: &http.Client{
+			Timeout: deliveryTimeout,
+			// Redirects are failures, the webhook has to be updated to the new url
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		interval:    dispatchInterval,
+		backoff:     baseBackoff,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Run sends the due deliveries until the context is done, full batches are followed by the next one right away
+func (d *Dispatcher) Run(ctx context.Context) {
+	worker.Poll(ctx, d.interval, dispatchBatchSize, "failed to dispatch webhook deliveries", d.dispatch)
+}
+
+// dispatch sends a batch of due deliveries concurrently and returns how many were claimed
+func (d *Dispatcher) dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.claimer.ClaimDeliveries(ctx, dispatchBatchSize, deliveryLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		errs     error
+		webhooks = make(map[uuid.UUID]types.Webhook)
+	)
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.getter.Get(ctx, delivery.WebhookID)
+			if errors.Is(err, types.ErrNotFound) {
+				// the webhook was deleted with its deliveries after they were claimed
+				continue
+			} else if err != nil {
+				errs = errors.CombineErrors(errs, err)
+				continue
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.deliver(ctx, webhook, delivery); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = errors.CombineErrors(errs, errors.Wrapf(err, "delivery %s", delivery.ID))
+			}
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), errs
+}
+
+// deliver sends the delivery once and saves the attempt, attempts cut short by the context are retried once the
+// lease of the delivery ends
+func (d *Dispatcher) deliver(ctx context.Context, webhook types.Webhook, delivery types.WebhookDelivery) error {
+	attempt := d.send(ctx, webhook, delivery.Event)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d.schedule(&delivery, attempt)
+	return d.saver.SaveAttempt(ctx, delivery, attempt)
+}
+
+// send posts the event as JSON to the webhook, signed with its secret
+func (d *Dispatcher) send(ctx context.Context, webhook types.Webhook, event types.UserEvent) (attempt types.WebhookAttempt) {
+	attempt.AttemptedAt = time.Now().UTC()
+	defer func() {
+		attempt.Duration = time.Since(attempt.AttemptedAt)
+	}()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, event.ID.String())
+	req.Header.Set(EventKindHeader, string(event.Kind))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(attempt.AttemptedAt.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, attempt.AttemptedAt, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseExcerpt))
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		if len(excerpt) > 0 {
+			attempt.Error += ": " + string(excerpt)
+		}
+	}
+	return attempt
+}
+
+// schedule sets the state and the next attempt of the delivery after the attempt
+func (d *Dispatcher) schedule(delivery *types.WebhookDelivery, attempt types.WebhookAttempt) {
+	delivery.UpdatedAt = attempt.AttemptedAt
+	if attempt.Succeeded() {
+		delivery.State = types.WebhookDeliverySucceeded
+		return
+	}
+	delivery.Attempts++
+	if delivery.Attempts >= d.maxAttempts {
+		delivery.State = types.WebhookDeliveryDead
+		return
+	}
+	delivery.NextAttemptAt = attempt.AttemptedAt.Add(backoff(d.backoff, delivery.Attempts))
+}
+
+// backoff returns the delay after the given amount of failed attempts, the base doubled per attempt up to maxBackoff
+func backoff(base time.Duration, attempts int) time.Duration {
+	if attempts > 30 {
+		return maxBackoff
+	}
+	return min(base<<(attempts-1), maxBackoff)
+}
@@ -0,0 +1,261 @@
+package webhookDelivery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+const testSecret = "0123456789abcdef"
+
+// fakeDeliveries is an in-memory store of webhooks and deliveries, claimed deliveries are due again after the lease
+type fakeDeliveries struct {
+	mu         sync.Mutex
+	webhooks   map[uuid.UUID]types.Webhook
+	deliveries []types.WebhookDelivery
+	log        []types.WebhookAttempt
+}
+
+func (f *fakeDeliveries) Get(_ context.Context, id uuid.UUID) (types.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if webhook, ok := f.webhooks[id]; ok {
+		return webhook, nil
+	}
+	return types.Webhook{}, types.ErrNotFound
+}
+
+func (f *fakeDeliveries) ClaimDeliveries(_ context.Context, limit int, lease time.Duration) ([]types.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	var claimed []types.WebhookDelivery
+	for i := range f.deliveries {
+		if len(claimed) < limit && f.deliveries[i].State == types.WebhookDeliveryPending && !f.deliveries[i].NextAttemptAt.After(now) {
+			f.deliveries[i].NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, f.deliveries[i])
+		}
+	}
+	return claimed, nil
+}
+
+func (f *fakeDeliveries) SaveAttempt(_ context.Context, delivery types.WebhookDelivery, attempt types.WebhookAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.deliveries {
+		if f.deliveries[i].ID == delivery.ID {
+			f.deliveries[i] = delivery
+		}
+	}
+	attempt.DeliveryID = delivery.ID
+	f.log = append(f.log, attempt)
+	return nil
+}
+
+// receiver is a webhook endpoint which verifies the deliveries and answers with the statuses in order,
+// repeating the last one
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	events   []types.UserEvent
+	errs     []error
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	if err := Verify(testSecret, req.Header.Get(TimestampHeader), req.Header.Get(SignatureHeader), body, time.Minute, time.Now()); err != nil {
+		rc.errs = append(rc.errs, err)
+	}
+	var event types.UserEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		rc.errs = append(rc.errs, err)
+	} else if req.Header.Get(EventIDHeader) != event.ID.String() || req.Header.Get(EventKindHeader) != string(event.Kind) {
+		rc.errs = append(rc.errs, ErrInvalidSignature)
+	}
+	rc.events = append(rc.events, event)
+
+	status := rc.statuses[0]
+	if len(rc.statuses) > 1 {
+		rc.statuses = rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+	if status >= 300 {
+		_, _ = w.Write([]byte("boom"))
+	}
+}
+
+func Test_Dispatcher(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		url          string
+		deleted      bool
+		maxAttempts  int
+		wantState    types.WebhookDeliveryState
+		wantAttempts int
+		wantLog      []types.WebhookAttempt
+	}{
+		{
+			name:        "Success Case",
+			statuses:    []int{http.StatusNoContent},
+			maxAttempts: 3,
+			wantState:   types.WebhookDeliverySucceeded,
+			wantLog:     []types.WebhookAttempt{{StatusCode: http.StatusNoContent}},
+		},
+		{
+			name:         "Retry Case",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			maxAttempts:  3,
+			wantState:    types.WebhookDeliverySucceeded,
+			wantAttempts: 2,
+			wantLog: []types.WebhookAttempt{
+				{StatusCode: http.StatusInternalServerError, Error: "unexpected status 500: boom"},
+				{StatusCode: http.StatusBadGateway, Error: "unexpected status 502: boom"},
+				{StatusCode: http.StatusOK},
+			},
+		},
+		{
+			name:         "Dead Letter Case",
+			statuses:     []int{http.StatusServiceUnavailable},
+			maxAttempts:  3,
+			wantState:    types.WebhookDeliveryDead,
+			wantAttempts: 3,
+			wantLog: []types.WebhookAttempt{
+				{StatusCode: http.StatusServiceUnavailable, Error: "unexpected status 503: boom"},
+				{StatusCode: http.StatusServiceUnavailable, Error: "unexpected status 503: boom"},
+				{StatusCode: http.StatusServiceUnavailable, Error: "unexpected status 503: boom"},
+			},
+		},
+		{
+			name:         "Redirect Case",
+			statuses:     []int{http.StatusMovedPermanently},
+			maxAttempts:  1,
+			wantState:    types.WebhookDeliveryDead,
+			wantAttempts: 1,
+			wantLog:      []types.WebhookAttempt{{StatusCode: http.StatusMovedPermanently, Error: "unexpected status 301: boom"}},
+		},
+		{
+			name:         "Unreachable Case",
+			url:          closed.URL,
+			maxAttempts:  1,
+			wantState:    types.WebhookDeliveryDead,
+			wantAttempts: 1,
+			wantLog:      []types.WebhookAttempt{{Error: "unreachable"}},
+		},
+		{
+			name:        "Deleted Webhook Case",
+			statuses:    []int{http.StatusOK},
+			deleted:     true,
+			maxAttempts: 3,
+			wantState:   types.WebhookDeliveryPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{statuses: tt.statuses}
+			server := httptest.NewServer(rc)
+			defer server.Close()
+
+			webhook := types.Webhook{ID: uuid.New(), URL: server.URL, Events: types.Array[types.UserEventKind]{types.UserDeleted}, Secret: testSecret}
+			if tt.url != "" {
+				webhook.URL = tt.url
+			}
+			event := types.NewUserDeleted(uuid.New())
+			store := &fakeDeliveries{
+				webhooks:   map[uuid.UUID]types.Webhook{webhook.ID: webhook},
+				deliveries: []types.WebhookDelivery{types.NewWebhookDelivery(webhook.ID, event)},
+			}
+			if tt.deleted {
+				delete(store.webhooks, webhook.ID)
+			}
+
+			d := createDispatcher(store, store, store)
+			d.backoff, d.maxAttempts = time.Millisecond, tt.maxAttempts
+
+			ctx := context.Background()
+			for range 100 {
+				if _, err := d.dispatch(ctx); !assert.NoError(t, err) {
+					return
+				}
+				if store.deliveries[0].State != types.WebhookDeliveryPending || tt.deleted {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			delivery := store.deliveries[0]
+			assert.Equal(t, tt.wantState, delivery.State)
+			assert.Equal(t, tt.wantAttempts, delivery.Attempts)
+			if assert.Len(t, store.log, len(tt.wantLog)) {
+				for i, want := range tt.wantLog {
+					got := store.log[i]
+					assert.Equal(t, delivery.ID, got.DeliveryID)
+					assert.Equal(t, want.StatusCode, got.StatusCode)
+					if want.Error == "unreachable" {
+						assert.NotEmpty(t, got.Error)
+					} else {
+						assert.Equal(t, want.Error, got.Error)
+					}
+				}
+			}
+			if tt.url == "" {
+				assert.Empty(t, rc.errs)
+				for _, got := range rc.events {
+					assert.Equal(t, event, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_Backoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{
+			name:     "First Attempt Case",
+			attempts: 1,
+			want:     baseBackoff,
+		},
+		{
+			name:     "Doubled Case",
+			attempts: 4,
+			want:     8 * baseBackoff,
+		},
+		{
+			name:     "Capped Case",
+			attempts: maxAttempts,
+			want:     maxBackoff,
+		},
+		{
+			name:     "Overflow Case",
+			attempts: 100,
+			want:     maxBackoff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, backoff(baseBackoff, tt.attempts))
+		})
+	}
+}
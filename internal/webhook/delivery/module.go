@@ -0,0 +1,21 @@
+package webhookDelivery
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/event"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/worker"
+)
+
+// run dispatches the deliveries in the background while the application is running
+func run(lc fx.Lifecycle, dispatcher *Dispatcher) {
+	worker.Run(lc, dispatcher.Run)
+}
+
+var FXWebhookDeliveryModule = fx.Options(
+	fx.Provide(
+		fx.Annotate(createPublisher, fx.ResultTags(event.PublisherGroup)),
+		createDispatcher,
+	),
+	fx.Invoke(run),
+)
@@ -0,0 +1,25 @@
+package webhookDelivery
+
+import (
+	"context"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/datasource"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/event"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+)
+
+// publisher enqueues the deliveries of the relayed events. The relay publishes in its outbox transaction, so the
+// deliveries are enqueued once the events are marked as published in the outbox.
+type publisher struct {
+	enqueuer datasource.WebhookDeliveryEnqueuer
+}
+
+func createPublisher(enqueuer datasource.WebhookDeliveryEnqueuer) event.Publisher {
+	return &publisher{
+		enqueuer: enqueuer,
+	}
+}
+
+func (p *publisher) Publish(ctx context.Context, events []types.UserEvent) error {
+	return p.enqueuer.EnqueueDeliveries(ctx, events)
+}
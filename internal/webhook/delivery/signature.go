@@ -0,0 +1,57 @@
+// Package webhookDelivery delivers the user events to the webhooks subscribed to them. The relay enqueues a delivery
+// per webhook in its outbox transaction, and the dispatcher sends the due deliveries, retrying failed ones with an
+// exponential backoff until they are dead.
+package webhookDelivery
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// EventIDHeader is the id of the delivered event, receivers can skip events they have seen already
+	EventIDHeader   = "X-Webhook-Id"
+	EventKindHeader = "X-Webhook-Event"
+	// TimestampHeader is the unix time in seconds the delivery was sent at, it is part of the signature
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+	// signaturePrefix names the algorithm of signatures
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredSignature = errors.New("expired webhook signature")
+)
+
+// Sign returns the signature of a delivery sent at the timestamp, the hex encoded HMAC-SHA256 of
+// "<timestamp>.<body>" with the secret of the webhook, prefixed by "sha256="
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a delivery as its receiver would. Deliveries sent longer
+// than the tolerance before now are rejected, so that captured deliveries can't be replayed.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.CombineErrors(ErrInvalidSignature, err)
+	}
+	sentAt := time.Unix(unix, 0)
+	if now.Sub(sentAt) > tolerance || sentAt.Sub(now) > tolerance {
+		return ErrExpiredSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, sentAt, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package webhookDelivery
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Verify(t *testing.T) {
+	sentAt := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"kind":"user.deleted"}`)
+	signature := Sign(testSecret, sentAt, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		wantErr   error
+	}{
+		{
+			name:      "Valid Case",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(sentAt.Unix(), 10),
+			signature: signature,
+			body:      body,
+			now:       sentAt.Add(time.Minute),
+		},
+		{
+			name:      "Wrong Secret Case",
+			secret:    "fedcba9876543210",
+			timestamp: strconv.FormatInt(sentAt.Unix(), 10),
+			signature: signature,
+			body:      body,
+			now:       sentAt,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "Tampered Body Case",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(sentAt.Unix(), 10),
+			signature: signature,
+			body:      []byte(`{"kind":"user.created"}`),
+			now:       sentAt,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "Tampered Timestamp Case",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(sentAt.Unix()+1, 10),
+			signature: signature,
+			body:      body,
+			now:       sentAt,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "Expired Case",
+			secret:    testSecret,
+			timestamp: strconv.FormatInt(sentAt.Unix(), 10),
+			signature: signature,
+			body:      body,
+			now:       sentAt.Add(time.Hour),
+			wantErr:   ErrExpiredSignature,
+		},
+		{
+			name:      "Invalid Timestamp Case",
+			secret:    testSecret,
+			timestamp: "yesterday",
+			signature: signature,
+			body:      body,
+			now:       sentAt,
+			wantErr:   ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute, tt.now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
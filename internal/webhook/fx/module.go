@@ -0,0 +1,15 @@
+package webhookDI
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+	webhookDB "github.com/pedramktb/schwarzit-probearbeit/internal/webhook/db"
+	webhookDelivery "github.com/pedramktb/schwarzit-probearbeit/internal/webhook/delivery"
+)
+
+var FXWebhookModule = fx.Module("webhook",
+	webhookDB.FXWebhookDBProvide,
+	usecase.FXWebhookServiceProvide,
+	webhookDelivery.FXWebhookDeliveryModule,
+)
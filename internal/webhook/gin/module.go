@@ -0,0 +1,29 @@
+package webhookGinRouter
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+)
+
+func provideRoutes(e gin.IRouter, r *r, authMiddleware gin.HandlerFunc) {
+	g := e.Group("/api/v1/webhooks")
+	{
+		g.Use(authMiddleware)
+		g.POST("/", r.Create)
+		g.GET("/", r.Query)
+		g.GET("/:id", r.Get)
+		g.PUT("/:id", r.Update)
+		g.DELETE("/:id", r.Delete)
+		g.GET("/:id/deliveries", r.QueryDeliveries)
+		g.GET("/:id/deliveries/:delivery_id", r.GetDelivery)
+		g.POST("/:id/deliveries/:delivery_id/redeliver", r.Redeliver)
+	}
+}
+
+var FXWebhookGinRouterModule = fx.Options(
+	fx.Provide(create),
+	fx.Invoke(fx.Annotate(
+		provideRoutes,
+		fx.ParamTags("", "", `name:"authMiddleware"`),
+	)),
+)
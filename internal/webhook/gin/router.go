@@ -0,0 +1,285 @@
+package webhookGinRouter
+
+import (
+	"net/http"
+
+	"github.com/cockroachdb/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/dtos"
+	ginRouter "github.com/pedramktb/schwarzit-probearbeit/internal/gin"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/types"
+	"github.com/pedramktb/schwarzit-probearbeit/internal/usecase"
+)
+
+type r struct {
+	webhookService *usecase.WebhookService
+}
+
+func create(webhookService *usecase.WebhookService) *r {
+	return &r{webhookService}
+}
+
+// @Summary Create a webhook (admin)
+// @Description Subscribe an endpoint to kinds of user events. Each event is posted as JSON (UserEvent) with the headers
+// @Description X-Webhook-Id (the event id), X-Webhook-Event (the kind), X-Webhook-Timestamp (unix seconds) and
+// @Description X-Webhook-Signature (`sha256=` and the hex HMAC-SHA256 of `<timestamp>.<body>` with the secret).
+// @Description Failed deliveries are retried with exponential backoff until they are dead.
+// @Description The secret is generated if it is missing, it is only returned in this response.
+// @Tags webhook
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param webhook body SaveWebhook true "Webhook"
+// @Success 200 {object} CreatedWebhook
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/webhooks [post]
+func (r *r) Create(c *gin.Context) {
+	webhookDTO := dtos.SaveWebhook{}
+	if err := c.ShouldBindJSON(&webhookDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	if webhook, err := r.webhookService.Create(c.Request.Context(), ginRouter.GetActor(c), webhookDTO.ToWebhook(uuid.Nil)); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.JSON(http.StatusOK, dtos.FromCreatedWebhook(&webhook))
+	}
+}
+
+// @Summary Query webhooks (admin)
+// @Description Query the webhooks, oldest first
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param params query Pagination false "Pagination Parameters"
+// @Success 200 {array} []Webhook
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/webhooks [get]
+func (r *r) Query(c *gin.Context) {
+	paramsDTO := dtos.Pagination{}
+	if err := c.ShouldBindQuery(&paramsDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	webhooks, err := r.webhookService.Query(c.Request.Context(), ginRouter.GetActor(c), types.QueryParams{Pagination: paramsDTO.ToPagination()})
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+	webhookDTOs := make([]dtos.Webhook, len(webhooks))
+	for i, webhook := range webhooks {
+		webhookDTOs[i] = dtos.FromWebhook(&webhook)
+	}
+	c.JSON(http.StatusOK, webhookDTOs)
+}
+
+// @Summary Get a webhook (admin)
+// @Description Get a webhook by id, without its secret
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} Webhook
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/webhooks/{id} [get]
+func (r *r) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	if webhook, err := r.webhookService.Get(c.Request.Context(), ginRouter.GetActor(c), id); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.JSON(http.StatusOK, dtos.FromWebhook(&webhook))
+	}
+}
+
+// @Summary Update a webhook (admin)
+// @Description Update the url and events of a webhook by id, and its secret if given.
+// @Description Pending deliveries are sent to the new url, with the new secret.
+// @Tags webhook
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param webhook body SaveWebhook true "Webhook"
+// @Success 200 {object} Webhook
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 422 {object} ErrorResponse "Unprocessable Error, the data violates a constraint"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/webhooks/{id} [put]
+func (r *r) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	webhookDTO := dtos.SaveWebhook{}
+	if err := c.ShouldBindJSON(&webhookDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	if webhook, err := r.webhookService.Update(c.Request.Context(), ginRouter.GetActor(c), id, webhookDTO.ToWebhook(id)); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.JSON(http.StatusOK, dtos.FromWebhook(&webhook))
+	}
+}
+
+// @Summary Delete a webhook (admin)
+// @Description Delete a webhook by id with its deliveries and their logs
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/webhooks/{id} [delete]
+func (r *r) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	if err := r.webhookService.Delete(c.Request.Context(), ginRouter.GetActor(c), id); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.Status(http.StatusOK)
+	}
+}
+
+// @Summary Query the deliveries of a webhook (admin)
+// @Description Query the deliveries of a webhook by id, newest first
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param params query WebhookDeliveryQueryParams false "Query Parameters"
+// @Success 200 {array} []WebhookDelivery
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (r *r) QueryDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		ginRouter.ErrorResponse(c, errors.CombineErrors(types.ErrInvalidID, err))
+		return
+	}
+
+	paramsDTO := dtos.WebhookDeliveryQueryParams{}
+	if err := c.ShouldBindQuery(&paramsDTO); err != nil {
+		ginRouter.ErrorResponse(c, dtos.BindingError(err))
+		return
+	}
+
+	deliveries, err := r.webhookService.QueryDeliveries(c.Request.Context(), ginRouter.GetActor(c), id, paramsDTO.ToQueryParams(id))
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+	deliveryDTOs := make([]dtos.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryDTOs[i] = dtos.FromWebhookDelivery(&delivery)
+	}
+	c.JSON(http.StatusOK, deliveryDTOs)
+}
+
+// @Summary Get a delivery of a webhook (admin)
+// @Description Get a delivery of a webhook by id, with the log of its attempts
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} WebhookDelivery
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/webhooks/{id}/deliveries/{delivery_id} [get]
+func (r *r) GetDelivery(c *gin.Context) {
+	id, deliveryID, err := deliveryParams(c)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	if delivery, err := r.webhookService.GetDelivery(c.Request.Context(), ginRouter.GetActor(c), id, deliveryID); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.JSON(http.StatusOK, dtos.FromWebhookDelivery(&delivery))
+	}
+}
+
+// @Summary Redeliver a delivery of a webhook (admin)
+// @Description Send a delivery of a webhook by id again, e.g. a dead one once the endpoint is fixed.
+// @Description The delivery is pending and due right away, its attempts are reset while its log is kept.
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} WebhookDelivery
+// @Failure 400 {object} ErrorResponse "Bad Request Error"
+// @Failure 401 {object} ErrorResponse "Unauthorized Error"
+// @Failure 403 {object} ErrorResponse "Forbidden Error"
+// @Failure 404 {object} ErrorResponse "Not Found Error"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /api/v1/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (r *r) Redeliver(c *gin.Context) {
+	id, deliveryID, err := deliveryParams(c)
+	if err != nil {
+		ginRouter.ErrorResponse(c, err)
+		return
+	}
+
+	if delivery, err := r.webhookService.Redeliver(c.Request.Context(), ginRouter.GetActor(c), id, deliveryID); err != nil {
+		ginRouter.ErrorResponse(c, err)
+	} else {
+		c.JSON(http.StatusOK, dtos.FromWebhookDelivery(&delivery))
+	}
+}
+
+// deliveryParams parses the webhook and delivery ids of the path
+func deliveryParams(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.CombineErrors(types.ErrInvalidID, err)
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.CombineErrors(types.ErrInvalidID, err)
+	}
+	return id, deliveryID, nil
+}
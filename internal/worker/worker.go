@@ -0,0 +1,55 @@
+// Package worker runs the background pollers of the service, such as the event relay and the webhook dispatcher
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pedramktb/schwarzit-probearbeit/internal/logging"
+)
+
+// Poll calls poll until the context is done, full batches are followed by the next one right away,
+// otherwise it waits for the interval. Errors are logged with the message, as the next poll retries.
+func Poll(ctx context.Context, interval time.Duration, batchSize int, msg string, poll func(ctx context.Context) (int, error)) {
+	for {
+		count, err := poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error(msg, zap.Error(err))
+		}
+		if err == nil && count == batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Run runs the function in the background while the application is running, stopping waits for it to return
+func Run(lc fx.Lifecycle, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
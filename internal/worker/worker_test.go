@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx/fxtest"
+)
+
+func Test_Poll(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []int
+		errs    []error
+		// polls is how many polls are expected before the interval
+		polls int
+	}{
+		{
+			name:    "Full Batches Case",
+			results: []int{10, 10, 3},
+			errs:    []error{nil, nil, nil},
+			polls:   3,
+		},
+		{
+			name:    "Partial Batch Case",
+			results: []int{3},
+			errs:    []error{nil},
+			polls:   1,
+		},
+		{
+			name:    "Error Case",
+			results: []int{10},
+			errs:    []error{errors.New("failed")},
+			polls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			polls := 0
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				Poll(ctx, time.Hour, 10, "failed to poll", func(ctx context.Context) (int, error) {
+					polls++
+					if polls == len(tt.results) {
+						defer cancel()
+					}
+					if polls > len(tt.results) {
+						return 0, nil
+					}
+					return tt.results[polls-1], tt.errs[polls-1]
+				})
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("poll didn't stop once the context was done")
+			}
+			assert.Equal(t, tt.polls, polls)
+		})
+	}
+}
+
+func Test_Run(t *testing.T) {
+	lc := fxtest.NewLifecycle(t)
+	started := make(chan struct{})
+	stopped := false
+	Run(lc, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		stopped = true
+	})
+
+	lc.RequireStart()
+	<-started
+	lc.RequireStop()
+	assert.True(t, stopped)
+}
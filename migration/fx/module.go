@@ -3,19 +3,55 @@ package migrationDI
 import (
 	"github.com/pedramktb/schwarzit-probearbeit/migration"
 	v1Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v1"
+	v10Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v10"
+	v2Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v2"
+	v3Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v3"
+	v4Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v4"
+	v5Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v5"
+	v6Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v6"
+	v7Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v7"
+	v8Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v8"
+	v9Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v9"
 	"go.uber.org/fx"
 )
 
 var FXMigrationModule = fx.Module("migration",
 	v1Migration.FXV1MigrationProvide,
+	v2Migration.FXV2MigrationProvide,
+	v3Migration.FXV3MigrationProvide,
+	v4Migration.FXV4MigrationProvide,
+	v5Migration.FXV5MigrationProvide,
+	v6Migration.FXV6MigrationProvide,
+	v7Migration.FXV7MigrationProvide,
+	v8Migration.FXV8MigrationProvide,
+	v9Migration.FXV9MigrationProvide,
+	v10Migration.FXV10MigrationProvide,
 	fx.Provide(fx.Annotate(
 		func(
 			v1Migrator migration.Migrator,
+			v2Migrator migration.Migrator,
+			v3Migrator migration.Migrator,
+			v4Migrator migration.Migrator,
+			v5Migrator migration.Migrator,
+			v6Migrator migration.Migrator,
+			v7Migrator migration.Migrator,
+			v8Migrator migration.Migrator,
+			v9Migrator migration.Migrator,
+			v10Migrator migration.Migrator,
 		) migration.Migrator {
 			return create(
 				v1Migrator,
+				v2Migrator,
+				v3Migrator,
+				v4Migrator,
+				v5Migrator,
+				v6Migrator,
+				v7Migrator,
+				v8Migrator,
+				v9Migrator,
+				v10Migrator,
 			)
 		},
-		fx.ParamTags(`name:"v1Migrator"`),
+		fx.ParamTags(`name:"v1Migrator"`, `name:"v2Migrator"`, `name:"v3Migrator"`, `name:"v4Migrator"`, `name:"v5Migrator"`, `name:"v6Migrator"`, `name:"v7Migrator"`, `name:"v8Migrator"`, `name:"v9Migrator"`, `name:"v10Migrator"`),
 	)),
 )
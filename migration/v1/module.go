@@ -9,8 +9,3 @@ var FXV1MigrationProvide = fx.Provide(
 	create,
 	fx.Annotate(func(m *migrator) migration.Migrator { return m }, fx.ResultTags(`name:"v1Migrator"`)),
 )
-
-var FXV1TestMigrationProvide = fx.Provide(
-	create,
-	fx.Annotate(func(m *migrator) migration.Migrator { return m }),
-)
@@ -0,0 +1,9 @@
+-- Audit log of the domain events of users
+-- The relay marks the events of the outbox as published rather than deleting them, so that the events of a user
+-- are kept and can be exported (subject access requests). The relay only reads the unpublished events.
+ALTER TABLE outbox ADD COLUMN published_at TIMESTAMPTZ;
+
+-- Relaying the unpublished events in order
+CREATE INDEX idx_outbox_unpublished ON outbox(position) WHERE published_at IS NULL;
+-- Reading the events of a user in order
+CREATE INDEX idx_outbox_user_id ON outbox(user_id, position);
@@ -0,0 +1,11 @@
+package v10Migration
+
+import (
+	"github.com/pedramktb/schwarzit-probearbeit/migration"
+	"go.uber.org/fx"
+)
+
+var FXV10MigrationProvide = fx.Provide(
+	create,
+	fx.Annotate(func(m *migrator) migration.Migrator { return m }, fx.ResultTags(`name:"v10Migrator"`)),
+)
@@ -0,0 +1,41 @@
+-- Addresses of users
+CREATE TYPE address_kind AS ENUM ('billing', 'shipping', 'home');
+
+CREATE TABLE addresses (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id UUID NOT NULL REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT
+);
+CREATE INDEX idx_addresses_deleted_at ON addresses(deleted_at);
+CREATE INDEX idx_addresses_user_id ON addresses(user_id);
+
+CREATE TABLE address_versions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    address_id UUID NOT NULL REFERENCES addresses(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+    kind address_kind NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    address address_domain NOT NULL
+);
+CREATE INDEX idx_address_versions_created_at ON address_versions(created_at);
+CREATE INDEX idx_address_versions_id ON address_versions(address_id);
+
+-- Prevent updates and deletes on immutable tables
+DO $$
+DECLARE
+    table_name TEXT;
+    tables TEXT[] := ARRAY[
+        'addresses',
+        'address_versions'
+    ];
+BEGIN
+    FOREACH table_name IN ARRAY tables LOOP
+        EXECUTE format('
+            CREATE TRIGGER trig_no_update_or_delete_%s
+            BEFORE UPDATE OR DELETE ON %s
+            FOR EACH ROW
+            EXECUTE FUNCTION func_no_update_or_delete()', table_name, table_name);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
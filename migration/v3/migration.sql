@@ -0,0 +1,6 @@
+-- Indexes supporting the sorting of users
+-- The latest version of each user is selected with DISTINCT ON (user_id) ordered by created_at DESC,
+-- which can be read from this index instead of sorting all versions before the result is sorted
+CREATE INDEX idx_user_versions_user_id_created_at ON user_versions(user_id, created_at DESC);
+-- Sorting by creation time with the id tiebreaker on the base table
+CREATE INDEX idx_users_created_at_id ON users(created_at, id) WHERE deleted_at IS NULL;
@@ -0,0 +1,11 @@
+package v3Migration
+
+import (
+	"github.com/pedramktb/schwarzit-probearbeit/migration"
+	"go.uber.org/fx"
+)
+
+var FXV3MigrationProvide = fx.Provide(
+	create,
+	fx.Annotate(func(m *migrator) migration.Migrator { return m }, fx.ResultTags(`name:"v3Migrator"`)),
+)
@@ -0,0 +1,13 @@
+-- Fuzzy search over users
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The text users are searched by, it has to be immutable to be indexed
+CREATE FUNCTION user_search_text(first_name TEXT, last_name TEXT, email TEXT, phone TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE PARALLEL SAFE AS $$
+    SELECT first_name || ' ' || last_name || ' ' || email || ' ' || phone
+$$;
+
+-- Trigram index supporting the word similarity operator (<%) over all versions,
+-- matching versions are used to preselect the users before their latest versions are ranked
+CREATE INDEX idx_user_versions_search ON user_versions
+    USING GIN (user_search_text(first_name, last_name, email, phone) gin_trgm_ops);
@@ -0,0 +1,51 @@
+-- Changes feed of users
+-- Indexes supporting the order of the changes by time with the change id tiebreaker,
+-- versions are the creates and updates and the deletion time of the base table the deletes
+CREATE INDEX idx_user_versions_created_at_id ON user_versions(created_at, id);
+CREATE INDEX idx_users_deleted_at_id ON users(deleted_at, id) WHERE deleted_at IS NOT NULL;
+
+-- Notify the listeners of the user_changes channel about every change, notifications are delivered on commit.
+-- The payload is the change as returned by the changes feed, without the password hash.
+-- A version created at the same time as its user is the first one, see User.ToSave
+CREATE FUNCTION func_notify_user_change() RETURNS TRIGGER AS $$
+DECLARE
+    payload JSON;
+BEGIN
+    IF TG_TABLE_NAME = 'user_versions' THEN
+        SELECT json_build_object(
+            'kind', CASE WHEN NEW.created_at = users.created_at THEN 'created' ELSE 'updated' END,
+            'change_id', NEW.id,
+            'changed_at', NEW.created_at,
+            'id', NEW.user_id,
+            'version_id', NEW.id,
+            'first_name', NEW.first_name,
+            'last_name', NEW.last_name,
+            'email', NEW.email,
+            'phone', NEW.phone,
+            'is_admin', NEW.is_admin,
+            'created_at', users.created_at,
+            'updated_at', NEW.created_at
+        ) INTO payload FROM users WHERE users.id = NEW.user_id;
+    ELSE
+        payload := json_build_object(
+            'kind', 'deleted',
+            'change_id', NEW.id,
+            'changed_at', NEW.deleted_at,
+            'id', NEW.id,
+            'created_at', NEW.created_at,
+            'updated_at', NEW.deleted_at
+        );
+    END IF;
+    PERFORM pg_notify('user_changes', payload::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trig_notify_user_change
+AFTER INSERT ON user_versions
+FOR EACH ROW EXECUTE FUNCTION func_notify_user_change();
+
+CREATE TRIGGER trig_notify_user_delete
+AFTER UPDATE OF deleted_at ON users
+FOR EACH ROW WHEN (OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL)
+EXECUTE FUNCTION func_notify_user_change();
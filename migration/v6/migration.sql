@@ -0,0 +1,67 @@
+-- Unique emails of users
+-- The email of the latest version of every non-deleted user, maintained by triggers on new versions and deletes.
+-- Its unique index makes the emails unique case-insensitively, while the history keeps the previous emails.
+CREATE TABLE user_emails (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+    email email_domain NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX idx_user_emails_email ON user_emails(lower(email));
+
+-- Existing users sharing an email can't be made unique without locking all but one of them out of their account,
+-- so the migration fails and lists them instead. They have to be resolved first, see the README.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(format('%s (users %s)', email, user_ids), '; ' ORDER BY email)
+    INTO duplicates
+    FROM (
+        SELECT lower(last_version.email) AS email, string_agg(users.id::TEXT, ', ' ORDER BY users.created_at, users.id) AS user_ids
+        FROM users
+        JOIN (
+            SELECT DISTINCT ON (user_id) user_id, email FROM user_versions ORDER BY user_id, created_at DESC
+        ) AS last_version ON users.id = last_version.user_id
+        WHERE users.deleted_at IS NULL
+        GROUP BY lower(last_version.email)
+        HAVING count(*) > 1
+    ) AS duplicate_emails;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users share emails case-insensitively: %', duplicates
+            USING HINT = 'Change the email of all but one user of each email or delete them, then migrate again';
+    END IF;
+END $$;
+
+INSERT INTO user_emails (user_id, email, updated_at)
+SELECT users.id, last_version.email, last_version.created_at
+FROM users
+JOIN (
+    SELECT DISTINCT ON (user_id) * FROM user_versions ORDER BY user_id, created_at DESC
+) AS last_version ON users.id = last_version.user_id
+WHERE users.deleted_at IS NULL;
+
+-- A version which is not newer than the current one (e.g. inserted out of order) doesn't change the email,
+-- an email taken by another user fails the insert of the version with a unique violation of idx_user_emails_email
+CREATE FUNCTION func_sync_user_email() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'user_versions' THEN
+        INSERT INTO user_emails (user_id, email, updated_at) VALUES (NEW.user_id, NEW.email, NEW.created_at)
+        ON CONFLICT (user_id) DO UPDATE SET email = EXCLUDED.email, updated_at = EXCLUDED.updated_at
+        WHERE user_emails.updated_at <= EXCLUDED.updated_at;
+    ELSE
+        DELETE FROM user_emails WHERE user_id = NEW.id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trig_sync_user_email
+AFTER INSERT ON user_versions
+FOR EACH ROW EXECUTE FUNCTION func_sync_user_email();
+
+-- Deleted users release their email
+CREATE TRIGGER trig_release_user_email
+AFTER UPDATE OF deleted_at ON users
+FOR EACH ROW WHEN (OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL)
+EXECUTE FUNCTION func_sync_user_email();
@@ -0,0 +1,30 @@
+-- Current versions of users
+-- A copy of the latest version of every user, maintained by the application in the transaction of every new version.
+-- Reads join it by the user instead of selecting the latest version out of all versions of all users.
+CREATE TABLE user_current (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+    id UUID NOT NULL UNIQUE REFERENCES user_versions(id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ NOT NULL,
+    first_name non_empty_text NOT NULL,
+    last_name non_empty_text NOT NULL,
+    email email_domain NOT NULL,
+    phone phone_domain NOT NULL,
+    is_admin BOOLEAN NOT NULL,
+    password_hash non_empty_large_text NOT NULL
+);
+
+INSERT INTO user_current (user_id, id, created_at, first_name, last_name, email, phone, is_admin, password_hash)
+SELECT DISTINCT ON (user_id) user_id, id, created_at, first_name, last_name, email, phone, is_admin, password_hash
+FROM user_versions
+ORDER BY user_id, created_at DESC;
+
+-- Sorting by the fields of the current versions with the id tiebreaker
+CREATE INDEX idx_user_current_first_name ON user_current(first_name, user_id);
+CREATE INDEX idx_user_current_last_name ON user_current(last_name, user_id);
+CREATE INDEX idx_user_current_email ON user_current(email, user_id);
+CREATE INDEX idx_user_current_created_at ON user_current(created_at, user_id);
+
+-- Trigram index of the current versions, searches no longer preselect the users through all versions
+CREATE INDEX idx_user_current_search ON user_current
+    USING GIN (user_search_text(first_name, last_name, email, phone) gin_trgm_ops);
+DROP INDEX idx_user_versions_search;
@@ -0,0 +1,12 @@
+-- Outbox of the domain events of users
+-- Events are written in the transaction of the change they describe and deleted by the relay once they are published,
+-- so that no event of a committed change is lost and no event of a rolled back change is published.
+CREATE TABLE outbox (
+    -- position orders the events in the order they were written, which occurred_at can't for events of one change
+    position BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    payload JSONB NOT NULL
+);
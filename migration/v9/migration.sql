@@ -0,0 +1,46 @@
+-- Webhooks
+-- Subscriptions of endpoints to kinds of user events, the secret signs the deliveries
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY,
+    url non_empty_large_text NOT NULL,
+    events TEXT[] NOT NULL CHECK (cardinality(events) > 0),
+    secret non_empty_text NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Deliveries of events to webhooks
+-- A delivery is created per event and subscribed webhook when the event is relayed from the outbox, an event relayed
+-- again is not delivered twice. Pending deliveries are sent once next_attempt_at is reached, failed attempts are
+-- retried with backoff until the delivery is dead.
+CREATE TYPE webhook_delivery_state AS ENUM ('pending', 'succeeded', 'dead');
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON UPDATE RESTRICT ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event JSONB NOT NULL,
+    state webhook_delivery_state NOT NULL,
+    attempts INTEGER NOT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (webhook_id, event_id)
+);
+
+-- Listing the deliveries of a webhook, newest first
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at);
+-- Claiming the due deliveries
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE state = 'pending';
+
+-- Log of the attempts of deliveries
+CREATE TABLE webhook_attempts (
+    id UUID PRIMARY KEY,
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON UPDATE RESTRICT ON DELETE CASCADE,
+    attempted_at TIMESTAMPTZ NOT NULL,
+    -- status_code is the status of the response, 0 if there was none
+    status_code INTEGER NOT NULL,
+    -- error is empty if the attempt succeeded
+    error TEXT NOT NULL,
+    -- duration of the attempt in nanoseconds
+    duration BIGINT NOT NULL
+);
+CREATE INDEX idx_webhook_attempts_delivery_id ON webhook_attempts(delivery_id, attempted_at);
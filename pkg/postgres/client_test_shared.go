@@ -7,7 +7,7 @@ import (
 
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/pedramktb/schwarzit-probearbeit/migration"
-	v1Migration "github.com/pedramktb/schwarzit-probearbeit/migration/v1"
+	migrationDI "github.com/pedramktb/schwarzit-probearbeit/migration/fx"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
 
@@ -58,7 +58,7 @@ func Test_Create_DB(ip, port, dbName string) *gorm.DB {
 
 	fx.New(
 		fx.Provide(func() *gorm.DB { return db }),
-		v1Migration.FXV1TestMigrationProvide,
+		migrationDI.FXMigrationModule,
 		fx.Invoke(func(m migration.Migrator) {
 			m.Migrate(context.Background())
 		}),